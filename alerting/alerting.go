@@ -0,0 +1,168 @@
+// Package alerting evaluates vital sign readings against alert rules.
+//
+// A rule either compares one parameter of the new reading with a threshold,
+// e.g. systolic pressure above 180, or looks for a trend: the parameter rose
+// (or fell) with every one of the last N readings. Rules are global or belong
+// to a single patient; a patient rule replaces the global rules with the same
+// parameter and condition, so that e.g. a chronically hypertensive patient
+// can be given a higher systolic threshold.
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	model "health-care-backend/repository/model"
+)
+
+const (
+	minTrendReadings = 2
+	maxTrendReadings = 10
+)
+
+// parameters maps the parameter names of rules to their value in a reading.
+// Optional values are missing from readings that lack them.
+var parameters = map[string]func(v model.VitalSign) (float64, bool){
+	"body_temperature":   func(v model.VitalSign) (float64, bool) { return v.BodyTemperature, true },
+	"pulse_rate":         func(v model.VitalSign) (float64, bool) { return float64(v.PulseRate), true },
+	"respiration_rate":   func(v model.VitalSign) (float64, bool) { return float64(v.RespirationRate), true },
+	"systolic_pressure":  func(v model.VitalSign) (float64, bool) { return float64(v.SystolicPressure), true },
+	"diastolic_pressure": func(v model.VitalSign) (float64, bool) { return float64(v.DiastolicPressure), true },
+	"oxygen_saturation": func(v model.VitalSign) (float64, bool) {
+		if v.OxygenSaturation == nil {
+			return 0, false
+		}
+		return float64(*v.OxygenSaturation), true
+	},
+}
+
+// Finding is a rule matched by a reading.
+type Finding struct {
+	Rule    model.AlertRule
+	Value   float64
+	Message string
+}
+
+// Validate checks a rule before it is stored.
+func Validate(r model.AlertRule) error {
+	if _, ok := parameters[r.Parameter]; !ok {
+		return errors.New("parameter must be one of body_temperature, pulse_rate, respiration_rate, systolic_pressure, diastolic_pressure or oxygen_saturation")
+	}
+	if r.Severity != model.AlertWarning && r.Severity != model.AlertCritical {
+		return errors.New("severity must be warning or critical")
+	}
+	switch r.Condition {
+	case model.AlertAbove, model.AlertBelow:
+		if r.Threshold == nil {
+			return errors.New("threshold is required for above and below rules")
+		}
+		if r.Readings != nil {
+			return errors.New("readings is only allowed for rising and falling rules")
+		}
+	case model.AlertRising, model.AlertFalling:
+		if r.Readings == nil || *r.Readings < minTrendReadings || *r.Readings > maxTrendReadings {
+			return fmt.Errorf("readings must be between %d and %d for rising and falling rules", minTrendReadings, maxTrendReadings)
+		}
+		if r.Threshold != nil {
+			return errors.New("threshold is only allowed for above and below rules")
+		}
+	default:
+		return errors.New("condition must be one of above, below, rising or falling")
+	}
+	return nil
+}
+
+// EffectiveRules drops the global rules that are replaced by a patient rule.
+// rules must only hold global rules and those of a single patient.
+func EffectiveRules(rules []model.AlertRule) []model.AlertRule {
+	replaced := make(map[[2]string]bool)
+	for _, r := range rules {
+		if r.PatientID != nil {
+			replaced[[2]string{r.Parameter, r.Condition}] = true
+		}
+	}
+	effective := make([]model.AlertRule, 0, len(rules))
+	for _, r := range rules {
+		if r.PatientID == nil && replaced[[2]string{r.Parameter, r.Condition}] {
+			continue
+		}
+		effective = append(effective, r)
+	}
+	return effective
+}
+
+// HistoryNeeded returns how many of the latest readings Evaluate needs to see.
+func HistoryNeeded(rules []model.AlertRule) int {
+	n := 1
+	for _, r := range rules {
+		if r.Readings != nil && *r.Readings > n {
+			n = *r.Readings
+		}
+	}
+	return n
+}
+
+// Evaluate matches the last reading of history, which is ordered oldest
+// first, against the rules.
+func Evaluate(rules []model.AlertRule, history []model.VitalSign) []Finding {
+	if len(history) == 0 {
+		return nil
+	}
+	var findings []Finding
+	for _, r := range rules {
+		value := parameters[r.Parameter]
+		if value == nil {
+			continue
+		}
+		current, ok := value(history[len(history)-1])
+		if !ok {
+			continue
+		}
+		var message string
+		switch r.Condition {
+		case model.AlertAbove:
+			if current > *r.Threshold {
+				message = fmt.Sprintf("%s %s is above %s", r.Parameter, format(current), format(*r.Threshold))
+			}
+		case model.AlertBelow:
+			if current < *r.Threshold {
+				message = fmt.Sprintf("%s %s is below %s", r.Parameter, format(current), format(*r.Threshold))
+			}
+		case model.AlertRising, model.AlertFalling:
+			if trend(history, value, *r.Readings, r.Condition == model.AlertRising) {
+				message = fmt.Sprintf("%s has been %s over the last %d readings, now %s", r.Parameter, r.Condition, *r.Readings, format(current))
+			}
+		}
+		if message != "" {
+			findings = append(findings, Finding{Rule: r, Value: current, Message: message})
+		}
+	}
+	return findings
+}
+
+// trend reports whether each of the last n readings is strictly higher
+// (rising) or lower than the one before it. Readings without the value break
+// the trend.
+func trend(history []model.VitalSign, value func(model.VitalSign) (float64, bool), n int, rising bool) bool {
+	if len(history) < n {
+		return false
+	}
+	window := history[len(history)-n:]
+	prev, ok := value(window[0])
+	if !ok {
+		return false
+	}
+	for _, v := range window[1:] {
+		cur, ok := value(v)
+		if !ok || (rising && cur <= prev) || (!rising && cur >= prev) {
+			return false
+		}
+		prev = cur
+	}
+	return true
+}
+
+func format(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
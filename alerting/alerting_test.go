@@ -0,0 +1,78 @@
+package alerting
+
+import (
+	"testing"
+
+	model "health-care-backend/repository/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int           { return &v }
+func floatPtr(v float64) *float64 { return &v }
+
+func reading(systolic int, temperature float64) model.VitalSign {
+	return model.VitalSign{
+		BodyTemperature:   temperature,
+		PulseRate:         70,
+		RespirationRate:   16,
+		SystolicPressure:  systolic,
+		DiastolicPressure: 80,
+	}
+}
+
+func Test_EvaluateThresholds(t *testing.T) {
+	rules := []model.AlertRule{
+		{RuleID: 1, Parameter: "systolic_pressure", Condition: model.AlertAbove, Threshold: floatPtr(180), Severity: model.AlertCritical},
+		{RuleID: 2, Parameter: "body_temperature", Condition: model.AlertAbove, Threshold: floatPtr(101.5), Severity: model.AlertWarning},
+		{RuleID: 3, Parameter: "oxygen_saturation", Condition: model.AlertBelow, Threshold: floatPtr(92), Severity: model.AlertCritical},
+	}
+	findings := Evaluate(rules, []model.VitalSign{reading(185, 100.1)})
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, 1, findings[0].Rule.RuleID)
+		assert.Equal(t, 185.0, findings[0].Value)
+		assert.Equal(t, "systolic_pressure 185 is above 180", findings[0].Message)
+	}
+
+	assert.Empty(t, Evaluate(rules, []model.VitalSign{reading(180, 101.5)}))
+
+	hypoxic := reading(120, 98.6)
+	hypoxic.OxygenSaturation = intPtr(89)
+	findings = Evaluate(rules, []model.VitalSign{hypoxic})
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, 3, findings[0].Rule.RuleID)
+	}
+}
+
+func Test_EvaluateTrend(t *testing.T) {
+	rules := []model.AlertRule{
+		{RuleID: 1, Parameter: "systolic_pressure", Condition: model.AlertRising, Readings: intPtr(3), Severity: model.AlertWarning},
+	}
+	assert.Len(t, Evaluate(rules, []model.VitalSign{reading(120, 98.6), reading(130, 98.6), reading(140, 98.6)}), 1)
+	assert.Empty(t, Evaluate(rules, []model.VitalSign{reading(130, 98.6), reading(130, 98.6), reading(140, 98.6)}))
+	assert.Empty(t, Evaluate(rules, []model.VitalSign{reading(130, 98.6), reading(140, 98.6)}))
+	assert.Equal(t, 3, HistoryNeeded(rules))
+}
+
+func Test_EffectiveRules(t *testing.T) {
+	global := model.AlertRule{RuleID: 1, Parameter: "systolic_pressure", Condition: model.AlertAbove, Threshold: floatPtr(180)}
+	fever := model.AlertRule{RuleID: 2, Parameter: "body_temperature", Condition: model.AlertAbove, Threshold: floatPtr(101.5)}
+	own := model.AlertRule{RuleID: 3, PatientID: intPtr(1), Parameter: "systolic_pressure", Condition: model.AlertAbove, Threshold: floatPtr(200)}
+	assert.Equal(t, []model.AlertRule{fever, own}, EffectiveRules([]model.AlertRule{global, fever, own}))
+}
+
+func Test_Validate(t *testing.T) {
+	assert.NoError(t, Validate(model.AlertRule{Parameter: "pulse_rate", Condition: model.AlertAbove, Threshold: floatPtr(130), Severity: model.AlertCritical}))
+	assert.NoError(t, Validate(model.AlertRule{Parameter: "pulse_rate", Condition: model.AlertRising, Readings: intPtr(3), Severity: model.AlertWarning}))
+
+	invalid := map[string]model.AlertRule{
+		"parameter":         {Parameter: "mood", Condition: model.AlertAbove, Threshold: floatPtr(1), Severity: model.AlertWarning},
+		"severity":          {Parameter: "pulse_rate", Condition: model.AlertAbove, Threshold: floatPtr(1), Severity: "urgent"},
+		"missing threshold": {Parameter: "pulse_rate", Condition: model.AlertAbove, Severity: model.AlertWarning},
+		"short trend":       {Parameter: "pulse_rate", Condition: model.AlertFalling, Readings: intPtr(1), Severity: model.AlertWarning},
+		"condition":         {Parameter: "pulse_rate", Condition: "equals", Threshold: floatPtr(1), Severity: model.AlertWarning},
+	}
+	for name, r := range invalid {
+		assert.Error(t, Validate(r), name)
+	}
+}
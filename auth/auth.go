@@ -0,0 +1,197 @@
+// Package auth issues and verifies the JWT bearer tokens of the API.
+//
+// Tokens are signed with HS256 using a shared secret or with RS256. RS256
+// tokens are verified against the public keys of a local JWKS file, picked by
+// the token's "kid" header, so tokens of an external identity provider can be
+// accepted without calling it. Tokens issued by the login endpoint are signed
+// with the RS256 private key when one is configured and with the HS256
+// secret otherwise.
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed, expired, not
+	// signed by a trusted key or lack the identity claims.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrNoSigningKey is returned by Issue when neither an HS256 secret nor an
+	// RS256 private key is configured.
+	ErrNoSigningKey = errors.New("no signing key configured")
+)
+
+type Config struct {
+	HS256Secret string
+	// JWKSFile holds the RS256 public keys trusted for verification.
+	JWKSFile string
+	// RS256PrivateKeyFile is a PEM encoded RSA key used to sign issued tokens.
+	// Its public key is trusted under RS256KeyID.
+	RS256PrivateKeyFile string
+	RS256KeyID          string
+	Issuer              string
+	TTL                 time.Duration
+}
+
+// Identity is the authenticated caller. At most one of DoctorID, NurseID and
+// PatientID is set, depending on Role.
+type Identity struct {
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	DoctorID  *int   `json:"doctor_id,omitempty"`
+	NurseID   *int   `json:"nurse_id,omitempty"`
+	PatientID *int   `json:"patient_id,omitempty"`
+}
+
+// Claims are the JWT claims. The subject is the user id.
+type Claims struct {
+	jwt.RegisteredClaims
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	DoctorID  *int   `json:"doctor_id,omitempty"`
+	NurseID   *int   `json:"nurse_id,omitempty"`
+	PatientID *int   `json:"patient_id,omitempty"`
+}
+
+type Authenticator struct {
+	hs256Secret []byte
+	rsaKeys     map[string]*rsa.PublicKey
+	signingKey  *rsa.PrivateKey
+	signingKID  string
+	issuer      string
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+// New loads the configured keys. At least one verification key is required.
+func New(cfg Config) (*Authenticator, error) {
+	a := &Authenticator{
+		hs256Secret: []byte(cfg.HS256Secret),
+		rsaKeys:     map[string]*rsa.PublicKey{},
+		issuer:      cfg.Issuer,
+		ttl:         cfg.TTL,
+		now:         time.Now,
+	}
+	if cfg.HS256Secret != "" && len(cfg.HS256Secret) < 32 {
+		return nil, errors.New("the HS256 secret must be at least 32 bytes long")
+	}
+	if cfg.JWKSFile != "" {
+		data, err := os.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		if a.rsaKeys, err = parseJWKS(data); err != nil {
+			return nil, fmt.Errorf("JWKS file %s: %w", cfg.JWKSFile, err)
+		}
+	}
+	if cfg.RS256PrivateKeyFile != "" {
+		data, err := os.ReadFile(cfg.RS256PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if a.signingKey, err = jwt.ParseRSAPrivateKeyFromPEM(data); err != nil {
+			return nil, fmt.Errorf("RS256 private key file %s: %w", cfg.RS256PrivateKeyFile, err)
+		}
+		a.signingKID = cfg.RS256KeyID
+		a.rsaKeys[a.signingKID] = &a.signingKey.PublicKey
+	}
+	if len(a.hs256Secret) == 0 && len(a.rsaKeys) == 0 {
+		return nil, errors.New("configure an HS256 secret, a JWKS file or an RS256 private key")
+	}
+	if a.ttl <= 0 {
+		return nil, errors.New("the token TTL must be positive")
+	}
+	return a, nil
+}
+
+// Issue signs a token for the identity. It returns the token and its expiry.
+func (a *Authenticator) Issue(id Identity) (string, time.Time, error) {
+	now := a.now()
+	expiresAt := now.Add(a.ttl)
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    a.issuer,
+			Subject:   strconv.Itoa(id.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Username:  id.Username,
+		Role:      id.Role,
+		DoctorID:  id.DoctorID,
+		NurseID:   id.NurseID,
+		PatientID: id.PatientID,
+	}
+	var (
+		signed string
+		err    error
+	)
+	switch {
+	case a.signingKey != nil:
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = a.signingKID
+		signed, err = token.SignedString(a.signingKey)
+	case len(a.hs256Secret) > 0:
+		signed, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.hs256Secret)
+	default:
+		return "", time.Time{}, ErrNoSigningKey
+	}
+	return signed, expiresAt, err
+}
+
+// Verify checks the signature, expiry and issuer of the token and returns the
+// identity it carries.
+func (a *Authenticator) Verify(tokenString string) (Identity, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(a.now),
+	}
+	if a.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(a.issuer))
+	}
+	var claims Claims
+	if _, err := jwt.ParseWithClaims(tokenString, &claims, a.key, opts...); err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	uid, err := strconv.Atoi(claims.Subject)
+	if err != nil || claims.Role == "" {
+		return Identity{}, fmt.Errorf("%w: missing subject or role", ErrInvalidToken)
+	}
+	return Identity{
+		UserID:    uid,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		DoctorID:  claims.DoctorID,
+		NurseID:   claims.NurseID,
+		PatientID: claims.PatientID,
+	}, nil
+}
+
+// key picks the verification key by algorithm and, for RS256, by key id.
+func (a *Authenticator) key(token *jwt.Token) (interface{}, error) {
+	switch token.Method {
+	case jwt.SigningMethodHS256:
+		if len(a.hs256Secret) == 0 {
+			return nil, errors.New("HS256 tokens are not accepted")
+		}
+		return a.hs256Secret, nil
+	case jwt.SigningMethodRS256:
+		kid, _ := token.Header["kid"].(string)
+		key, ok := a.rsaKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+	}
+}
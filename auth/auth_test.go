@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func intPtr(i int) *int { return &i }
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func jwksFor(t *testing.T, kid string, key *rsa.PublicKey) []byte {
+	data, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	require.NoError(t, err)
+	return data
+}
+
+func Test_IssueVerifyHS256(t *testing.T) {
+	a, err := New(Config{HS256Secret: testSecret, Issuer: "test", TTL: time.Hour})
+	require.NoError(t, err)
+
+	want := Identity{UserID: 7, Username: "john.doe", Role: "doctor", DoctorID: intPtr(1)}
+	token, expiresAt, err := a.Issue(want)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, time.Minute)
+
+	got, err := a.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	other, err := New(Config{HS256Secret: "fedcba9876543210fedcba9876543210", Issuer: "test", TTL: time.Hour})
+	require.NoError(t, err)
+	_, err = other.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func Test_VerifyRejects(t *testing.T) {
+	a, err := New(Config{HS256Secret: testSecret, Issuer: "test", TTL: time.Hour})
+	require.NoError(t, err)
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+		require.NoError(t, err)
+		return token
+	}
+	now := time.Now()
+	cases := map[string]string{
+		"expired":        sign(jwt.MapClaims{"sub": "1", "role": "admin", "iss": "test", "exp": now.Add(-time.Minute).Unix()}),
+		"no expiry":      sign(jwt.MapClaims{"sub": "1", "role": "admin", "iss": "test"}),
+		"wrong issuer":   sign(jwt.MapClaims{"sub": "1", "role": "admin", "iss": "other", "exp": now.Add(time.Hour).Unix()}),
+		"no role":        sign(jwt.MapClaims{"sub": "1", "iss": "test", "exp": now.Add(time.Hour).Unix()}),
+		"non-numeric id": sign(jwt.MapClaims{"sub": "root", "role": "admin", "iss": "test", "exp": now.Add(time.Hour).Unix()}),
+		"garbage":        "not.a.token",
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+		"sub": "1", "role": "admin", "iss": "test", "exp": now.Add(time.Hour).Unix(),
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	cases["alg none"] = unsigned
+
+	for name, token := range cases {
+		_, err := a.Verify(token)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+}
+
+func Test_IssueVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyFile := writeFile(t, "key.pem", pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+
+	issuer, err := New(Config{RS256PrivateKeyFile: keyFile, RS256KeyID: "k1", Issuer: "test", TTL: time.Hour})
+	require.NoError(t, err)
+	token, _, err := issuer.Issue(Identity{UserID: 1, Username: "admin", Role: "admin"})
+	require.NoError(t, err)
+
+	// a verifier that only knows the public key from a JWKS file
+	verifier, err := New(Config{JWKSFile: writeFile(t, "jwks.json", jwksFor(t, "k1", &key.PublicKey)), Issuer: "test", TTL: time.Hour})
+	require.NoError(t, err)
+	id, err := verifier.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", id.Username)
+
+	unknownKID, err := New(Config{JWKSFile: writeFile(t, "jwks.json", jwksFor(t, "k2", &key.PublicKey)), Issuer: "test", TTL: time.Hour})
+	require.NoError(t, err)
+	_, err = unknownKID.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// a verifier without a private key or secret cannot issue tokens
+	_, _, err = verifier.Issue(Identity{UserID: 1, Role: "admin"})
+	assert.ErrorIs(t, err, ErrNoSigningKey)
+}
+
+func Test_NewRequiresKeys(t *testing.T) {
+	_, err := New(Config{TTL: time.Hour})
+	assert.Error(t, err)
+	_, err = New(Config{HS256Secret: "short", TTL: time.Hour})
+	assert.Error(t, err)
+}
+
+func Test_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, err := New(Config{HS256Secret: testSecret, TTL: time.Hour})
+	require.NoError(t, err)
+	router := gin.New()
+	router.GET("/me", Middleware(a), func(ctx *gin.Context) {
+		id, _ := IdentityFrom(ctx)
+		ctx.JSON(http.StatusOK, id)
+	})
+	token, _, err := a.Issue(Identity{UserID: 3, Username: "emily.wilson", Role: "nurse", NurseID: intPtr(1)})
+	require.NoError(t, err)
+
+	for header, status := range map[string]int{
+		"":                 http.StatusUnauthorized,
+		"Basic abc":        http.StatusUnauthorized,
+		"Bearer invalid":   http.StatusUnauthorized,
+		"Bearer " + token:  http.StatusOK,
+		"bearer  " + token: http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, status, rec.Code, header)
+		if status == http.StatusOK {
+			assert.Contains(t, rec.Body.String(), `"nurse_id":1`)
+		} else {
+			assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func Test_CheckPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	require.NoError(t, err)
+	assert.True(t, CheckPassword(hash, "correct horse"))
+	assert.False(t, CheckPassword(hash, "wrong horse"))
+	assert.False(t, CheckPassword("", "correct horse"))
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const identityKey = "auth.identity"
+
+// Middleware rejects requests without a valid bearer token and stores the
+// caller's identity in the gin context.
+func Middleware(a *Authenticator) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		header := ctx.GetHeader("Authorization")
+		scheme, token, found := strings.Cut(header, " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			unauthorized(ctx, "missing bearer token")
+			return
+		}
+		id, err := a.Verify(strings.TrimSpace(token))
+		if err != nil {
+			unauthorized(ctx, "invalid or expired token")
+			return
+		}
+		SetIdentity(ctx, id)
+		ctx.Next()
+	}
+}
+
+// SetIdentity stores the caller's identity in the gin context.
+func SetIdentity(ctx *gin.Context, id Identity) {
+	ctx.Set(identityKey, id)
+}
+
+// IdentityFrom returns the identity stored by Middleware.
+func IdentityFrom(ctx *gin.Context) (Identity, bool) {
+	id, ok := ctx.Get(identityKey)
+	if !ok {
+		return Identity{}, false
+	}
+	identity, ok := id.(Identity)
+	return identity, ok
+}
+
+// unauthorized answers 401 with the error body of package routes.
+func unauthorized(ctx *gin.Context, msg string) {
+	ctx.Header("WWW-Authenticate", `Bearer realm="health-care"`)
+	body := gin.H{"code": "unauthorized", "error": msg}
+	if id := ctx.Writer.Header().Get("X-Request-ID"); id != "" {
+		body["request_id"] = id
+	}
+	ctx.AbortWithStatusJSON(http.StatusUnauthorized, body)
+}
@@ -1,23 +1,109 @@
 package envconfig
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
 
 type (
 	Env struct {
 		Port         int    `envconfig:"PORT" default:"5500" required:"true"`
-		DATABASE_URL string `envconfig:"DATABASE_URL" required:"true"`
+		DATABASE_URL string `envconfig:"DATABASE_URL" required:"true" secret:"dsn"`
+		// QueryTimeout bounds each repository call; requests whose queries
+		// run longer answer 504. Zero disables the timeout.
+		QueryTimeout time.Duration `envconfig:"QUERY_TIMEOUT" default:"5s"`
+		// Database pool settings; a SQLite database keeps a single
+		// connection. The first connection is tried DBConnectAttempts times,
+		// waiting DBConnectBackoff after the first failure and twice as long
+		// after every further one.
+		DBMaxOpenConns    int           `envconfig:"DB_MAX_OPEN_CONNS" default:"25"`
+		DBMaxIdleConns    int           `envconfig:"DB_MAX_IDLE_CONNS" default:"5"`
+		DBConnMaxLifetime time.Duration `envconfig:"DB_CONN_MAX_LIFETIME" default:"30m"`
+		DBConnectAttempts int           `envconfig:"DB_CONNECT_ATTEMPTS" default:"5"`
+		DBConnectBackoff  time.Duration `envconfig:"DB_CONNECT_BACKOFF" default:"1s"`
+		// HTTP server timeouts. The write timeout does not apply to the
+		// dashboard streams. On SIGINT or SIGTERM the server stops accepting
+		// connections and waits up to ShutdownTimeout for running requests.
+		ReadTimeout     time.Duration `envconfig:"HTTP_READ_TIMEOUT" default:"15s"`
+		WriteTimeout    time.Duration `envconfig:"HTTP_WRITE_TIMEOUT" default:"30s"`
+		IdleTimeout     time.Duration `envconfig:"HTTP_IDLE_TIMEOUT" default:"2m"`
+		ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"20s"`
+		// MigrationMode is "up" to apply pending migrations at startup,
+		// "check" to refuse to start when the schema is behind, or "down" to
+		// roll back the latest migration and exit.
+		MigrationMode string `envconfig:"MIGRATION_MODE" default:"up"`
+		// SeedDemoData loads fixtures after migrating: SeedFile when set,
+		// otherwise the built-in demo data set. Never enable in production.
+		SeedDemoData bool   `envconfig:"SEED_DEMO_DATA" default:"false"`
+		SeedFile     string `envconfig:"SEED_FILE"`
+		// JWT settings, see package auth. At least one of the HS256 secret,
+		// the JWKS file and the RS256 private key must be set.
+		JWTHS256Secret         string        `envconfig:"JWT_HS256_SECRET" secret:"true"`
+		JWTJWKSFile            string        `envconfig:"JWT_JWKS_FILE"`
+		JWTRS256PrivateKeyFile string        `envconfig:"JWT_RS256_PRIVATE_KEY_FILE"`
+		JWTRS256KeyID          string        `envconfig:"JWT_RS256_KEY_ID" default:"health-care-backend"`
+		JWTIssuer              string        `envconfig:"JWT_ISSUER" default:"health-care-backend"`
+		JWTTTL                 time.Duration `envconfig:"JWT_TTL" default:"1h"`
+		// BootstrapAdminUsername creates an admin account with
+		// BootstrapAdminPassword at startup unless the username exists.
+		BootstrapAdminUsername string `envconfig:"BOOTSTRAP_ADMIN_USERNAME"`
+		BootstrapAdminPassword string `envconfig:"BOOTSTRAP_ADMIN_PASSWORD" secret:"true"`
 	}
 )
 
+// masked replaces secrets in Settings.
+const masked = "******"
+
+// dsnPassword matches the password of a key/value postgres DSN.
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
 func Process(env *Env) error {
-	return envconfig.Process("", env)
+	if err := envconfig.Process("", env); err != nil {
+		return err
+	}
+	if env.DBConnectAttempts < 1 {
+		return fmt.Errorf("DB_CONNECT_ATTEMPTS must be at least 1, got %d", env.DBConnectAttempts)
+	}
+	return nil
+}
+
+// Setting is an environment variable and the value in effect.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Settings lists every variable of the configuration in declaration order,
+// for logging at startup. Fields tagged secret:"true" are masked when set,
+// and the password of a secret:"dsn" field.
+func (env *Env) Settings() []Setting {
+	v := reflect.ValueOf(env).Elem()
+	settings := make([]Setting, 0, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := fmt.Sprint(v.Field(i).Interface())
+		switch field.Tag.Get("secret") {
+		case "true":
+			if value != "" {
+				value = masked
+			}
+		case "dsn":
+			value = maskDSN(value)
+		}
+		settings = append(settings, Setting{Name: field.Tag.Get("envconfig"), Value: value})
+	}
+	return settings
 }
 
-func New() (*Env, error) {
-	var env Env
-	err := envconfig.Process("", &env)
-	if err != nil {
-		return nil, err
+// maskDSN masks the password of a postgres URL or key/value DSN.
+func maskDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		return u.Redacted()
 	}
-	return &env, nil
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+masked)
 }
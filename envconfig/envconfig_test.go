@@ -0,0 +1,31 @@
+package envconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Settings(t *testing.T) {
+	t.Setenv("DATABASE_URL", "sslmode=disable host=db user=app password='s3cr et' dbname=health-care")
+	t.Setenv("JWT_HS256_SECRET", "dev-only-secret")
+	var env Env
+	assert.NoError(t, Process(&env))
+
+	settings := map[string]string{}
+	for _, s := range env.Settings() {
+		settings[s.Name] = s.Value
+	}
+	assert.Equal(t, "sslmode=disable host=db user=app password=****** dbname=health-care", settings["DATABASE_URL"])
+	assert.Equal(t, "******", settings["JWT_HS256_SECRET"])
+	assert.Equal(t, "", settings["BOOTSTRAP_ADMIN_PASSWORD"])
+	assert.Equal(t, "5500", settings["PORT"])
+	assert.Equal(t, "5s", settings["QUERY_TIMEOUT"])
+
+	assert.Equal(t, "postgres://app:xxxxx@db:5432/health-care", maskDSN("postgres://app:s3cret@db:5432/health-care"))
+	assert.Equal(t, "postgres://db/health-care", maskDSN("postgres://db/health-care"))
+	assert.Equal(t, "sqlite::memory:", maskDSN("sqlite::memory:"))
+
+	t.Setenv("DB_CONNECT_ATTEMPTS", "0")
+	assert.Error(t, Process(&env))
+}
@@ -0,0 +1,108 @@
+// Package events fans out notifications about changed patients to the
+// dashboard streams of this server.
+//
+// Changes are announced by database triggers with postgres NOTIFY, so every
+// replica hears about writes made through any other replica. A notification
+// only says which patient changed; subscribers reload what they show of that
+// patient.
+package events
+
+import (
+	"sort"
+	"sync"
+)
+
+// AllPatients is published when changes may have been missed, e.g. while the
+// listener reconnected. Subscribers reload everything.
+const AllPatients = 0
+
+// Broker delivers published patient ids to every subscription.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed chan struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*Subscription]struct{}), closed: make(chan struct{})}
+}
+
+// Close tells the subscribers that the server shuts down, see Closed. It may
+// be called more than once.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	select {
+	case <-b.closed:
+	default:
+		close(b.closed)
+	}
+}
+
+// Closed is closed by Close. Subscribers end their streams then, so that the
+// server can finish shutting down; clients reconnect to another replica.
+func (b *Broker) Closed() <-chan struct{} {
+	return b.closed
+}
+
+// Subscription collects the ids published since the last Take. Publishing
+// never blocks on a slow subscriber: repeated changes of a patient are folded
+// into one.
+type Subscription struct {
+	mu      sync.Mutex
+	pending map[int]struct{}
+	ready   chan struct{}
+}
+
+// Subscribe starts collecting ids. Call Unsubscribe when done.
+func (b *Broker) Subscribe() *Subscription {
+	s := &Subscription{pending: make(map[int]struct{}), ready: make(chan struct{}, 1)}
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+func (b *Broker) Unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	delete(b.subs, s)
+	b.mu.Unlock()
+}
+
+// Publish announces a change of the patient, or AllPatients.
+func (b *Broker) Publish(pid int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for s := range b.subs {
+		s.add(pid)
+	}
+}
+
+func (s *Subscription) add(pid int) {
+	s.mu.Lock()
+	s.pending[pid] = struct{}{}
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Ready receives once ids are pending.
+func (s *Subscription) Ready() <-chan struct{} {
+	return s.ready
+}
+
+// Take returns the pending ids in ascending order, so AllPatients comes
+// first, and clears them.
+func (s *Subscription) Take() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pids := make([]int, 0, len(s.pending))
+	for pid := range s.pending {
+		pids = append(pids, pid)
+	}
+	s.pending = make(map[int]struct{})
+	sort.Ints(pids)
+	return pids
+}
@@ -0,0 +1,41 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BrokerFoldsChanges(t *testing.T) {
+	b := NewBroker()
+	s := b.Subscribe()
+	other := b.Subscribe()
+	b.Unsubscribe(other)
+
+	b.Publish(3)
+	b.Publish(1)
+	b.Publish(3)
+	<-s.Ready()
+	assert.Equal(t, []int{1, 3}, s.Take())
+	assert.Empty(t, s.Take())
+	assert.Empty(t, other.Take())
+
+	select {
+	case <-s.Ready():
+		t.Error("nothing is pending")
+	default:
+	}
+}
+
+func Test_BrokerClose(t *testing.T) {
+	b := NewBroker()
+	select {
+	case <-b.Closed():
+		t.Error("broker is open")
+	default:
+	}
+	b.Close()
+	b.Close()
+	_, open := <-b.Closed()
+	assert.False(t, open)
+}
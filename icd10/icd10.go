@@ -0,0 +1,90 @@
+// Package icd10 is an offline lookup table of ICD-10-CM diagnosis codes.
+//
+// The bundled table in codes.csv is a subset covering the diagnoses commonly
+// seen on the ward. Codes that are not in it cannot be recorded, so extend the
+// file when clinicians need one that is missing.
+package icd10
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed codes.csv
+var codesCSV []byte
+
+// Code is a billable ICD-10-CM code, e.g. "E11.9".
+type Code struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+var (
+	codes  []Code
+	byCode map[string]Code
+)
+
+func init() {
+	var err error
+	if codes, err = parse(codesCSV); err != nil {
+		panic(fmt.Sprintf("icd10: bundled code table: %v", err))
+	}
+	byCode = make(map[string]Code, len(codes))
+	for _, c := range codes {
+		byCode[c.Code] = c
+	}
+}
+
+func parse(data []byte) ([]Code, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 || len(rows[0]) != 2 || rows[0][0] != "code" {
+		return nil, fmt.Errorf("missing code,description header")
+	}
+	parsed := make([]Code, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		parsed = append(parsed, Code{Code: Normalize(row[0]), Description: row[1]})
+	}
+	sort.Slice(parsed, func(i, j int) bool { return parsed[i].Code < parsed[j].Code })
+	return parsed, nil
+}
+
+// Normalize upper-cases the code and inserts the dot after the category, so
+// "e119" and "E11.9" are the same code.
+func Normalize(code string) string {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), ".", ""))
+	if len(code) > 3 {
+		code = code[:3] + "." + code[3:]
+	}
+	return code
+}
+
+// Lookup returns the code from the bundled table.
+func Lookup(code string) (Code, bool) {
+	c, ok := byCode[Normalize(code)]
+	return c, ok
+}
+
+// Search returns up to limit codes, in code order, whose code starts with
+// query or whose description contains it, ignoring case.
+func Search(query string, limit int) []Code {
+	query = strings.TrimSpace(query)
+	prefix := Normalize(query)
+	words := strings.ToLower(query)
+	found := make([]Code, 0)
+	for _, c := range codes {
+		if len(found) == limit {
+			break
+		}
+		if strings.HasPrefix(c.Code, prefix) || strings.Contains(strings.ToLower(c.Description), words) {
+			found = append(found, c)
+		}
+	}
+	return found
+}
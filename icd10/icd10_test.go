@@ -0,0 +1,39 @@
+package icd10
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Normalize(t *testing.T) {
+	assert.Equal(t, "E11.9", Normalize(" e119 "))
+	assert.Equal(t, "E11.9", Normalize("E11.9"))
+	assert.Equal(t, "I10", Normalize("i10"))
+	assert.Equal(t, "S06.0X0A", Normalize("s060x0a"))
+}
+
+func Test_Lookup(t *testing.T) {
+	c, ok := Lookup("i10")
+	assert.True(t, ok)
+	assert.Equal(t, "Essential (primary) hypertension", c.Description)
+
+	_, ok = Lookup("X99.9")
+	assert.False(t, ok)
+}
+
+func Test_Search(t *testing.T) {
+	byPrefix := Search("E11", 10)
+	assert.NotEmpty(t, byPrefix)
+	for _, c := range byPrefix {
+		assert.Equal(t, "E11", c.Code[:3])
+	}
+
+	byWord := Search("ASTHMA", 10)
+	assert.NotEmpty(t, byWord)
+	for _, c := range byWord {
+		assert.Contains(t, c.Description, "asthma")
+	}
+
+	assert.Len(t, Search("", 5), 5)
+}
@@ -0,0 +1,120 @@
+// Package logging keeps protected health information out of the logs.
+//
+// Redact wraps a zap logger so that fields which can hold patient data are
+// masked before they are written: fields named like names, phone numbers,
+// addresses or birth dates are dropped to a placeholder, values that zap
+// would render from arbitrary structs are not rendered at all, and error
+// texts and messages have quoted values, dates and phone numbers removed,
+// since database errors echo the offending input.
+package logging
+
+import (
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted replaces masked values.
+const Redacted = "[REDACTED]"
+
+// phiKeys are the field keys whose values are always masked, normalized by
+// normalizeKey.
+var phiKeys = map[string]bool{
+	"name": true, "firstname": true, "lastname": true, "fullname": true, "patientname": true,
+	"phone": true, "phonenumber": true, "address": true, "email": true,
+	"dob": true, "dateofbirth": true, "birthdate": true,
+	"ssn": true, "reason": true, "records": true, "patient": true,
+}
+
+var sensitiveText = []*regexp.Regexp{
+	regexp.MustCompile(`"[^"]*"`),
+	regexp.MustCompile(`'[^']*'`),
+	regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`),
+	regexp.MustCompile(`\b\d{1,2}/\d{1,2}/\d{2,4}\b`),
+	regexp.MustCompile(`\+?\(?\b\d{3}\)?[-. ]\d{3}[-. ]\d{4}\b`),
+}
+
+// Redact returns a logger that masks patient data, see the package comment.
+func Redact(logger *zap.Logger) *zap.Logger {
+	return logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &redactingCore{Core: core}
+	}))
+}
+
+// RedactText removes quoted values, dates and phone numbers from s.
+func RedactText(s string) string {
+	for _, re := range sensitiveText {
+		s = re.ReplaceAllStringFunc(s, func(match string) string {
+			switch match[0] {
+			case '"':
+				return `"` + Redacted + `"`
+			case '\'':
+				return `'` + Redacted + `'`
+			}
+			return Redacted
+		})
+	}
+	return s
+}
+
+type redactingCore struct {
+	zapcore.Core
+}
+
+func (c *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactingCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = RedactText(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, 0, len(fields))
+	for _, f := range fields {
+		redacted = append(redacted, redactField(f))
+	}
+	return redacted
+}
+
+func redactField(f zapcore.Field) zapcore.Field {
+	if phiKeys[normalizeKey(f.Key)] {
+		return zap.String(f.Key, Redacted)
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, RedactText(f.String))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, RedactText(err.Error()))
+		}
+		return zap.String(f.Key, Redacted)
+	case zapcore.ReflectType, zapcore.StringerType, zapcore.ObjectMarshalerType,
+		zapcore.ArrayMarshalerType, zapcore.ByteStringType, zapcore.BinaryType:
+		// these render arbitrary values, e.g. whole database records
+		return zap.String(f.Key, Redacted)
+	}
+	return f
+}
+
+func normalizeKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return -1
+	}, key)
+}
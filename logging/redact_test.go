@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_RedactText(t *testing.T) {
+	assert.Equal(t, `invalid input syntax for type date: "[REDACTED]"`,
+		RedactText(`invalid input syntax for type date: "1988-13-12"`))
+	assert.Equal(t, "born [REDACTED], call [REDACTED]", RedactText("born 1988-03-12, call 123-456-7890"))
+	assert.Equal(t, "patient 42 not found", RedactText("patient 42 not found"))
+}
+
+func Test_Redact(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := Redact(zap.New(core)).With(zap.String("first_name", "Alice"))
+
+	logger.Info("patient updated",
+		zap.String("Last Name", "Johnson"),
+		zap.String("phone_number", "123-456-7891"),
+		zap.Int("patient id", 1),
+		zap.Error(errors.New(`duplicate value "Alice Johnson"`)),
+		zap.Any("row", struct{ Address string }{"123 Main St"}))
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, Redacted, fields["first_name"])
+		assert.Equal(t, Redacted, fields["Last Name"])
+		assert.Equal(t, Redacted, fields["phone_number"])
+		assert.Equal(t, int64(1), fields["patient id"])
+		assert.Equal(t, `duplicate value "[REDACTED]"`, fields["error"])
+		assert.Equal(t, Redacted, fields["row"])
+	}
+}
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"health-care-backend/auth"
 	"health-care-backend/envconfig"
+	"health-care-backend/events"
+	"health-care-backend/logging"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"health-care-backend/repository"
+	model "health-care-backend/repository/model"
 
 	"go.uber.org/zap"
 
@@ -22,33 +31,185 @@ func main() {
 	var err error
 	logger, err = zap.NewProduction()
 	if err != nil {
-		logger.Error("failed to initialize logger ", zap.String("error message", err.Error()))
+		fmt.Fprintln(os.Stderr, "failed to initialize logger:", err)
+		os.Exit(1)
 	}
+	logger = logging.Redact(logger)
 
 	err = envconfig.Process(&env)
 	if err != nil {
-		logger.Error("failed to load config from env vars ", zap.String("error message", err.Error()))
+		logger.Fatal("failed to load config from env vars ", zap.String("error message", err.Error()))
 	}
+	var settings []zap.Field
+	for _, s := range env.Settings() {
+		settings = append(settings, zap.String(s.Name, s.Value))
+	}
+	logger.Info("Loaded configuration", settings...)
 	gin.SetMode(gin.ReleaseMode)
 
-	db, err := repository.NewGormDatabase(env.DATABASE_URL, false)
+	db, err := connectDatabase()
+	if err != nil {
+		logger.Fatal("failed to connect to database ", zap.String("error message", err.Error()))
+	}
+	if err := db.ConfigurePool(repository.PoolConfig{
+		MaxOpenConns:    env.DBMaxOpenConns,
+		MaxIdleConns:    env.DBMaxIdleConns,
+		ConnMaxLifetime: env.DBConnMaxLifetime,
+	}); err != nil {
+		logger.Fatal("failed to configure the database pool ", zap.String("error message", err.Error()))
+	}
+	db.QueryTimeout = env.QueryTimeout
+	switch env.MigrationMode {
+	case "up":
+		if err := db.Migrate(); err != nil {
+			logger.Fatal("failed to migrate database ", zap.String("error message", err.Error()))
+		}
+		logger.Info("Finished migrating database", zap.Int("schema version", db.SchemaVersion()))
+	case "check":
+		pending, err := db.PendingMigrations()
+		if err != nil {
+			logger.Fatal("failed to check database schema ", zap.String("error message", err.Error()))
+		}
+		if len(pending) > 0 {
+			logger.Fatal("database schema is behind, refusing to serve",
+				zap.Int("pending migrations", len(pending)),
+				zap.Int("first pending version", pending[0].Version))
+		}
+	case "down":
+		if err := db.Rollback(1); err != nil {
+			logger.Fatal("failed to roll back database ", zap.String("error message", err.Error()))
+		}
+		logger.Info("Rolled back the latest migration")
+		if err := db.Close(); err != nil {
+			logger.Error("failed to close the database ", zap.String("error message", err.Error()))
+		}
+		logger.Sync()
+		return
+	default:
+		logger.Fatal("unknown MIGRATION_MODE", zap.String("mode", env.MigrationMode))
+	}
+
+	if env.SeedDemoData {
+		fixtures, err := loadFixtures(env.SeedFile)
+		if err != nil {
+			logger.Fatal("failed to load seed fixtures ", zap.String("error message", err.Error()))
+		}
+		if err := repository.Seed(db, fixtures); err != nil {
+			logger.Fatal("failed to seed database ", zap.String("error message", err.Error()))
+		}
+		logger.Info("Finished seeding database", zap.String("fixtures", env.SeedFile))
+	}
+
+	if env.BootstrapAdminUsername != "" {
+		if err := bootstrapAdmin(context.Background(), repository.NewUserRepo(db), env.BootstrapAdminUsername, env.BootstrapAdminPassword); err != nil {
+			logger.Fatal("failed to create the bootstrap admin ", zap.String("error message", err.Error()))
+		}
+	}
+
+	authenticator, err := auth.New(auth.Config{
+		HS256Secret:         env.JWTHS256Secret,
+		JWKSFile:            env.JWTJWKSFile,
+		RS256PrivateKeyFile: env.JWTRS256PrivateKeyFile,
+		RS256KeyID:          env.JWTRS256KeyID,
+		Issuer:              env.JWTIssuer,
+		TTL:                 env.JWTTTL,
+	})
 	if err != nil {
-		logger.Error("failed to connect to database ", zap.String("error message", err.Error()))
+		logger.Fatal("failed to load JWT keys ", zap.String("error message", err.Error()))
 	}
-	if err := db.AutoMigrate(); err != nil {
-		logger.Error("failed to migrate database ", zap.String("error message", err.Error()))
+
+	// dashboard streams learn about changes made through any replica from
+	// postgres notifications
+	broker := events.NewBroker()
+	listenCtx, stopListening := context.WithCancel(context.Background())
+	listening := make(chan struct{})
+	if db.Dialect() == repository.Postgres {
+		go func() {
+			defer close(listening)
+			repository.ListenPatientChanges(listenCtx, env.DATABASE_URL, logger, broker.Publish)
+		}()
+	} else {
+		close(listening)
+		logger.Warn("Dashboard streams receive no change notifications", zap.String("dialect", string(db.Dialect())))
 	}
-	logger.Info("Finished migrating database")
 
-	server := routes.Register(gin.Default(), logger, db, &env)
+	// requests only queue their audit entries, see repository.AuditWriter
+	auditWriter := repository.NewAuditWriter(repository.NewAuditRepo(db), logger)
+
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", env.Port),
+		Handler:      routes.Register(gin.New(), logger, db, &env, authenticator, broker, auditWriter),
+		ReadTimeout:  env.ReadTimeout,
+		WriteTimeout: env.WriteTimeout,
+		IdleTimeout:  env.IdleTimeout,
+	}
+	// dashboard streams never go idle, they end once the broker is closed
+	server.RegisterOnShutdown(broker.Close)
 	go func() {
-		server.Run(":5500")
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			logger.Fatal("failed to serve ", zap.String("error message", err.Error()))
+		}
 	}()
-	logger.Info("Server started")
+	logger.Info("Server started", zap.Int("port", env.Port))
 
 	// graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("shutdown servers...")
+	ctx, cancel := context.WithTimeout(context.Background(), env.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Warn("requests still running at the shutdown deadline were cut off", zap.Error(err))
+		server.Close()
+	}
+	stopListening()
+	<-listening
+	// the audit entries of the last requests are written before exiting
+	auditWriter.Close()
+	if err := db.Close(); err != nil {
+		logger.Error("failed to close the database ", zap.String("error message", err.Error()))
+	}
+	logger.Info("Server stopped")
+	logger.Sync()
+}
+
+// connectDatabase opens the database, retrying while it is not reachable yet,
+// e.g. because it starts together with this server.
+func connectDatabase() (*repository.GormDatabase, error) {
+	backoff := env.DBConnectBackoff
+	for attempt := 1; ; attempt++ {
+		db, err := repository.NewGormDatabase(env.DATABASE_URL, false)
+		if err == nil || attempt >= env.DBConnectAttempts {
+			return db, err
+		}
+		logger.Warn("failed to connect to database, retrying",
+			zap.Error(err), zap.Int("attempt", attempt), zap.Duration("backoff", backoff))
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// bootstrapAdmin creates the admin account unless the username is taken, so
+// that a fresh database can be administered without seeding demo users.
+func bootstrapAdmin(ctx context.Context, users repository.Users, username, password string) error {
+	if _, err := users.SelectUserByUsername(ctx, username); !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if len(password) < 12 {
+		return errors.New("BOOTSTRAP_ADMIN_PASSWORD must be at least 12 characters long")
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, err = users.InsertUser(ctx, model.User{Username: username, PasswordHash: hash, Role: model.RoleAdmin})
+	return err
+}
+
+func loadFixtures(path string) (*repository.Fixtures, error) {
+	if path == "" {
+		return repository.DemoFixtures()
+	}
+	return repository.LoadFixtures(path)
 }
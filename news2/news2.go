@@ -0,0 +1,225 @@
+// Package news2 computes the National Early Warning Score 2 of the Royal
+// College of Physicians from a set of vital signs.
+//
+// Seven parameters are scored from 0 to 3 and summed. Only SpO2 scale 1 is
+// implemented; patients with hypercapnic respiratory failure, who are scored
+// on scale 2, are over-scored for saturations between 88 and 95%. Readings
+// recorded before SpO2, supplemental oxygen and consciousness were captured
+// are scored from the parameters they have, and flagged as incomplete.
+package news2
+
+import (
+	"math"
+)
+
+// Risk is the clinical risk band of a score.
+type Risk string
+
+const (
+	RiskLow       Risk = "low"
+	RiskLowMedium Risk = "low-medium"
+	RiskMedium    Risk = "medium"
+	RiskHigh      Risk = "high"
+)
+
+// Consciousness levels of the ACVPU scale.
+const (
+	Alert        = "A"
+	NewConfusion = "C"
+	Voice        = "V"
+	Pain         = "P"
+	Unresponsive = "U"
+)
+
+// Inputs are the vital signs of one reading. Nil fields were not recorded.
+type Inputs struct {
+	RespirationRate    *int
+	OxygenSaturation   *int
+	SupplementalOxygen *bool
+	SystolicPressure   *int
+	PulseRate          *int
+	Consciousness      *string
+	// TemperatureF is in degrees Fahrenheit, as stored in VITAL_SIGN.
+	TemperatureF *float64
+}
+
+// SubScores are the points of each parameter; nil when it was not recorded.
+type SubScores struct {
+	RespirationRate    *int `json:"respiration_rate"`
+	OxygenSaturation   *int `json:"oxygen_saturation"`
+	SupplementalOxygen *int `json:"supplemental_oxygen"`
+	SystolicPressure   *int `json:"systolic_pressure"`
+	PulseRate          *int `json:"pulse_rate"`
+	Consciousness      *int `json:"consciousness"`
+	Temperature        *int `json:"temperature"`
+}
+
+// Score is the aggregate score. When Complete is false some parameters were
+// missing, so Total and Risk are a lower bound.
+type Score struct {
+	Total     int       `json:"total"`
+	Risk      Risk      `json:"risk"`
+	Complete  bool      `json:"complete"`
+	SubScores SubScores `json:"sub_scores"`
+}
+
+// Compute scores the inputs.
+func Compute(in Inputs) Score {
+	var s SubScores
+	if in.RespirationRate != nil {
+		s.RespirationRate = points(respirationRatePoints(*in.RespirationRate))
+	}
+	if in.OxygenSaturation != nil {
+		s.OxygenSaturation = points(oxygenSaturationPoints(*in.OxygenSaturation))
+	}
+	if in.SupplementalOxygen != nil {
+		p := 0
+		if *in.SupplementalOxygen {
+			p = 2
+		}
+		s.SupplementalOxygen = points(p)
+	}
+	if in.SystolicPressure != nil {
+		s.SystolicPressure = points(systolicPressurePoints(*in.SystolicPressure))
+	}
+	if in.PulseRate != nil {
+		s.PulseRate = points(pulseRatePoints(*in.PulseRate))
+	}
+	if in.Consciousness != nil {
+		p := 3
+		if *in.Consciousness == Alert {
+			p = 0
+		}
+		s.Consciousness = points(p)
+	}
+	if in.TemperatureF != nil {
+		s.Temperature = points(temperaturePoints(FahrenheitToCelsius(*in.TemperatureF)))
+	}
+
+	score := Score{Complete: true, SubScores: s}
+	red := false
+	for _, p := range []*int{s.RespirationRate, s.OxygenSaturation, s.SupplementalOxygen,
+		s.SystolicPressure, s.PulseRate, s.Consciousness, s.Temperature} {
+		if p == nil {
+			score.Complete = false
+			continue
+		}
+		score.Total += *p
+		red = red || *p == 3
+	}
+	score.Risk = risk(score.Total, red)
+	return score
+}
+
+// riskOrder orders the risk bands from low to high.
+var riskOrder = map[Risk]int{RiskLow: 0, RiskLowMedium: 1, RiskMedium: 2, RiskHigh: 3}
+
+// Rank orders scores by clinical risk: by band first, then by total. A single
+// parameter scoring 3 thus ranks above a total of 4 without one.
+func (s Score) Rank() int {
+	// totals stay below 100
+	return riskOrder[s.Risk]*100 + s.Total
+}
+
+// ValidConsciousness reports whether level is one of A, C, V, P or U.
+func ValidConsciousness(level string) bool {
+	switch level {
+	case Alert, NewConfusion, Voice, Pain, Unresponsive:
+		return true
+	}
+	return false
+}
+
+// FahrenheitToCelsius converts and rounds to the one decimal the NEWS2
+// temperature bands are defined with.
+func FahrenheitToCelsius(f float64) float64 {
+	return math.Round((f-32)*5/9*10) / 10
+}
+
+// risk applies the thresholds of the NEWS2 chart: 7 or more is high, 5 or 6
+// medium, and a single parameter scoring 3 is low-medium.
+func risk(total int, red bool) Risk {
+	switch {
+	case total >= 7:
+		return RiskHigh
+	case total >= 5:
+		return RiskMedium
+	case red:
+		return RiskLowMedium
+	}
+	return RiskLow
+}
+
+func respirationRatePoints(rr int) int {
+	switch {
+	case rr <= 8:
+		return 3
+	case rr <= 11:
+		return 1
+	case rr <= 20:
+		return 0
+	case rr <= 24:
+		return 2
+	}
+	return 3
+}
+
+func oxygenSaturationPoints(spo2 int) int {
+	switch {
+	case spo2 <= 91:
+		return 3
+	case spo2 <= 93:
+		return 2
+	case spo2 <= 95:
+		return 1
+	}
+	return 0
+}
+
+func systolicPressurePoints(sbp int) int {
+	switch {
+	case sbp <= 90:
+		return 3
+	case sbp <= 100:
+		return 2
+	case sbp <= 110:
+		return 1
+	case sbp <= 219:
+		return 0
+	}
+	return 3
+}
+
+func pulseRatePoints(hr int) int {
+	switch {
+	case hr <= 40:
+		return 3
+	case hr <= 50:
+		return 1
+	case hr <= 90:
+		return 0
+	case hr <= 110:
+		return 1
+	case hr <= 130:
+		return 2
+	}
+	return 3
+}
+
+func temperaturePoints(c float64) int {
+	switch {
+	case c <= 35.0:
+		return 3
+	case c <= 36.0:
+		return 1
+	case c <= 38.0:
+		return 0
+	case c <= 39.0:
+		return 1
+	}
+	return 2
+}
+
+func points(p int) *int {
+	return &p
+}
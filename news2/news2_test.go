@@ -0,0 +1,92 @@
+package news2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int           { return &v }
+func boolPtr(v bool) *bool        { return &v }
+func strPtr(v string) *string     { return &v }
+func floatPtr(v float64) *float64 { return &v }
+
+func Test_ComputeNormal(t *testing.T) {
+	s := Compute(Inputs{
+		RespirationRate:    intPtr(16),
+		OxygenSaturation:   intPtr(97),
+		SupplementalOxygen: boolPtr(false),
+		SystolicPressure:   intPtr(120),
+		PulseRate:          intPtr(70),
+		Consciousness:      strPtr(Alert),
+		TemperatureF:       floatPtr(98.6),
+	})
+	assert.Equal(t, 0, s.Total)
+	assert.Equal(t, RiskLow, s.Risk)
+	assert.True(t, s.Complete)
+}
+
+func Test_ComputeDeteriorating(t *testing.T) {
+	s := Compute(Inputs{
+		RespirationRate:    intPtr(23),      // 2
+		OxygenSaturation:   intPtr(93),      // 2
+		SupplementalOxygen: boolPtr(true),   // 2
+		SystolicPressure:   intPtr(105),     // 1
+		PulseRate:          intPtr(115),     // 2
+		Consciousness:      strPtr(Alert),   // 0
+		TemperatureF:       floatPtr(101.5), // 38.6 °C: 1
+	})
+	assert.Equal(t, 10, s.Total)
+	assert.Equal(t, RiskHigh, s.Risk)
+	assert.Equal(t, 2, *s.SubScores.RespirationRate)
+	assert.Equal(t, 1, *s.SubScores.Temperature)
+}
+
+func Test_ComputeRedScore(t *testing.T) {
+	s := Compute(Inputs{
+		RespirationRate:    intPtr(16),
+		OxygenSaturation:   intPtr(97),
+		SupplementalOxygen: boolPtr(false),
+		SystolicPressure:   intPtr(120),
+		PulseRate:          intPtr(70),
+		Consciousness:      strPtr(NewConfusion),
+		TemperatureF:       floatPtr(98.6),
+	})
+	assert.Equal(t, 3, s.Total)
+	assert.Equal(t, RiskLowMedium, s.Risk)
+	// a red score outranks a higher total in the low band
+	assert.Greater(t, s.Rank(), Score{Total: 4, Risk: RiskLow}.Rank())
+	assert.Less(t, s.Rank(), Score{Total: 5, Risk: RiskMedium}.Rank())
+}
+
+func Test_ComputeIncomplete(t *testing.T) {
+	s := Compute(Inputs{
+		RespirationRate:  intPtr(22),
+		SystolicPressure: intPtr(95),
+		PulseRate:        intPtr(72),
+		TemperatureF:     floatPtr(98.6),
+	})
+	assert.False(t, s.Complete)
+	assert.Equal(t, 4, s.Total)
+	assert.Nil(t, s.SubScores.OxygenSaturation)
+	assert.Nil(t, s.SubScores.Consciousness)
+}
+
+func Test_Bands(t *testing.T) {
+	for rr, want := range map[int]int{8: 3, 9: 1, 11: 1, 12: 0, 20: 0, 21: 2, 24: 2, 25: 3} {
+		assert.Equal(t, want, respirationRatePoints(rr), "respiration rate %d", rr)
+	}
+	for spo2, want := range map[int]int{91: 3, 92: 2, 93: 2, 94: 1, 95: 1, 96: 0} {
+		assert.Equal(t, want, oxygenSaturationPoints(spo2), "SpO2 %d", spo2)
+	}
+	for sbp, want := range map[int]int{90: 3, 91: 2, 100: 2, 101: 1, 110: 1, 111: 0, 219: 0, 220: 3} {
+		assert.Equal(t, want, systolicPressurePoints(sbp), "systolic %d", sbp)
+	}
+	for hr, want := range map[int]int{40: 3, 41: 1, 50: 1, 51: 0, 90: 0, 91: 1, 110: 1, 111: 2, 130: 2, 131: 3} {
+		assert.Equal(t, want, pulseRatePoints(hr), "pulse %d", hr)
+	}
+	for c, want := range map[float64]int{35.0: 3, 35.1: 1, 36.0: 1, 36.1: 0, 38.0: 0, 38.1: 1, 39.0: 1, 39.1: 2} {
+		assert.Equal(t, want, temperaturePoints(c), "temperature %g", c)
+	}
+	assert.Equal(t, 37.0, FahrenheitToCelsius(98.6))
+}
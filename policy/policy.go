@@ -0,0 +1,130 @@
+// Package policy decides what an authenticated principal may do.
+//
+// Access is checked on two levels. Permissions say which kinds of operations
+// a role may perform at all, e.g. reading the nurse dashboard. Patient scope
+// says which patients a principal may see: admins see everyone, doctors the
+// patients they attend, nurses the patients assigned to them in
+// PATIENT_NURSE and patients only themselves. Doctors and nurses can widen
+// their scope by one patient for a limited time with a break-the-glass
+// emergency grant; the grant lets them read the patient, changes stay with
+// the attending doctor and the assigned nurses. Handlers check both before
+// touching the repository, and the repository applies the patient scope to
+// its queries again so that a handler that forgets a check cannot leak rows.
+package policy
+
+type Role string
+
+const (
+	Admin   Role = "admin"
+	Doctor  Role = "doctor"
+	Nurse   Role = "nurse"
+	Patient Role = "patient"
+)
+
+type Permission string
+
+const (
+	ReadPatientDashboard Permission = "dashboard:patient:read"
+	ReadDoctorDashboard  Permission = "dashboard:doctor:read"
+	ReadNurseDashboard   Permission = "dashboard:nurse:read"
+	ReadPatients         Permission = "patients:read"
+	WritePatients        Permission = "patients:write"
+	ReadAssignments      Permission = "assignments:read"
+	WriteAssignments     Permission = "assignments:write"
+	ReadVitalSigns       Permission = "vitals:read"
+	WriteVitalSigns      Permission = "vitals:write"
+	ReadMedications      Permission = "medications:read"
+	WriteMedications     Permission = "medications:write"
+	ReadDiagnoses        Permission = "diagnoses:read"
+	WriteDiagnoses       Permission = "diagnoses:write"
+	ReadStaff            Permission = "staff:read"
+	WriteStaff           Permission = "staff:write"
+	ReadReports          Permission = "reports:read"
+	ReadCodeTables       Permission = "codes:read"
+	// RequestEmergencyAccess allows break-the-glass grants to patients out
+	// of the principal's scope.
+	RequestEmergencyAccess    Permission = "emergency-access:request"
+	ReadEmergencyAccessReport Permission = "emergency-access:report"
+	ReadAuditLog              Permission = "audit:read"
+	ReadAlerts                Permission = "alerts:read"
+	WriteAlerts               Permission = "alerts:write"
+	ReadAlertRules            Permission = "alert-rules:read"
+	WritePatientAlertRules    Permission = "alert-rules:patient:write"
+	WriteGlobalAlertRules     Permission = "alert-rules:global:write"
+)
+
+var rolePermissions = map[Role][]Permission{
+	Admin: {
+		ReadPatientDashboard, ReadDoctorDashboard, ReadNurseDashboard,
+		ReadPatients, WritePatients, ReadAssignments, WriteAssignments,
+		ReadVitalSigns, WriteVitalSigns, ReadMedications, WriteMedications,
+		ReadDiagnoses, WriteDiagnoses, ReadStaff, WriteStaff, ReadReports,
+		ReadCodeTables, ReadEmergencyAccessReport, ReadAuditLog,
+		ReadAlerts, WriteAlerts, ReadAlertRules, WritePatientAlertRules,
+		WriteGlobalAlertRules,
+	},
+	Doctor: {
+		ReadPatientDashboard, ReadDoctorDashboard,
+		ReadPatients, WritePatients, ReadAssignments, WriteAssignments,
+		ReadVitalSigns, WriteVitalSigns, ReadMedications, WriteMedications,
+		ReadDiagnoses, WriteDiagnoses, ReadStaff, ReadCodeTables,
+		RequestEmergencyAccess, ReadAlerts, WriteAlerts, ReadAlertRules,
+		WritePatientAlertRules,
+	},
+	Nurse: {
+		ReadPatientDashboard, ReadNurseDashboard,
+		ReadPatients, ReadAssignments,
+		ReadVitalSigns, WriteVitalSigns, ReadMedications, ReadDiagnoses,
+		ReadStaff, ReadCodeTables, RequestEmergencyAccess,
+		ReadAlerts, WriteAlerts, ReadAlertRules,
+	},
+	Patient: {
+		ReadPatientDashboard,
+		ReadPatients, ReadVitalSigns, ReadMedications, ReadDiagnoses,
+	},
+}
+
+var grants = func() map[Role]map[Permission]bool {
+	m := make(map[Role]map[Permission]bool, len(rolePermissions))
+	for role, perms := range rolePermissions {
+		m[role] = make(map[Permission]bool, len(perms))
+		for _, perm := range perms {
+			m[role][perm] = true
+		}
+	}
+	return m
+}()
+
+// Principal is the caller a decision is made for. Only the id matching the
+// role is set.
+type Principal struct {
+	UserID    int
+	Role      Role
+	DoctorID  int
+	NurseID   int
+	PatientID int
+}
+
+// Can reports whether the principal's role grants the permission. Unknown
+// roles are granted nothing.
+func (p Principal) Can(perm Permission) bool {
+	return grants[p.Role][perm]
+}
+
+// SeesAllPatients reports whether the principal's patient scope is
+// unrestricted.
+func (p Principal) SeesAllPatients() bool {
+	return p.Role == Admin
+}
+
+// CanViewDoctor reports whether the principal may open the dashboard of the
+// doctor: doctors only their own, admins any.
+func (p Principal) CanViewDoctor(did int) bool {
+	return p.Role == Admin || (p.Role == Doctor && p.DoctorID == did)
+}
+
+// CanViewNurse reports whether the principal may open the dashboard of the
+// nurse: nurses only their own, admins any.
+func (p Principal) CanViewNurse(nid int) bool {
+	return p.Role == Admin || (p.Role == Nurse && p.NurseID == nid)
+}
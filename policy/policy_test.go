@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Can(t *testing.T) {
+	admin := Principal{Role: Admin}
+	doctor := Principal{Role: Doctor, DoctorID: 1}
+	nurse := Principal{Role: Nurse, NurseID: 1}
+	patient := Principal{Role: Patient, PatientID: 1}
+
+	assert.True(t, admin.Can(WriteStaff))
+	assert.False(t, doctor.Can(WriteStaff))
+	assert.True(t, doctor.Can(ReadDoctorDashboard))
+	assert.False(t, doctor.Can(ReadNurseDashboard))
+	assert.True(t, nurse.Can(WriteVitalSigns))
+	assert.False(t, nurse.Can(WriteMedications))
+	assert.True(t, patient.Can(ReadPatientDashboard))
+	assert.False(t, patient.Can(ReadDoctorDashboard))
+	assert.False(t, patient.Can(WriteVitalSigns))
+	assert.True(t, admin.Can(ReadAuditLog))
+	assert.False(t, doctor.Can(ReadAuditLog))
+	assert.True(t, nurse.Can(WriteAlerts))
+	assert.False(t, nurse.Can(WritePatientAlertRules))
+	assert.False(t, doctor.Can(WriteGlobalAlertRules))
+	assert.False(t, patient.Can(ReadAlerts))
+	assert.False(t, Principal{Role: "intruder"}.Can(ReadPatients))
+}
+
+func Test_CanViewStaffDashboards(t *testing.T) {
+	assert.True(t, Principal{Role: Admin}.CanViewDoctor(2))
+	assert.True(t, Principal{Role: Doctor, DoctorID: 2}.CanViewDoctor(2))
+	assert.False(t, Principal{Role: Doctor, DoctorID: 1}.CanViewDoctor(2))
+	assert.False(t, Principal{Role: Nurse, NurseID: 2}.CanViewDoctor(2))
+
+	assert.True(t, Principal{Role: Nurse, NurseID: 3}.CanViewNurse(3))
+	assert.False(t, Principal{Role: Nurse, NurseID: 3}.CanViewNurse(1))
+	assert.False(t, Principal{Role: Patient, PatientID: 3}.CanViewNurse(3))
+}
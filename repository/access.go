@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"health-care-backend/policy"
+	model "health-care-backend/repository/model"
+)
+
+type Access interface {
+	CanAccessPatient(ctx context.Context, p policy.Principal, pid int) (bool, error)
+	CanManagePatient(ctx context.Context, p policy.Principal, pid int) (bool, error)
+	NextGrantExpiry(ctx context.Context, p policy.Principal) (*time.Time, error)
+}
+
+type accessRepo struct {
+	db *GormDatabase
+}
+
+func NewAccessRepo(db *GormDatabase) Access {
+	return &accessRepo{db: db}
+}
+
+// CanAccessPatient reports whether the patient exists and is in the
+// principal's scope. Unknown patients are reported as out of scope, so the
+// answer does not reveal which ids exist.
+func (r *accessRepo) CanAccessPatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	scope, args := patientScope(p, "pt.PATIENT_ID")
+	return r.inScope(ctx, pid, scope, args)
+}
+
+// CanManagePatient is CanAccessPatient without emergency grants: only the
+// attending doctor, the assigned nurses and admins may change a patient.
+func (r *accessRepo) CanManagePatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	scope, args := assignedScope(p, "pt.PATIENT_ID")
+	return r.inScope(ctx, pid, scope, args)
+}
+
+// NextGrantExpiry returns when the first of the principal's active emergency
+// grants expires, which shrinks the scope; nil without active grants.
+func (r *accessRepo) NextGrantExpiry(ctx context.Context, p policy.Principal) (*time.Time, error) {
+	if p.Role != policy.Doctor && p.Role != policy.Nurse {
+		return nil, nil
+	}
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.EmergencyGrant
+	if err := db.Raw(`
+		SELECT EXPIRES_AT FROM EMERGENCY_ACCESS
+		WHERE USER_ID = ? AND REVOKED_AT IS NULL AND EXPIRES_AT > ?
+		ORDER BY EXPIRES_AT
+		LIMIT 1`, p.UserID, time.Now().UTC()).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0].ExpiresAt, nil
+}
+
+func (r *accessRepo) inScope(ctx context.Context, pid int, scope string, args []interface{}) (bool, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var count int64
+	if err := db.Raw(`SELECT COUNT(*) FROM PATIENT AS pt WHERE pt.PATIENT_ID = ? AND `+scope,
+		append([]interface{}{pid}, args...)...).Scan(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// patientScope returns a condition on the patient id column that holds for
+// the patients the principal may see, see package policy. Doctors and nurses
+// also see the patients of their active emergency grants. The column must be
+// qualified with its table alias, otherwise it would bind to the tables of
+// the condition's subqueries.
+func patientScope(p policy.Principal, column string) (string, []interface{}) {
+	scope, args := assignedScope(p, column)
+	switch p.Role {
+	case policy.Doctor, policy.Nurse:
+		return `(` + scope + ` OR ` + emergencyScope(column) + `)`,
+			append(args, p.UserID, time.Now().UTC())
+	default:
+		return scope, args
+	}
+}
+
+// assignedScope is patientScope without emergency grants, the scope of
+// changes to a patient. Grants only let their holder read.
+func assignedScope(p policy.Principal, column string) (string, []interface{}) {
+	switch p.Role {
+	case policy.Admin:
+		return "1 = 1", nil
+	case policy.Doctor:
+		return `EXISTS (
+			SELECT 1 FROM PATIENT AS scope_p
+			WHERE scope_p.PATIENT_ID = ` + column + ` AND scope_p.DOCTOR_ID = ?)`,
+			[]interface{}{p.DoctorID}
+	case policy.Nurse:
+		return `EXISTS (
+			SELECT 1 FROM PATIENT_NURSE AS scope_pn
+			WHERE scope_pn.PATIENT_ID = ` + column + ` AND scope_pn.NURSE_ID = ?)`,
+			[]interface{}{p.NurseID}
+	case policy.Patient:
+		return column + ` = ?`, []interface{}{p.PatientID}
+	default:
+		return "1 = 0", nil
+	}
+}
+
+// emergencyScope holds for the patients of the user's active emergency
+// grants. It takes the user id and the current time as arguments.
+func emergencyScope(column string) string {
+	return `EXISTS (
+			SELECT 1 FROM EMERGENCY_ACCESS AS scope_ea
+			WHERE scope_ea.PATIENT_ID = ` + column + ` AND scope_ea.USER_ID = ?
+			AND scope_ea.REVOKED_AT IS NULL AND scope_ea.EXPIRES_AT > ?)`
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"health-care-backend/policy"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PatientScope(t *testing.T) {
+	scope, args := patientScope(policy.Principal{Role: policy.Admin}, "pt.PATIENT_ID")
+	assert.Equal(t, "1 = 1", scope)
+	assert.Empty(t, args)
+
+	scope, args = patientScope(policy.Principal{UserID: 7, Role: policy.Doctor, DoctorID: 2}, "pt.PATIENT_ID")
+	assert.Contains(t, scope, "scope_p.PATIENT_ID = pt.PATIENT_ID")
+	assert.Contains(t, scope, "scope_ea.PATIENT_ID = pt.PATIENT_ID")
+	assert.Equal(t, []interface{}{2, 7}, args[:2])
+	assert.Len(t, args, strings.Count(scope, "?"))
+
+	scope, args = patientScope(policy.Principal{UserID: 8, Role: policy.Nurse, NurseID: 3}, "v.patient_id")
+	assert.Contains(t, scope, "scope_pn.PATIENT_ID = v.patient_id")
+	assert.Contains(t, scope, "scope_ea.PATIENT_ID = v.patient_id")
+	assert.Equal(t, []interface{}{3, 8}, args[:2])
+	assert.Len(t, args, strings.Count(scope, "?"))
+
+	scope, args = patientScope(policy.Principal{Role: policy.Patient, PatientID: 4}, "v.id")
+	assert.Equal(t, "v.id = ?", scope)
+	assert.Equal(t, []interface{}{4}, args)
+
+	scope, _ = patientScope(policy.Principal{}, "v.id")
+	assert.Equal(t, "1 = 0", scope)
+
+	scope, args = assignedScope(policy.Principal{UserID: 7, Role: policy.Doctor, DoctorID: 2}, "pt.PATIENT_ID")
+	assert.Contains(t, scope, "scope_p.PATIENT_ID = pt.PATIENT_ID")
+	assert.NotContains(t, scope, "scope_ea")
+	assert.Equal(t, []interface{}{2}, args)
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"health-care-backend/alerting"
+	"health-care-backend/policy"
+	model "health-care-backend/repository/model"
+
+	"gorm.io/gorm"
+)
+
+// Alerts are raised by InsertVitalSigns; this interface reads and handles
+// them and maintains the rules.
+type Alerts interface {
+	ListAlerts(ctx context.Context, p policy.Principal, f AlertFilter) ([]model.Alert, error)
+	SelectAlert(ctx context.Context, p policy.Principal, alertID int) (model.Alert, error)
+	AcknowledgeAlert(ctx context.Context, p policy.Principal, alertID int, now time.Time) (model.Alert, error)
+	ResolveAlert(ctx context.Context, p policy.Principal, alertID int, now time.Time) (model.Alert, error)
+
+	ListAlertRules(ctx context.Context, pid int) ([]model.AlertRule, error)
+	SelectAlertRule(ctx context.Context, ruleID int) (model.AlertRule, error)
+	InsertAlertRule(ctx context.Context, r model.AlertRule) (model.AlertRule, error)
+	DeactivateAlertRule(ctx context.Context, ruleID int) (model.AlertRule, error)
+}
+
+// AlertFilter narrows ListAlerts. Zero fields do not filter.
+type AlertFilter struct {
+	PatientID int
+	Status    string
+}
+
+type alertRepo struct {
+	db *GormDatabase
+}
+
+func NewAlertRepo(db *GormDatabase) Alerts {
+	return &alertRepo{db: db}
+}
+
+// ListAlerts returns the alerts of the patients in the principal's scope,
+// newest first.
+func (r *alertRepo) ListAlerts(ctx context.Context, p policy.Principal, f AlertFilter) ([]model.Alert, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	scope, args := patientScope(p, "a.PATIENT_ID")
+	query := `SELECT * FROM ALERT AS a WHERE ` + scope
+	if f.PatientID != 0 {
+		query += ` AND a.PATIENT_ID = ?`
+		args = append(args, f.PatientID)
+	}
+	if f.Status != "" {
+		query += ` AND a.STATUS = ?`
+		args = append(args, f.Status)
+	}
+	var records []model.Alert
+	if err := db.Raw(query+` ORDER BY a.CREATED_AT DESC, a.ALERT_ID DESC`, args...).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SelectAlert yields ErrNotFound for alerts of patients out of the
+// principal's scope too.
+func (r *alertRepo) SelectAlert(ctx context.Context, p policy.Principal, alertID int) (model.Alert, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	scope, args := patientScope(p, "a.PATIENT_ID")
+	var records []model.Alert
+	if err := db.Raw(`SELECT * FROM ALERT AS a WHERE a.ALERT_ID = ? AND `+scope,
+		append([]interface{}{alertID}, args...)...).Scan(&records).Error; err != nil {
+		return model.Alert{}, err
+	}
+	if len(records) == 0 {
+		return model.Alert{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// AcknowledgeAlert marks an open alert as seen. Alerts that are not open
+// yield ErrConflict.
+func (r *alertRepo) AcknowledgeAlert(ctx context.Context, p policy.Principal, alertID int, now time.Time) (model.Alert, error) {
+	return r.updateAlert(ctx, p, alertID, `
+	UPDATE ALERT SET STATUS = 'acknowledged', ACKNOWLEDGED_AT = ?, ACKNOWLEDGED_BY = ?
+	WHERE ALERT_ID = ? AND STATUS = 'open'`, now, p.UserID, alertID)
+}
+
+// ResolveAlert closes an open or acknowledged alert, after which its rule may
+// raise a new one. Resolved alerts yield ErrConflict.
+func (r *alertRepo) ResolveAlert(ctx context.Context, p policy.Principal, alertID int, now time.Time) (model.Alert, error) {
+	return r.updateAlert(ctx, p, alertID, `
+	UPDATE ALERT SET STATUS = 'resolved', RESOLVED_AT = ?, RESOLVED_BY = ?
+	WHERE ALERT_ID = ? AND STATUS <> 'resolved'`, now, p.UserID, alertID)
+}
+
+func (r *alertRepo) updateAlert(ctx context.Context, p policy.Principal, alertID int, update string, args ...interface{}) (model.Alert, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	// emergency grants do not extend to changes
+	scope, scopeArgs := assignedScope(p, "a.PATIENT_ID")
+	var count int64
+	if err := db.Raw(`SELECT COUNT(*) FROM ALERT AS a WHERE a.ALERT_ID = ? AND `+scope,
+		append([]interface{}{alertID}, scopeArgs...)...).Scan(&count).Error; err != nil {
+		return model.Alert{}, err
+	}
+	if count == 0 {
+		return model.Alert{}, ErrNotFound
+	}
+	res := db.Exec(update, args...)
+	if res.Error != nil {
+		return model.Alert{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return model.Alert{}, ErrConflict
+	}
+	return r.SelectAlert(ctx, p, alertID)
+}
+
+// ListAlertRules returns the active global rules and, with a non-zero pid,
+// the active rules of that patient.
+func (r *alertRepo) ListAlertRules(ctx context.Context, pid int) ([]model.AlertRule, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	return activeAlertRules(db, pid)
+}
+
+func (r *alertRepo) SelectAlertRule(ctx context.Context, ruleID int) (model.AlertRule, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.AlertRule
+	if err := db.Raw(`SELECT * FROM ALERT_RULE WHERE RULE_ID = ?`, ruleID).Scan(&records).Error; err != nil {
+		return model.AlertRule{}, err
+	}
+	if len(records) == 0 {
+		return model.AlertRule{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// InsertAlertRule stores an active rule. An unknown patient yields
+// ErrInvalidReference.
+func (r *alertRepo) InsertAlertRule(ctx context.Context, rule model.AlertRule) (model.AlertRule, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.AlertRule
+	if err := db.Raw(`
+	INSERT INTO ALERT_RULE (PATIENT_ID, PARAMETER, CONDITION, THRESHOLD, READINGS, SEVERITY, CREATED_BY)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	RETURNING *`,
+		rule.PatientID, rule.Parameter, rule.Condition, rule.Threshold, rule.Readings, rule.Severity, rule.CreatedBy).Scan(&records).Error; err != nil {
+		return model.AlertRule{}, translateError(err)
+	}
+	return records[0], nil
+}
+
+// DeactivateAlertRule stops a rule from raising alerts. Its alerts are kept.
+// Inactive rules yield ErrConflict.
+func (r *alertRepo) DeactivateAlertRule(ctx context.Context, ruleID int) (model.AlertRule, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	res := db.Exec(`UPDATE ALERT_RULE SET ACTIVE = FALSE WHERE RULE_ID = ? AND ACTIVE`, ruleID)
+	if res.Error != nil {
+		return model.AlertRule{}, res.Error
+	}
+	rule, err := r.SelectAlertRule(ctx, ruleID)
+	if err != nil {
+		return model.AlertRule{}, err
+	}
+	if res.RowsAffected == 0 {
+		return model.AlertRule{}, ErrConflict
+	}
+	return rule, nil
+}
+
+func activeAlertRules(db *gorm.DB, pid int) ([]model.AlertRule, error) {
+	var records []model.AlertRule
+	if err := db.Raw(`
+	SELECT * FROM ALERT_RULE
+	WHERE ACTIVE AND (PATIENT_ID IS NULL OR PATIENT_ID = ?)
+	ORDER BY RULE_ID`, pid).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// raiseAlerts evaluates the patient's rules for each new reading, in issue
+// time order, and stores the findings. It runs in the transaction that
+// inserted the readings, so a reading is never stored without its alerts.
+func raiseAlerts(tx *gorm.DB, pid int, readings []model.VitalSign) error {
+	rules, err := activeAlertRules(tx, pid)
+	if err != nil {
+		return err
+	}
+	rules = alerting.EffectiveRules(rules)
+	if len(rules) == 0 {
+		return nil
+	}
+	n := alerting.HistoryNeeded(rules)
+	sorted := append([]model.VitalSign(nil), readings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].IssueTime.Before(sorted[j].IssueTime) })
+	for _, reading := range sorted {
+		history := []model.VitalSign{reading}
+		if n > 1 {
+			if history, err = latestVitalSigns(tx, pid, reading.IssueTime, n); err != nil {
+				return err
+			}
+		}
+		for _, f := range alerting.Evaluate(rules, history) {
+			// ON CONFLICT skips rules with an unresolved alert of the patient
+			if err := tx.Exec(`
+			INSERT INTO ALERT (RULE_ID, PATIENT_ID, ISSUE_TIME, PARAMETER, VALUE, SEVERITY, MESSAGE)
+			VALUES (?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+				f.Rule.RuleID, pid, reading.IssueTime, f.Rule.Parameter, f.Value, f.Rule.Severity, truncate(f.Message, 255)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// latestVitalSigns returns up to n readings issued at or before until, oldest
+// first.
+func latestVitalSigns(tx *gorm.DB, pid int, until time.Time, n int) ([]model.VitalSign, error) {
+	var records []model.VitalSign
+	if err := tx.Raw(`
+	SELECT * FROM VITAL_SIGN WHERE PATIENT_ID = ? AND ISSUE_TIME <= ?
+	ORDER BY ISSUE_TIME DESC LIMIT ?`, pid, until, n).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.TrimSpace(s[:n])
+}
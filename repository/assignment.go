@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	model "health-care-backend/repository/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Assignments interface {
+	AssignNurse(ctx context.Context, pid, nid int) (model.Assignment, error)
+	UnassignNurse(ctx context.Context, pid, nid int) (model.Assignment, error)
+	ReassignDoctor(ctx context.Context, pid, did int) (model.Assignment, error)
+	ListAssignmentHistory(ctx context.Context, pid int, currentOnly bool) ([]model.Assignment, error)
+}
+
+type assignmentRepo struct {
+	db *GormDatabase
+}
+
+func NewAssignmentRepo(db *GormDatabase) Assignments {
+	return &assignmentRepo{db: db}
+}
+
+const selectAssignments = `
+	SELECT
+		h.assignment_id,
+		h.patient_id,
+		h.staff_kind,
+		h.staff_id,
+		COALESCE(doc.first_name, n.first_name) AS staff_first_name,
+		COALESCE(doc.last_name, n.last_name) AS staff_last_name,
+		h.effective_from,
+		h.effective_to
+	FROM assignment_history AS h
+	LEFT JOIN doctor AS doc ON h.staff_kind = 'doctor' AND doc.doctor_id = h.staff_id
+	LEFT JOIN nurse AS n ON h.staff_kind = 'nurse' AND n.nurse_id = h.staff_id`
+
+// AssignNurse adds the nurse to the patient's care team. The nurse must be
+// active and the patient admitted; assigning the same nurse twice yields
+// ErrConflict.
+func (r *assignmentRepo) AssignNurse(ctx context.Context, pid, nid int) (model.Assignment, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var assignment model.Assignment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockAdmittedPatient(tx, pid); err != nil {
+			return err
+		}
+		if err := requireActiveStaff(tx, NurseStaff, nid); err != nil {
+			return err
+		}
+		if err := tx.Exec(`INSERT INTO PATIENT_NURSE (PATIENT_ID, NURSE_ID) VALUES (?, ?)`, pid, nid).Error; err != nil {
+			return translateError(err)
+		}
+		var err error
+		assignment, err = openAssignment(tx, pid, NurseStaff, nid)
+		return err
+	})
+	return assignment, err
+}
+
+// UnassignNurse removes the nurse from the patient's care team and closes the
+// assignment. It yields ErrNotFound if the nurse was not assigned.
+func (r *assignmentRepo) UnassignNurse(ctx context.Context, pid, nid int) (model.Assignment, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var assignment model.Assignment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Exec(`DELETE FROM PATIENT_NURSE WHERE PATIENT_ID = ? AND NURSE_ID = ?`, pid, nid)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		closed, err := closeAssignments(tx, pid, NurseStaff, nid)
+		if err != nil {
+			return err
+		}
+		if len(closed) > 0 {
+			assignment = closed[0]
+		}
+		return nil
+	})
+	return assignment, err
+}
+
+// ReassignDoctor hands the patient over to another attending doctor. The
+// doctor must be active and differ from the current one.
+func (r *assignmentRepo) ReassignDoctor(ctx context.Context, pid, did int) (model.Assignment, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var assignment model.Assignment
+	err := db.Transaction(func(tx *gorm.DB) error {
+		p, err := lockAdmittedPatient(tx, pid)
+		if err != nil {
+			return err
+		}
+		if p.DoctorID == did {
+			return ErrConflict
+		}
+		if err := requireActiveStaff(tx, DoctorStaff, did); err != nil {
+			return err
+		}
+		if err := tx.Exec(`UPDATE PATIENT SET DOCTOR_ID = ? WHERE PATIENT_ID = ?`, did, pid).Error; err != nil {
+			return err
+		}
+		assignment, err = moveDoctorAssignment(tx, pid, did)
+		return err
+	})
+	return assignment, err
+}
+
+func (r *assignmentRepo) ListAssignmentHistory(ctx context.Context, pid int, currentOnly bool) ([]model.Assignment, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var exists int64
+	if err := db.Raw(`SELECT COUNT(*) FROM PATIENT WHERE PATIENT_ID = ?`, pid).Scan(&exists).Error; err != nil {
+		return nil, err
+	}
+	if exists == 0 {
+		return nil, ErrNotFound
+	}
+	var records []model.Assignment
+	if err := db.Raw(selectAssignments+`
+	WHERE h.patient_id = ? AND (NOT ? OR h.effective_to IS NULL)
+	ORDER BY h.effective_from, h.assignment_id`, pid, currentOnly).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// lockAdmittedPatient locks the patient row for the rest of the transaction.
+// It yields ErrNotFound for unknown and ErrConflict for discharged patients.
+func lockAdmittedPatient(tx *gorm.DB, pid int) (model.Patient, error) {
+	var records []model.Patient
+	if err := tx.Raw(forUpdate(tx, `SELECT * FROM PATIENT WHERE PATIENT_ID = ?`), pid).Scan(&records).Error; err != nil {
+		return model.Patient{}, err
+	}
+	if len(records) == 0 {
+		return model.Patient{}, ErrNotFound
+	}
+	if records[0].DischargedAt != nil {
+		return model.Patient{}, ErrConflict
+	}
+	return records[0], nil
+}
+
+// requireActiveStaff yields ErrInvalidReference unless the staff member
+// exists and is active. It locks the staff row like DeactivateStaff, so the
+// two cannot interleave.
+func requireActiveStaff(tx *gorm.DB, kind StaffKind, id int) error {
+	active, err := lockStaff(tx, kind, id)
+	if errors.Is(err, ErrNotFound) || err == nil && !active {
+		return ErrInvalidReference
+	}
+	return err
+}
+
+func openAssignment(tx *gorm.DB, pid int, kind StaffKind, staffID int) (model.Assignment, error) {
+	var ids []int
+	if err := tx.Raw(`
+	INSERT INTO ASSIGNMENT_HISTORY (PATIENT_ID, STAFF_KIND, STAFF_ID, EFFECTIVE_FROM)
+	VALUES (?, ?, ?, ?)
+	RETURNING ASSIGNMENT_ID`, pid, kind, staffID, time.Now().UTC()).Scan(&ids).Error; err != nil {
+		return model.Assignment{}, err
+	}
+	var records []model.Assignment
+	if err := tx.Raw(selectAssignments+` WHERE h.assignment_id = ?`, ids[0]).Scan(&records).Error; err != nil {
+		return model.Assignment{}, err
+	}
+	return records[0], nil
+}
+
+// closeAssignments ends the open assignments of the patient to the given
+// staff member, or to any staff member of that kind when staffID is 0.
+func closeAssignments(tx *gorm.DB, pid int, kind StaffKind, staffID int) ([]model.Assignment, error) {
+	var ids []int
+	if err := tx.Raw(`
+	UPDATE ASSIGNMENT_HISTORY SET EFFECTIVE_TO = ?
+	WHERE PATIENT_ID = ? AND STAFF_KIND = ? AND (? = 0 OR STAFF_ID = ?) AND EFFECTIVE_TO IS NULL
+	RETURNING ASSIGNMENT_ID`, time.Now().UTC(), pid, kind, staffID, staffID).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var records []model.Assignment
+	if err := tx.Raw(selectAssignments+` WHERE h.assignment_id IN ?`, ids).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// moveDoctorAssignment closes the patient's open doctor assignment and opens
+// one for did. PATIENT.DOCTOR_ID must be updated by the caller.
+func moveDoctorAssignment(tx *gorm.DB, pid, did int) (model.Assignment, error) {
+	if _, err := closeAssignments(tx, pid, DoctorStaff, 0); err != nil {
+		return model.Assignment{}, err
+	}
+	return openAssignment(tx, pid, DoctorStaff, did)
+}
+
+// backfillAssignmentHistory opens a history row for every current assignment
+// that does not have one yet, e.g. after seeding fixtures.
+func backfillAssignmentHistory(tx *gorm.DB) error {
+	now := time.Now().UTC()
+	if err := tx.Exec(`
+	INSERT INTO ASSIGNMENT_HISTORY (PATIENT_ID, STAFF_KIND, STAFF_ID, EFFECTIVE_FROM)
+	SELECT p.PATIENT_ID, 'doctor', p.DOCTOR_ID, ? FROM PATIENT AS p
+	WHERE NOT EXISTS (
+		SELECT 1 FROM ASSIGNMENT_HISTORY AS h
+		WHERE h.PATIENT_ID = p.PATIENT_ID AND h.STAFF_KIND = 'doctor'
+		AND h.STAFF_ID = p.DOCTOR_ID AND h.EFFECTIVE_TO IS NULL)`, now).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`
+	INSERT INTO ASSIGNMENT_HISTORY (PATIENT_ID, STAFF_KIND, STAFF_ID, EFFECTIVE_FROM)
+	SELECT pn.PATIENT_ID, 'nurse', pn.NURSE_ID, ? FROM PATIENT_NURSE AS pn
+	WHERE NOT EXISTS (
+		SELECT 1 FROM ASSIGNMENT_HISTORY AS h
+		WHERE h.PATIENT_ID = pn.PATIENT_ID AND h.STAFF_KIND = 'nurse'
+		AND h.STAFF_ID = pn.NURSE_ID AND h.EFFECTIVE_TO IS NULL)`, now).Error
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"health-care-backend/policy"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// emily is the demo user of nurse 1.
+var emily = policy.Principal{UserID: 4, Role: policy.Nurse, NurseID: 1}
+
+// careTeam lists the patient's open assignments as "kind id", oldest first.
+func careTeam(t *testing.T, repo Assignments, pid int) []string {
+	t.Helper()
+	current, err := repo.ListAssignmentHistory(context.Background(), pid, true)
+	require.NoError(t, err)
+	team := make([]string, 0, len(current))
+	for _, a := range current {
+		assert.Nil(t, a.EffectiveTo)
+		team = append(team, fmt.Sprintf("%s %d", a.StaffKind, a.StaffID))
+	}
+	return team
+}
+
+func Test_BackfillAssignmentHistory(t *testing.T) {
+	db := seededSQLite(t)
+	assignments := NewAssignmentRepo(db)
+
+	// seeding opened the fixtures' assignments
+	assert.Equal(t, []string{"doctor 1", "nurse 1"}, careTeam(t, assignments, 1))
+	assert.Equal(t, []string{"doctor 2", "nurse 2"}, careTeam(t, assignments, 2))
+
+	// a second run only opens what is missing
+	require.NoError(t, db.DB.Exec(`INSERT INTO PATIENT_NURSE (PATIENT_ID, NURSE_ID) VALUES (2, 3)`).Error)
+	require.NoError(t, backfillAssignmentHistory(db.DB))
+	require.NoError(t, backfillAssignmentHistory(db.DB))
+	assert.Equal(t, []string{"doctor 1", "nurse 1"}, careTeam(t, assignments, 1))
+	assert.Equal(t, []string{"doctor 2", "nurse 2", "nurse 3"}, careTeam(t, assignments, 2))
+}
+
+func Test_AssignNurse(t *testing.T) {
+	db := seededSQLite(t)
+	assignments := NewAssignmentRepo(db)
+	ctx := context.Background()
+
+	assignment, err := assignments.AssignNurse(ctx, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, assignment.PatientID)
+	assert.Equal(t, string(NurseStaff), assignment.StaffKind)
+	assert.Equal(t, 1, assignment.StaffID)
+	assert.Equal(t, "Emily", assignment.StaffFirstName)
+	assert.Equal(t, "Wilson", assignment.StaffLastName)
+	assert.Nil(t, assignment.EffectiveTo)
+	assert.Equal(t, []string{"doctor 2", "nurse 2", "nurse 1"}, careTeam(t, assignments, 2))
+	ok, err := NewAccessRepo(db).CanAccessPatient(ctx, emily, 2)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = assignments.AssignNurse(ctx, 2, 1)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = assignments.AssignNurse(ctx, 99, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = assignments.AssignNurse(ctx, 2, 99)
+	assert.ErrorIs(t, err, ErrInvalidReference)
+	_, err = NewStaffRepo(db).DeactivateStaff(ctx, NurseStaff, 3)
+	require.NoError(t, err)
+	_, err = assignments.AssignNurse(ctx, 2, 3)
+	assert.ErrorIs(t, err, ErrInvalidReference)
+	assert.Equal(t, []string{"doctor 2", "nurse 2", "nurse 1"}, careTeam(t, assignments, 2))
+}
+
+func Test_UnassignNurse(t *testing.T) {
+	db := seededSQLite(t)
+	assignments := NewAssignmentRepo(db)
+	ctx := context.Background()
+
+	assignment, err := assignments.UnassignNurse(ctx, 4, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, assignment.StaffID)
+	assert.NotNil(t, assignment.EffectiveTo)
+	assert.Equal(t, []string{"doctor 1"}, careTeam(t, assignments, 4))
+	ok, err := NewAccessRepo(db).CanAccessPatient(ctx, emily, 4)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = assignments.UnassignNurse(ctx, 4, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = assignments.UnassignNurse(ctx, 99, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// the closed assignment stays in the history
+	history, err := assignments.ListAssignmentHistory(ctx, 4, false)
+	require.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func Test_ReassignDoctor(t *testing.T) {
+	db := seededSQLite(t)
+	assignments := NewAssignmentRepo(db)
+	ctx := context.Background()
+
+	_, err := assignments.ReassignDoctor(ctx, 2, 2)
+	assert.ErrorIs(t, err, ErrConflict)
+	assignment, err := assignments.ReassignDoctor(ctx, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, string(DoctorStaff), assignment.StaffKind)
+	assert.Equal(t, 3, assignment.StaffID)
+	assert.Equal(t, "Michael", assignment.StaffFirstName)
+	assert.Equal(t, []string{"nurse 2", "doctor 3"}, careTeam(t, assignments, 2))
+	patient, err := NewPatientRepo(db).SelectPatient(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, patient.DoctorID)
+
+	history, err := assignments.ListAssignmentHistory(ctx, 2, false)
+	require.NoError(t, err)
+	var closed int
+	for _, a := range history {
+		if a.StaffKind == string(DoctorStaff) && a.StaffID == 2 {
+			closed++
+			assert.NotNil(t, a.EffectiveTo)
+		}
+	}
+	assert.Equal(t, 1, closed)
+
+	_, err = assignments.ReassignDoctor(ctx, 99, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = assignments.ReassignDoctor(ctx, 2, 99)
+	assert.ErrorIs(t, err, ErrInvalidReference)
+	// doctor 2 attends nobody since the handover
+	_, err = NewStaffRepo(db).DeactivateStaff(ctx, DoctorStaff, 2)
+	require.NoError(t, err)
+	_, err = assignments.ReassignDoctor(ctx, 2, 2)
+	assert.ErrorIs(t, err, ErrInvalidReference)
+}
+
+func Test_AssignmentsOfDischargedPatients(t *testing.T) {
+	db := seededSQLite(t)
+	assignments := NewAssignmentRepo(db)
+	ctx := context.Background()
+
+	_, err := NewPatientRepo(db).DischargePatient(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"doctor 2"}, careTeam(t, assignments, 2))
+	_, err = assignments.AssignNurse(ctx, 2, 1)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = assignments.ReassignDoctor(ctx, 2, 3)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = assignments.UnassignNurse(ctx, 2, 2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func Test_AssignmentTimesAreWrittenInUTC(t *testing.T) {
+	db := seededSQLite(t)
+	ctx := context.Background()
+	_, err := NewAssignmentRepo(db).UnassignNurse(ctx, 4, 1)
+	require.NoError(t, err)
+	_, err = NewPatientRepo(db).DischargePatient(ctx, 2)
+	require.NoError(t, err)
+	_, err = NewStaffRepo(db).DeactivateStaff(ctx, DoctorStaff, 3)
+	require.NoError(t, err)
+
+	// stamped by the server, not by the database session's clock, like the
+	// grants and vital signs they are compared with
+	for _, query := range []string{
+		`SELECT CAST(EFFECTIVE_FROM AS TEXT) FROM ASSIGNMENT_HISTORY`,
+		`SELECT CAST(EFFECTIVE_TO AS TEXT) FROM ASSIGNMENT_HISTORY WHERE EFFECTIVE_TO IS NOT NULL`,
+		`SELECT CAST(DISCHARGED_AT AS TEXT) FROM PATIENT WHERE DISCHARGED_AT IS NOT NULL`,
+		`SELECT CAST(DEACTIVATED_AT AS TEXT) FROM DOCTOR WHERE DEACTIVATED_AT IS NOT NULL`,
+	} {
+		var stamps []string
+		require.NoError(t, db.DB.Raw(query).Scan(&stamps).Error)
+		require.NotEmpty(t, stamps, query)
+		for _, stamp := range stamps {
+			assert.True(t, strings.HasSuffix(stamp, "+00:00"), "%s: %s", query, stamp)
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	model "health-care-backend/repository/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+	auditVerifyBatch  = 500
+)
+
+// AuditLog is the append-only trail of patient data access. There is no way
+// to change or delete entries; the table rejects it too.
+type AuditLog interface {
+	AppendAuditEntries(ctx context.Context, entries []model.AuditEntry) ([]model.AuditEntry, error)
+	ListAuditEntries(ctx context.Context, f AuditFilter) ([]model.AuditEntry, error)
+	VerifyAuditChain(ctx context.Context) (AuditVerification, error)
+}
+
+// AuditFilter narrows ListAuditEntries. Zero fields do not filter; From and To
+// bound the time of the entry. Limit defaults to 100 and is capped at 1000.
+type AuditFilter struct {
+	UserID    int
+	PatientID int
+	From      *time.Time
+	To        *time.Time
+	Limit     int
+}
+
+// AuditVerification is the outcome of checking the hash chain. When Valid is
+// false, BrokenAt is the first entry that does not match and Reason says why.
+type AuditVerification struct {
+	Valid    bool
+	Entries  int64
+	BrokenAt int64
+	Reason   string
+}
+
+type auditRepo struct {
+	db *GormDatabase
+}
+
+func NewAuditRepo(db *GormDatabase) AuditLog {
+	return &auditRepo{db: db}
+}
+
+type auditHead struct {
+	LastEntryID int64
+	LastHash    string
+}
+
+type auditPatientRow struct {
+	EntryID   int64
+	PatientID int
+}
+
+// AppendAuditEntries chains the entries, in order, to the end of the log and
+// stores them in one transaction. The AUDIT_LOG_HEAD row is locked until it
+// commits, so concurrent writers, also of other replicas, append one batch
+// after another. Requests do not call it themselves but queue their entries
+// with an AuditWriter, which takes the lock once per batch.
+func (r *auditRepo) AppendAuditEntries(ctx context.Context, entries []model.AuditEntry) ([]model.AuditEntry, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	chained := make([]model.AuditEntry, 0, len(entries))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var heads []auditHead
+		if err := tx.Raw(forUpdate(tx, `SELECT LAST_ENTRY_ID, LAST_HASH FROM AUDIT_LOG_HEAD WHERE ID = 1`)).Scan(&heads).Error; err != nil {
+			return err
+		}
+		if len(heads) == 0 {
+			return fmt.Errorf("audit log head is missing")
+		}
+		head := heads[0]
+		for _, e := range entries {
+			e.OccurredAt = e.OccurredAt.UTC().Truncate(time.Microsecond)
+			e.PatientIDs = uniqueSorted(e.PatientIDs)
+			e.EntryID = head.LastEntryID + 1
+			e.PrevHash = head.LastHash
+			e.Hash = e.Digest()
+			if err := tx.Exec(`
+			INSERT INTO AUDIT_LOG (ENTRY_ID, OCCURRED_AT, USER_ID, USERNAME, ROLE, ACTION, STATUS, CLIENT_IP, REQUEST_ID, PREV_HASH, HASH)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				e.EntryID, e.OccurredAt, e.UserID, e.Username, e.Role, e.Action, e.Status, e.ClientIP, e.RequestID, e.PrevHash, e.Hash).Error; err != nil {
+				return err
+			}
+			for _, pid := range e.PatientIDs {
+				if err := tx.Exec(`INSERT INTO AUDIT_LOG_PATIENT (ENTRY_ID, PATIENT_ID) VALUES (?, ?)`, e.EntryID, pid).Error; err != nil {
+					return err
+				}
+			}
+			head = auditHead{LastEntryID: e.EntryID, LastHash: e.Hash}
+			chained = append(chained, e)
+		}
+		return tx.Exec(`UPDATE AUDIT_LOG_HEAD SET LAST_ENTRY_ID = ?, LAST_HASH = ? WHERE ID = 1`, head.LastEntryID, head.LastHash).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chained, nil
+}
+
+// ListAuditEntries returns the matching entries, newest first.
+func (r *auditRepo) ListAuditEntries(ctx context.Context, f AuditFilter) ([]model.AuditEntry, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if f.UserID != 0 {
+		conds = append(conds, "a.USER_ID = ?")
+		args = append(args, f.UserID)
+	}
+	if f.PatientID != 0 {
+		conds = append(conds, "EXISTS (SELECT 1 FROM AUDIT_LOG_PATIENT AS ap WHERE ap.ENTRY_ID = a.ENTRY_ID AND ap.PATIENT_ID = ?)")
+		args = append(args, f.PatientID)
+	}
+	if f.From != nil {
+		conds = append(conds, "a.OCCURRED_AT >= ?")
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		conds = append(conds, "a.OCCURRED_AT <= ?")
+		args = append(args, *f.To)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	if limit > maxAuditLimit {
+		limit = maxAuditLimit
+	}
+	query := `SELECT * FROM AUDIT_LOG AS a`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	var records []model.AuditEntry
+	if err := db.Raw(query+` ORDER BY a.ENTRY_ID DESC LIMIT ?`, append(args, limit)...).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	if err := loadAuditPatients(db, records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// VerifyAuditChain recomputes the hash of every entry, oldest first, and
+// checks that each one links to its predecessor and that the ids have no
+// gaps. The last entry must match AUDIT_LOG_HEAD, which catches entries cut
+// off the end of the log.
+// The query timeout applies to each batch, so that long logs can be verified.
+func (r *auditRepo) VerifyAuditChain(ctx context.Context) (AuditVerification, error) {
+	head, err := r.selectAuditHead(ctx)
+	if err != nil {
+		return AuditVerification{}, err
+	}
+	last := auditHead{LastHash: model.AuditGenesisHash}
+	for {
+		batch, err := r.selectAuditBatch(ctx, last.LastEntryID)
+		if err != nil {
+			return AuditVerification{}, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		var broken *AuditVerification
+		if last, broken = checkAuditChain(last, batch); broken != nil {
+			return *broken, nil
+		}
+		// entries written while verifying are left to the next run
+		if last.LastEntryID >= head.LastEntryID {
+			break
+		}
+	}
+	if last.LastEntryID < head.LastEntryID {
+		return AuditVerification{Entries: last.LastEntryID, BrokenAt: last.LastEntryID + 1,
+			Reason: "entries at the end of the log are missing"}, nil
+	}
+	if last.LastEntryID == head.LastEntryID && last.LastHash != head.LastHash {
+		return AuditVerification{Entries: last.LastEntryID, BrokenAt: last.LastEntryID,
+			Reason: "last entry does not match the log head"}, nil
+	}
+	return AuditVerification{Valid: true, Entries: last.LastEntryID}, nil
+}
+
+func (r *auditRepo) selectAuditHead(ctx context.Context) (auditHead, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var heads []auditHead
+	if err := db.Raw(`SELECT LAST_ENTRY_ID, LAST_HASH FROM AUDIT_LOG_HEAD WHERE ID = 1`).Scan(&heads).Error; err != nil {
+		return auditHead{}, err
+	}
+	if len(heads) == 0 {
+		return auditHead{}, fmt.Errorf("audit log head is missing")
+	}
+	return heads[0], nil
+}
+
+// selectAuditBatch returns the next entries after entry id after, oldest
+// first.
+func (r *auditRepo) selectAuditBatch(ctx context.Context, after int64) ([]model.AuditEntry, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var batch []model.AuditEntry
+	if err := db.Raw(`SELECT * FROM AUDIT_LOG WHERE ENTRY_ID > ? ORDER BY ENTRY_ID LIMIT ?`,
+		after, auditVerifyBatch).Scan(&batch).Error; err != nil {
+		return nil, err
+	}
+	if err := loadAuditPatients(db, batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// checkAuditChain checks entries, which must follow last in id order. It
+// returns the new end of the chain, or the verification result of the first
+// broken entry.
+func checkAuditChain(last auditHead, entries []model.AuditEntry) (auditHead, *AuditVerification) {
+	for _, e := range entries {
+		broken := func(reason string) (auditHead, *AuditVerification) {
+			return last, &AuditVerification{Entries: last.LastEntryID, BrokenAt: e.EntryID, Reason: reason}
+		}
+		switch {
+		case e.EntryID != last.LastEntryID+1:
+			return broken(fmt.Sprintf("entry %d is missing", last.LastEntryID+1))
+		case e.PrevHash != last.LastHash:
+			return broken("previous hash does not match the entry before")
+		case e.Hash != e.Digest():
+			return broken("entry was modified")
+		}
+		last = auditHead{LastEntryID: e.EntryID, LastHash: e.Hash}
+	}
+	return last, nil
+}
+
+// loadAuditPatients fills in the patient ids of the entries.
+func loadAuditPatients(db *gorm.DB, entries []model.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(entries))
+	byID := make(map[int64]*model.AuditEntry, len(entries))
+	for i := range entries {
+		ids = append(ids, entries[i].EntryID)
+		byID[entries[i].EntryID] = &entries[i]
+	}
+	var rows []auditPatientRow
+	if err := db.Raw(`
+	SELECT ENTRY_ID, PATIENT_ID FROM AUDIT_LOG_PATIENT
+	WHERE ENTRY_ID IN ? ORDER BY ENTRY_ID, PATIENT_ID`, ids).Scan(&rows).Error; err != nil {
+		return err
+	}
+	for _, row := range rows {
+		e := byID[row.EntryID]
+		e.PatientIDs = append(e.PatientIDs, row.PatientID)
+	}
+	return nil
+}
+
+func uniqueSorted(ids []int) []int {
+	if len(ids) == 0 {
+		return nil
+	}
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+	unique := sorted[:1]
+	for _, id := range sorted[1:] {
+		if id != unique[len(unique)-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
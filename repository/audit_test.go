@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	model "health-care-backend/repository/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func auditChain(n int) []model.AuditEntry {
+	entries := make([]model.AuditEntry, 0, n)
+	prev := model.AuditGenesisHash
+	for i := 1; i <= n; i++ {
+		e := model.AuditEntry{
+			EntryID:    int64(i),
+			OccurredAt: time.Date(2024, 1, 1, 0, 0, i, 0, time.UTC),
+			UserID:     1,
+			Username:   "john.doe",
+			Role:       "doctor",
+			Action:     "GET /api/patients/:id",
+			Status:     200,
+			PatientIDs: []int{i},
+			PrevHash:   prev,
+		}
+		e.Hash = e.Digest()
+		prev = e.Hash
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func Test_CheckAuditChain(t *testing.T) {
+	genesis := auditHead{LastHash: model.AuditGenesisHash}
+
+	entries := auditChain(3)
+	last, broken := checkAuditChain(genesis, entries)
+	assert.Nil(t, broken)
+	assert.Equal(t, auditHead{LastEntryID: 3, LastHash: entries[2].Hash}, last)
+
+	// a chain checked in two batches gives the same result
+	last, broken = checkAuditChain(genesis, entries[:2])
+	assert.Nil(t, broken)
+	last, broken = checkAuditChain(last, entries[2:])
+	assert.Nil(t, broken)
+	assert.Equal(t, int64(3), last.LastEntryID)
+
+	tampered := auditChain(3)
+	tampered[1].PatientIDs = []int{9}
+	_, broken = checkAuditChain(genesis, tampered)
+	if assert.NotNil(t, broken) {
+		assert.Equal(t, int64(2), broken.BrokenAt)
+		assert.Equal(t, "entry was modified", broken.Reason)
+	}
+
+	// recomputing the hash of an edited entry breaks the link of the next one
+	rehashed := auditChain(3)
+	rehashed[1].Status = 403
+	rehashed[1].Hash = rehashed[1].Digest()
+	_, broken = checkAuditChain(genesis, rehashed)
+	if assert.NotNil(t, broken) {
+		assert.Equal(t, int64(3), broken.BrokenAt)
+	}
+
+	gap := auditChain(3)
+	_, broken = checkAuditChain(genesis, []model.AuditEntry{gap[0], gap[2]})
+	if assert.NotNil(t, broken) {
+		assert.Equal(t, int64(3), broken.BrokenAt)
+		assert.Equal(t, "entry 2 is missing", broken.Reason)
+	}
+}
+
+func Test_AuditDigestRoundTrip(t *testing.T) {
+	e := auditChain(1)[0]
+	e.OccurredAt = time.Date(2024, 1, 1, 12, 0, 0, 123456789, time.FixedZone("CET", 3600))
+	stored := e
+	stored.OccurredAt = e.OccurredAt.UTC().Truncate(time.Microsecond)
+	assert.Equal(t, e.Digest(), stored.Digest())
+
+	e.PatientIDs = nil
+	empty := e
+	empty.PatientIDs = []int{}
+	assert.Equal(t, e.Digest(), empty.Digest())
+}
+
+func Test_UniqueSorted(t *testing.T) {
+	assert.Equal(t, []int{1, 2, 5}, uniqueSorted([]int{5, 1, 2, 5, 1}))
+	assert.Nil(t, uniqueSorted(nil))
+}
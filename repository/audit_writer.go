@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	model "health-care-backend/repository/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// auditQueueSize bounds the entries waiting to be written; requests wait
+	// for room once it is full.
+	auditQueueSize = 4096
+	// auditBatchSize bounds the entries appended in one transaction.
+	auditBatchSize = 200
+	// auditWriteTimeout bounds each batch, including the wait for the audit
+	// log head lock.
+	auditWriteTimeout = 10 * time.Second
+)
+
+// ErrAuditWriterClosed is returned by Enqueue once the writer was closed.
+var ErrAuditWriterClosed = errors.New("audit writer is closed")
+
+// AuditWriter appends audit entries in the background, so that requests do
+// not wait for the audit log head lock. Entries are taken from a bounded
+// queue in order and appended in batches of whatever has piled up, one
+// transaction per batch, see AuditLog.AppendAuditEntries.
+//
+// A batch that cannot be written is logged entry by entry as an error, so
+// that alerting picks it up.
+type AuditWriter struct {
+	log    AuditLog
+	logger *zap.Logger
+	queue  chan model.AuditEntry
+	done   chan struct{}
+	// mu keeps Close from closing the queue while Enqueue sends on it
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewAuditWriter starts writing queued entries to log. Call Close when done.
+func NewAuditWriter(log AuditLog, logger *zap.Logger) *AuditWriter {
+	w := &AuditWriter{
+		log:    log,
+		logger: logger,
+		queue:  make(chan model.AuditEntry, auditQueueSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Enqueue queues the entry. It waits while the queue is full, until ctx is
+// done.
+func (w *AuditWriter) Enqueue(ctx context.Context, e model.AuditEntry) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ErrAuditWriterClosed
+	}
+	select {
+	case w.queue <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close writes the entries still queued and stops the writer. Entries
+// enqueued afterwards are refused. It may be called more than once.
+func (w *AuditWriter) Close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *AuditWriter) run() {
+	defer close(w.done)
+	for e := range w.queue {
+		batch := append(make([]model.AuditEntry, 0, auditBatchSize), e)
+	fill:
+		for len(batch) < auditBatchSize {
+			select {
+			case e, ok := <-w.queue:
+				if !ok {
+					break fill
+				}
+				batch = append(batch, e)
+			default:
+				break fill
+			}
+		}
+		w.write(batch)
+	}
+}
+
+func (w *AuditWriter) write(batch []model.AuditEntry) {
+	ctx, cancel := context.WithTimeout(context.Background(), auditWriteTimeout)
+	defer cancel()
+	if _, err := w.log.AppendAuditEntries(ctx, batch); err != nil {
+		for _, e := range batch {
+			w.logger.Error("failed to write audit entry",
+				zap.Error(err),
+				zap.String("action", e.Action),
+				zap.Int("user id", e.UserID),
+				zap.Ints("patient ids", e.PatientIDs),
+				zap.String("request id", e.RequestID))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	model "health-care-backend/repository/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// batchAuditLog records the batches it was asked to append.
+type batchAuditLog struct {
+	AuditLog
+	mu      sync.Mutex
+	batches [][]model.AuditEntry
+	err     error
+}
+
+func (l *batchAuditLog) AppendAuditEntries(ctx context.Context, entries []model.AuditEntry) ([]model.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.batches = append(l.batches, entries)
+	return entries, l.err
+}
+
+func Test_AuditWriter(t *testing.T) {
+	log := &batchAuditLog{}
+	w := NewAuditWriter(log, zap.NewNop())
+	for i := 1; i <= 2*auditBatchSize; i++ {
+		require.NoError(t, w.Enqueue(context.Background(), model.AuditEntry{UserID: i}))
+	}
+	w.Close()
+	w.Close()
+	assert.ErrorIs(t, w.Enqueue(context.Background(), model.AuditEntry{}), ErrAuditWriterClosed)
+
+	var written []int
+	for _, batch := range log.batches {
+		assert.LessOrEqual(t, len(batch), auditBatchSize)
+		for _, e := range batch {
+			written = append(written, e.UserID)
+		}
+	}
+	if assert.Len(t, written, 2*auditBatchSize) {
+		for i, id := range written {
+			assert.Equal(t, i+1, id)
+		}
+	}
+}
+
+func Test_AuditWriterLogsLostEntries(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	w := NewAuditWriter(&batchAuditLog{err: errors.New("connection refused")}, zap.New(core))
+	require.NoError(t, w.Enqueue(context.Background(), model.AuditEntry{Action: "GET /api/patients/:id", RequestID: "req-1", PatientIDs: []int{4}}))
+	w.Close()
+	if assert.Equal(t, 1, logs.Len()) {
+		fields := logs.All()[0].ContextMap()
+		assert.Equal(t, "req-1", fields["request id"])
+		assert.Equal(t, "GET /api/patients/:id", fields["action"])
+	}
+}
+
+func Test_AuditWriterWaitsForRoom(t *testing.T) {
+	log := &batchAuditLog{}
+	// not started yet, so the queue stays full
+	w := &AuditWriter{log: log, logger: zap.NewNop(), queue: make(chan model.AuditEntry, 1), done: make(chan struct{})}
+	require.NoError(t, w.Enqueue(context.Background(), model.AuditEntry{UserID: 1}))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Enqueue(ctx, model.AuditEntry{UserID: 2}), context.DeadlineExceeded)
+
+	go w.run()
+	w.Close()
+	if assert.Len(t, log.batches, 1) {
+		assert.Equal(t, []model.AuditEntry{{UserID: 1}}, log.batches[0])
+	}
+}
@@ -1,14 +1,58 @@
 package repository
 
 import (
-	"fmt"
+	"context"
+	"health-care-backend/policy"
 	model "health-care-backend/repository/model"
+	"strings"
 )
 
+// Dashboard only returns the rows of patients in the principal's scope.
 type Dashboard interface {
-	SelectPatientDashboard(int) ([]model.PatientDashboardView, error)
-	SelectDoctorDashboard(did int) ([]model.DoctorDashboardView, error)
-	SelectNurseDashboard(nid int) ([]model.NurseDashboardView, error)
+	SelectPatientDashboard(ctx context.Context, p policy.Principal, pid int) ([]model.PatientDashboardView, error)
+	SelectDoctorDashboard(ctx context.Context, p policy.Principal, did int, q DashboardQuery) ([]model.DoctorDashboardView, error)
+	SelectNurseDashboard(ctx context.Context, p policy.Principal, nid int, q DashboardQuery) ([]model.NurseDashboardView, error)
+}
+
+// DashboardSort is what the doctor and nurse dashboards can be sorted by.
+type DashboardSort string
+
+const (
+	SortByLastName   DashboardSort = "last_name"
+	SortByAge        DashboardSort = "age"
+	SortByVitalsTime DashboardSort = "vitals_time"
+	// SortByRisk orders by the risk band of the NEWS2 score, then its total,
+	// see news2.Score.Rank.
+	SortByRisk DashboardSort = "risk"
+)
+
+// DashboardQuery selects a page of a doctor or nurse dashboard. Rows are
+// ordered by Sort, which defaults to the last name, and then by patient id.
+// Patients without a value to sort by, i.e. without readings, come last in
+// either direction. Zero fields do not filter, and a zero Limit returns every
+// row after the cursor.
+type DashboardQuery struct {
+	Sort       DashboardSort
+	Descending bool
+	// After continues behind the last row of the previous page.
+	After *DashboardCursor
+	Limit int
+	// Disease matches a current diagnosis by ICD-10 code prefix or part of
+	// its description, Medication a current order by part of its name.
+	Disease    string
+	Medication string
+	BloodType  string
+	MinAge     *int
+	MaxAge     *int
+}
+
+// DashboardCursor is the position of a row: the value it is sorted by, nil
+// when it has none, and its patient id. The value is a string for
+// SortByLastName, an int for SortByAge and SortByRisk and a time.Time for
+// SortByVitalsTime.
+type DashboardCursor struct {
+	Value     interface{}
+	PatientID int
 }
 
 type dashboardRepo struct {
@@ -19,27 +63,109 @@ func NewDashboardRepo(db *GormDatabase) Dashboard {
 	return &dashboardRepo{db: db}
 }
 
-func (d *dashboardRepo) SelectPatientDashboard(pid int) ([]model.PatientDashboardView, error) {
+func (d *dashboardRepo) SelectPatientDashboard(ctx context.Context, p policy.Principal, pid int) ([]model.PatientDashboardView, error) {
+	db, cancel := d.db.conn(ctx)
+	defer cancel()
 	var records []model.PatientDashboardView
-	if err := d.db.DB.Raw(`SELECT * FROM public.patient_dashboard_view WHERE id = ?`, pid).Scan(&records).Error; err != nil {
+	scope, args := patientScope(p, "v.id")
+	if err := db.Raw(`SELECT * FROM patient_dashboard_view AS v WHERE v.id = ? AND `+scope,
+		append([]interface{}{pid}, args...)...).Scan(&records).Error; err != nil {
 		return nil, err
 	}
 	return records, nil
 }
 
-func (d *dashboardRepo) SelectDoctorDashboard(did int) ([]model.DoctorDashboardView, error) {
+func (d *dashboardRepo) SelectDoctorDashboard(ctx context.Context, p policy.Principal, did int, q DashboardQuery) ([]model.DoctorDashboardView, error) {
+	db, cancel := d.db.conn(ctx)
+	defer cancel()
 	var records []model.DoctorDashboardView
-	if err := d.db.DB.Raw(`SELECT * FROM public.doctor_dashboard_view WHERE assigned_doctor_id = ?`, did).Scan(&records).Error; err != nil {
+	scope, args := patientScope(p, "v.patient_id")
+	query, args := q.apply(`SELECT * FROM doctor_dashboard_view AS v WHERE v.assigned_doctor_id = ? AND `+scope,
+		append([]interface{}{did}, args...), "v.last_name")
+	if err := db.Raw(query, args...).Scan(&records).Error; err != nil {
 		return nil, err
 	}
 	return records, nil
 }
 
-func (d *dashboardRepo) SelectNurseDashboard(nid int) ([]model.NurseDashboardView, error) {
+func (d *dashboardRepo) SelectNurseDashboard(ctx context.Context, p policy.Principal, nid int, q DashboardQuery) ([]model.NurseDashboardView, error) {
+	db, cancel := d.db.conn(ctx)
+	defer cancel()
 	var records []model.NurseDashboardView
-	if err := d.db.DB.Raw(`SELECT * FROM public.nurse_dashboard_view WHERE nurse_id = ?`, nid).Scan(&records).Error; err != nil {
+	scope, args := patientScope(p, "v.patient_id")
+	query, args := q.apply(`SELECT * FROM nurse_dashboard_view AS v WHERE v.nurse_id = ? AND `+scope,
+		append([]interface{}{nid}, args...), "v.patient_last_name")
+	if err := db.Raw(query, args...).Scan(&records).Error; err != nil {
 		return nil, err
 	}
-	fmt.Println(records)
 	return records, nil
 }
+
+// apply appends the filters, cursor, order and limit of the query to a
+// dashboard view query aliased v. The views name the last name column
+// differently.
+func (q DashboardQuery) apply(query string, args []interface{}, lastName string) (string, []interface{}) {
+	if q.Disease != "" {
+		query += ` AND EXISTS (SELECT 1 FROM patient_diagnosis AS d
+			WHERE d.patient_id = v.patient_id AND d.resolved_date IS NULL
+			AND (d.icd10_code LIKE ? ESCAPE '\' OR LOWER(d.description) LIKE ? ESCAPE '\'))`
+		args = append(args, escapeLike(strings.ToUpper(q.Disease))+"%", "%"+escapeLike(strings.ToLower(q.Disease))+"%")
+	}
+	if q.Medication != "" {
+		query += ` AND EXISTS (SELECT 1 FROM medication_order AS o
+			WHERE o.patient_id = v.patient_id AND o.status <> 'discontinued'
+			AND LOWER(o.name) LIKE ? ESCAPE '\')`
+		args = append(args, "%"+escapeLike(strings.ToLower(q.Medication))+"%")
+	}
+	if q.BloodType != "" {
+		query += ` AND v.blood_type = ?`
+		args = append(args, q.BloodType)
+	}
+	if q.MinAge != nil {
+		query += ` AND v.age >= ?`
+		args = append(args, *q.MinAge)
+	}
+	if q.MaxAge != nil {
+		query += ` AND v.age <= ?`
+		args = append(args, *q.MaxAge)
+	}
+
+	column := q.column(lastName)
+	direction, after := "ASC", ">"
+	if q.Descending {
+		direction, after = "DESC", "<"
+	}
+	if c := q.After; c != nil {
+		if c.Value == nil {
+			query += ` AND ` + column + ` IS NULL AND v.patient_id > ?`
+			args = append(args, c.PatientID)
+		} else {
+			query += ` AND (` + column + ` IS NULL OR ` + column + ` ` + after + ` ?
+				OR (` + column + ` = ? AND v.patient_id > ?))`
+			args = append(args, c.Value, c.Value, c.PatientID)
+		}
+	}
+	query += ` ORDER BY ` + column + ` IS NULL, ` + column + ` ` + direction + `, v.patient_id`
+	if q.Limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, q.Limit)
+	}
+	return query, args
+}
+
+func (q DashboardQuery) column(lastName string) string {
+	switch q.Sort {
+	case SortByAge:
+		return "v.age"
+	case SortByVitalsTime:
+		return "v.vitals_recorded_at"
+	case SortByRisk:
+		return "v.news2_rank"
+	}
+	return lastName
+}
+
+// escapeLike quotes the wildcards of a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DashboardQueryApply(t *testing.T) {
+	minAge := 40
+	q := DashboardQuery{
+		Sort:       SortByRisk,
+		Descending: true,
+		After:      &DashboardCursor{Value: 5, PatientID: 3},
+		Limit:      11,
+		Medication: "50%_off",
+		MinAge:     &minAge,
+	}
+	query, args := q.apply(`SELECT * FROM v WHERE TRUE`, nil, "v.last_name")
+	assert.Contains(t, query, `AND LOWER(o.name) LIKE ? ESCAPE '\'`)
+	assert.Contains(t, query, `AND v.age >= ?`)
+	assert.Contains(t, query, `v.news2_rank IS NULL OR v.news2_rank < ?`)
+	assert.Contains(t, query, `ORDER BY v.news2_rank IS NULL, v.news2_rank DESC, v.patient_id LIMIT ?`)
+	assert.Equal(t, []interface{}{`%50\%\_off%`, 40, 5, 5, 3, 11}, args)
+
+	query, args = DashboardQuery{After: &DashboardCursor{PatientID: 3}}.apply(`SELECT * FROM v WHERE TRUE`, nil, "v.last_name")
+	assert.Contains(t, query, `AND v.last_name IS NULL AND v.patient_id > ?`)
+	assert.Contains(t, query, `ORDER BY v.last_name IS NULL, v.last_name ASC, v.patient_id`)
+	assert.NotContains(t, query, "LIMIT")
+	assert.Equal(t, []interface{}{3}, args)
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	envconfig "health-care-backend/envconfig"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// prepareDatabaseConnection connects to DATABASE_URL, or to a fresh
+// in-memory SQLite database when it is not set, and migrates it. Setting
+// DATABASE_URL to a postgres database runs the tests of this file against
+// postgres; the other repository tests always use SQLite.
+//
+// The tests were in dashboard.test.go before, which the go tool compiled
+// into the package rather than ran.
+func prepareDatabaseConnection(t *testing.T) *GormDatabase {
+	t.Helper()
+	if os.Getenv("DATABASE_URL") == "" {
+		t.Setenv("DATABASE_URL", sqliteScheme+":memory:")
+	}
+
+	var env envconfig.Env
+	err := envconfig.Process(&env) // intent to load config from ENV variables
+	assert.NoError(t, err)
+
+	db, err := NewGormDatabase(env.DATABASE_URL, false)
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Migrate())
+	return db
+}
+
+func Test_NewGormDatabase(t *testing.T) {
+	db := prepareDatabaseConnection(t)
+	assert.NotNil(t, db)
+}
+
+func Test_ListTables(t *testing.T) {
+	db := prepareDatabaseConnection(t)
+	query := `
+		SELECT COUNT(table_name) FROM information_schema.tables WHERE table_schema = 'public';`
+	if db.Dialect() == SQLite {
+		query = `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table';`
+	}
+	var count int64
+	err := db.DB.Raw(query).Scan(&count).Error
+	assert.NoError(t, err)
+	assert.NotZero(t, count)
+}
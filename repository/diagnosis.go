@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	model "health-care-backend/repository/model"
+
+	"gorm.io/gorm"
+)
+
+type Diagnoses interface {
+	ListDiagnoses(ctx context.Context, pid int, includeResolved bool) ([]model.Diagnosis, error)
+	SelectDiagnosis(ctx context.Context, pid, diagnosisID int) (model.Diagnosis, error)
+	InsertDiagnosis(ctx context.Context, d model.Diagnosis) (model.Diagnosis, error)
+	UpdateDiagnosis(ctx context.Context, d model.Diagnosis) (model.Diagnosis, error)
+	CountDiagnoses(ctx context.Context) ([]model.DiagnosisCount, error)
+}
+
+type diagnosisRepo struct {
+	db *GormDatabase
+}
+
+func NewDiagnosisRepo(db *GormDatabase) Diagnoses {
+	return &diagnosisRepo{db: db}
+}
+
+// ListDiagnoses returns the patient's diagnoses, the primary one first.
+func (r *diagnosisRepo) ListDiagnoses(ctx context.Context, pid int, includeResolved bool) ([]model.Diagnosis, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	if _, err := NewPatientRepo(r.db).SelectPatient(ctx, pid); err != nil {
+		return nil, err
+	}
+	var records []model.Diagnosis
+	if err := db.Raw(`
+	SELECT * FROM PATIENT_DIAGNOSIS
+	WHERE PATIENT_ID = ? AND (? OR RESOLVED_DATE IS NULL)
+	ORDER BY IS_PRIMARY DESC, DIAGNOSIS_ID`, pid, includeResolved).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *diagnosisRepo) SelectDiagnosis(ctx context.Context, pid, diagnosisID int) (model.Diagnosis, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Diagnosis
+	if err := db.Raw(`
+	SELECT * FROM PATIENT_DIAGNOSIS
+	WHERE PATIENT_ID = ? AND DIAGNOSIS_ID = ?`, pid, diagnosisID).Scan(&records).Error; err != nil {
+		return model.Diagnosis{}, err
+	}
+	if len(records) == 0 {
+		return model.Diagnosis{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// InsertDiagnosis records a diagnosis of an admitted patient. A new open
+// primary diagnosis demotes the previous one to secondary.
+func (r *diagnosisRepo) InsertDiagnosis(ctx context.Context, d model.Diagnosis) (model.Diagnosis, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Diagnosis
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockAdmittedPatient(tx, d.PatientID); err != nil {
+			return err
+		}
+		if err := demotePrimaryDiagnosis(tx, d); err != nil {
+			return err
+		}
+		return tx.Raw(`
+		INSERT INTO PATIENT_DIAGNOSIS (PATIENT_ID, ICD10_CODE, DESCRIPTION, ONSET_DATE, RESOLVED_DATE, IS_PRIMARY)
+		VALUES (?, ?, ?, ?, ?, ?)
+		RETURNING *`,
+			d.PatientID, d.ICD10Code, d.Description, d.OnsetDate, d.ResolvedDate, d.IsPrimary).Scan(&records).Error
+	})
+	if err != nil {
+		return model.Diagnosis{}, err
+	}
+	return records[0], nil
+}
+
+// UpdateDiagnosis overwrites the dates and the primary flag. The code and
+// description are fixed; a different code is a new diagnosis.
+func (r *diagnosisRepo) UpdateDiagnosis(ctx context.Context, d model.Diagnosis) (model.Diagnosis, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Diagnosis
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockAdmittedPatient(tx, d.PatientID); err != nil {
+			return err
+		}
+		if err := demotePrimaryDiagnosis(tx, d); err != nil {
+			return err
+		}
+		if err := tx.Raw(`
+		UPDATE PATIENT_DIAGNOSIS SET ONSET_DATE = ?, RESOLVED_DATE = ?, IS_PRIMARY = ?
+		WHERE PATIENT_ID = ? AND DIAGNOSIS_ID = ?
+		RETURNING *`,
+			d.OnsetDate, d.ResolvedDate, d.IsPrimary, d.PatientID, d.DiagnosisID).Scan(&records).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			// roll back the demotion
+			return ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return model.Diagnosis{}, err
+	}
+	return records[0], nil
+}
+
+// CountDiagnoses reports how many patients have each coded diagnosis open,
+// most frequent first.
+func (r *diagnosisRepo) CountDiagnoses(ctx context.Context) ([]model.DiagnosisCount, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.DiagnosisCount
+	if err := db.Raw(`
+	SELECT ICD10_CODE, MIN(DESCRIPTION) AS description, COUNT(DISTINCT PATIENT_ID) AS patients
+	FROM PATIENT_DIAGNOSIS
+	WHERE ICD10_CODE IS NOT NULL AND RESOLVED_DATE IS NULL
+	GROUP BY ICD10_CODE
+	ORDER BY PATIENTS DESC, ICD10_CODE`).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// demotePrimaryDiagnosis clears the primary flag of the patient's other open
+// diagnoses if d is an open primary diagnosis.
+func demotePrimaryDiagnosis(tx *gorm.DB, d model.Diagnosis) error {
+	if !d.IsPrimary || d.ResolvedDate != nil {
+		return nil
+	}
+	return tx.Exec(`
+	UPDATE PATIENT_DIAGNOSIS SET IS_PRIMARY = FALSE
+	WHERE PATIENT_ID = ? AND DIAGNOSIS_ID <> ? AND IS_PRIMARY AND RESOLVED_DATE IS NULL`,
+		d.PatientID, d.DiagnosisID).Error
+}
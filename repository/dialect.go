@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Dialect is the SQL database behind a connection, named as gorm names it.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	// SQLite serves tests and development without a database server. Its
+	// schema starts at the current version, see sqliteMigrations, and there
+	// are no change notifications for the dashboard streams.
+	SQLite Dialect = "sqlite"
+)
+
+// sqliteScheme prefixes a DATABASE_URL that names a SQLite database file, or
+// ":memory:" for a private in-memory database, e.g. "sqlite:health-care.db".
+const sqliteScheme = "sqlite:"
+
+func dialectOf(db *gorm.DB) Dialect {
+	return Dialect(db.Dialector.Name())
+}
+
+func (d *GormDatabase) Dialect() Dialect {
+	return dialectOf(d.DB)
+}
+
+// dialector picks the driver for the DSN. SQLite databases are opened with
+// foreign keys enforced, as postgres does.
+func dialector(dsn string) gorm.Dialector {
+	if path, ok := strings.CutPrefix(dsn, sqliteScheme); ok {
+		return sqlite.Open(path + "?_foreign_keys=on&_busy_timeout=5000")
+	}
+	return postgres.Open(dsn)
+}
+
+// forUpdate makes a SELECT lock its rows for the rest of the transaction.
+// SQLite has no row locks and needs none: its writers take turns on the
+// single connection of the pool.
+func forUpdate(tx *gorm.DB, query string) string {
+	if dialectOf(tx) == SQLite {
+		return query
+	}
+	return query + ` FOR UPDATE`
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	model "health-care-backend/repository/model"
+)
+
+type EmergencyGrants interface {
+	InsertEmergencyGrant(ctx context.Context, g model.EmergencyGrant) (model.EmergencyGrant, error)
+	ListEmergencyGrants(ctx context.Context, f EmergencyGrantFilter) ([]model.EmergencyGrant, error)
+	SelectEmergencyGrant(ctx context.Context, grantID int) (model.EmergencyGrant, error)
+	RevokeEmergencyGrant(ctx context.Context, grantID int, now time.Time) (model.EmergencyGrant, error)
+}
+
+// EmergencyGrantFilter narrows ListEmergencyGrants. Zero fields do not filter;
+// From and To bound the grant time.
+type EmergencyGrantFilter struct {
+	UserID    int
+	PatientID int
+	From      *time.Time
+	To        *time.Time
+}
+
+type emergencyGrantRepo struct {
+	db *GormDatabase
+}
+
+func NewEmergencyGrantRepo(db *GormDatabase) EmergencyGrants {
+	return &emergencyGrantRepo{db: db}
+}
+
+const selectEmergencyGrants = `
+	SELECT
+		g.grant_id,
+		g.user_id,
+		u.username,
+		g.patient_id,
+		g.reason,
+		g.client_ip,
+		g.granted_at,
+		g.expires_at,
+		g.revoked_at
+	FROM emergency_access AS g
+	JOIN app_user AS u ON u.user_id = g.user_id`
+
+// InsertEmergencyGrant records the grant. An unknown patient yields
+// ErrInvalidReference.
+func (r *emergencyGrantRepo) InsertEmergencyGrant(ctx context.Context, g model.EmergencyGrant) (model.EmergencyGrant, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var ids []int
+	if err := db.Raw(`
+	INSERT INTO EMERGENCY_ACCESS (USER_ID, PATIENT_ID, REASON, CLIENT_IP, GRANTED_AT, EXPIRES_AT)
+	VALUES (?, ?, ?, ?, ?, ?)
+	RETURNING GRANT_ID`,
+		g.UserID, g.PatientID, g.Reason, g.ClientIP, g.GrantedAt, g.ExpiresAt).Scan(&ids).Error; err != nil {
+		return model.EmergencyGrant{}, translateError(err)
+	}
+	return r.SelectEmergencyGrant(ctx, ids[0])
+}
+
+// ListEmergencyGrants returns the matching grants, newest first.
+func (r *emergencyGrantRepo) ListEmergencyGrants(ctx context.Context, f EmergencyGrantFilter) ([]model.EmergencyGrant, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if f.UserID != 0 {
+		conds = append(conds, "g.user_id = ?")
+		args = append(args, f.UserID)
+	}
+	if f.PatientID != 0 {
+		conds = append(conds, "g.patient_id = ?")
+		args = append(args, f.PatientID)
+	}
+	if f.From != nil {
+		conds = append(conds, "g.granted_at >= ?")
+		args = append(args, *f.From)
+	}
+	if f.To != nil {
+		conds = append(conds, "g.granted_at <= ?")
+		args = append(args, *f.To)
+	}
+	query := selectEmergencyGrants
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	var records []model.EmergencyGrant
+	if err := db.Raw(query+` ORDER BY g.granted_at DESC, g.grant_id DESC`, args...).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *emergencyGrantRepo) SelectEmergencyGrant(ctx context.Context, grantID int) (model.EmergencyGrant, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.EmergencyGrant
+	if err := db.Raw(selectEmergencyGrants+` WHERE g.grant_id = ?`, grantID).Scan(&records).Error; err != nil {
+		return model.EmergencyGrant{}, err
+	}
+	if len(records) == 0 {
+		return model.EmergencyGrant{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// RevokeEmergencyGrant ends the grant early. Grants that already expired or
+// were revoked yield ErrConflict.
+func (r *emergencyGrantRepo) RevokeEmergencyGrant(ctx context.Context, grantID int, now time.Time) (model.EmergencyGrant, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	res := db.Exec(`
+	UPDATE EMERGENCY_ACCESS SET REVOKED_AT = ?
+	WHERE GRANT_ID = ? AND REVOKED_AT IS NULL AND EXPIRES_AT > ?`, now, grantID, now)
+	if res.Error != nil {
+		return model.EmergencyGrant{}, res.Error
+	}
+	g, err := r.SelectEmergencyGrant(ctx, grantID)
+	if err != nil {
+		return model.EmergencyGrant{}, err
+	}
+	if res.RowsAffected == 0 {
+		return model.EmergencyGrant{}, ErrConflict
+	}
+	return g, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict is returned when a write clashes with the current state,
+	// e.g. a duplicate key or an action that was already taken.
+	ErrConflict = errors.New("record conflicts with existing data")
+	// ErrInvalidReference is returned when a write points at a record that
+	// does not exist, e.g. an unknown doctor id.
+	ErrInvalidReference = errors.New("referenced record does not exist")
+	// ErrInUse is returned when a record cannot be retired because other
+	// active records still depend on it.
+	ErrInUse = errors.New("record is still in use")
+)
+
+// translateError maps constraint violations reported by the database to the
+// repository errors above and passes everything else through.
+func translateError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return ErrConflict
+		case "23503": // foreign_key_violation
+			return ErrInvalidReference
+		}
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return ErrConflict
+		case sqlite3.ErrConstraintForeignKey:
+			return ErrInvalidReference
+		}
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	model "health-care-backend/repository/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Medications interface {
+	ListMedicationOrders(ctx context.Context, pid int, status string) ([]model.MedicationOrder, error)
+	SelectMedicationOrder(ctx context.Context, pid, orderID int) (model.MedicationOrder, error)
+	InsertMedicationOrder(ctx context.Context, o model.MedicationOrder) (model.MedicationOrder, error)
+	UpdateMedicationOrder(ctx context.Context, o model.MedicationOrder) (model.MedicationOrder, error)
+}
+
+type medicationRepo struct {
+	db *GormDatabase
+}
+
+func NewMedicationRepo(db *GormDatabase) Medications {
+	return &medicationRepo{db: db}
+}
+
+// ListMedicationOrders returns the patient's orders, oldest first. An empty
+// status returns orders in every status.
+func (r *medicationRepo) ListMedicationOrders(ctx context.Context, pid int, status string) ([]model.MedicationOrder, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	if _, err := NewPatientRepo(r.db).SelectPatient(ctx, pid); err != nil {
+		return nil, err
+	}
+	var records []model.MedicationOrder
+	if err := db.Raw(`
+	SELECT * FROM MEDICATION_ORDER
+	WHERE PATIENT_ID = ? AND (? = '' OR STATUS = ?)
+	ORDER BY START_DATE, ORDER_ID`, pid, status, status).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *medicationRepo) SelectMedicationOrder(ctx context.Context, pid, orderID int) (model.MedicationOrder, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.MedicationOrder
+	if err := db.Raw(`
+	SELECT * FROM MEDICATION_ORDER
+	WHERE PATIENT_ID = ? AND ORDER_ID = ?`, pid, orderID).Scan(&records).Error; err != nil {
+		return model.MedicationOrder{}, err
+	}
+	if len(records) == 0 {
+		return model.MedicationOrder{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// InsertMedicationOrder creates an active order. The patient must be
+// admitted and the prescribing doctor active; a nil PrescribingDoctorID
+// defaults to the attending doctor.
+func (r *medicationRepo) InsertMedicationOrder(ctx context.Context, o model.MedicationOrder) (model.MedicationOrder, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.MedicationOrder
+	err := db.Transaction(func(tx *gorm.DB) error {
+		p, err := lockAdmittedPatient(tx, o.PatientID)
+		if err != nil {
+			return err
+		}
+		if o.PrescribingDoctorID == nil {
+			o.PrescribingDoctorID = &p.DoctorID
+		}
+		if err := requireActiveStaff(tx, DoctorStaff, *o.PrescribingDoctorID); err != nil {
+			return err
+		}
+		return tx.Raw(`
+		INSERT INTO MEDICATION_ORDER (PATIENT_ID, NAME, DOSE, UNIT, ROUTE, FREQUENCY, START_DATE, STOP_DATE, PRESCRIBING_DOCTOR_ID, STATUS)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING *`,
+			o.PatientID, o.Name, o.Dose, o.Unit, o.Route, o.Frequency, o.StartDate, o.StopDate, o.PrescribingDoctorID, model.MedicationActive).Scan(&records).Error
+	})
+	if err != nil {
+		return model.MedicationOrder{}, err
+	}
+	return records[0], nil
+}
+
+// UpdateMedicationOrder overwrites the mutable fields of the order.
+// Discontinued orders are final and yield ErrConflict.
+func (r *medicationRepo) UpdateMedicationOrder(ctx context.Context, o model.MedicationOrder) (model.MedicationOrder, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.MedicationOrder
+	if err := db.Raw(`
+	UPDATE MEDICATION_ORDER SET
+		DOSE = ?, UNIT = ?, ROUTE = ?, FREQUENCY = ?, STOP_DATE = ?, STATUS = ?,
+		UPDATED_AT = ?
+	WHERE PATIENT_ID = ? AND ORDER_ID = ? AND STATUS <> ?
+	RETURNING *`,
+		o.Dose, o.Unit, o.Route, o.Frequency, o.StopDate, o.Status, time.Now().UTC(),
+		o.PatientID, o.OrderID, model.MedicationDiscontinued).Scan(&records).Error; err != nil {
+		return model.MedicationOrder{}, err
+	}
+	if len(records) == 0 {
+		if _, err := r.SelectMedicationOrder(ctx, o.PatientID, o.OrderID); err != nil {
+			return model.MedicationOrder{}, err
+		}
+		return model.MedicationOrder{}, ErrConflict
+	}
+	return records[0], nil
+}
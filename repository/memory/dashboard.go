@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"health-care-backend/policy"
+	"health-care-backend/repository"
+	model "health-care-backend/repository/model"
+)
+
+// dashboardRow is a patient of a doctor or nurse dashboard before it is
+// turned into a view row.
+type dashboardRow struct {
+	patient model.Patient
+	age     int
+	nurseID int
+	vitals  *model.VitalSign
+}
+
+func (s *Store) SelectPatientDashboard(ctx context.Context, p policy.Principal, pid int) ([]model.PatientDashboardView, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	patient, ok := s.patients[pid]
+	doctor, hasDoctor := s.doctors[patient.DoctorID]
+	if !ok || !hasDoctor || !s.inScope(p, pid) {
+		return nil, nil
+	}
+	view := model.PatientDashboardView{
+		ID:                      patient.PatientID,
+		FirstName:               patient.FirstName,
+		LastName:                patient.LastName,
+		Age:                     patient.AgeAt(s.Now()),
+		Sex:                     patient.Sex,
+		BloodType:               patient.BloodType,
+		DOB:                     patient.DOB,
+		AssignedDoctorID:        doctor.ID,
+		AssignedDoctorFirstName: doctor.FirstName,
+		AssignedDoctorLastName:  doctor.LastName,
+		CurrentPrescribedMeds:   s.currentMedications(pid),
+		CurrentDiseases:         s.currentDiagnoses(pid),
+	}
+	if v := s.latestVitalSign(pid); v != nil {
+		view.BodyTemperature = &v.BodyTemperature
+		view.PulseRate = &v.PulseRate
+		view.RespirationRate = &v.RespirationRate
+		view.SystolicPressure = &v.SystolicPressure
+		view.DiastolicPressure = &v.DiastolicPressure
+		view.OxygenSaturation = v.OxygenSaturation
+		view.SupplementalOxygen = v.SupplementalOxygen
+		view.Consciousness = v.Consciousness
+		view.NEWS2Score = &v.NEWS2Score
+		view.NEWS2Rank = &v.NEWS2Rank
+		view.VitalsRecordedAt = &v.IssueTime
+	}
+	return []model.PatientDashboardView{view}, nil
+}
+
+func (s *Store) SelectDoctorDashboard(ctx context.Context, p policy.Principal, did int, q repository.DashboardQuery) ([]model.DoctorDashboardView, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var rows []dashboardRow
+	for _, patient := range s.patients {
+		if patient.DoctorID == did {
+			rows = append(rows, dashboardRow{patient: patient})
+		}
+	}
+	views := []model.DoctorDashboardView{}
+	for _, r := range s.page(p, q, rows) {
+		doctor := s.doctors[r.patient.DoctorID]
+		view := model.DoctorDashboardView{
+			PatientID:               r.patient.PatientID,
+			FirstName:               r.patient.FirstName,
+			LastName:                r.patient.LastName,
+			Age:                     r.age,
+			Sex:                     r.patient.Sex,
+			BloodType:               r.patient.BloodType,
+			PhoneNumber:             r.patient.PhoneNumber,
+			Address:                 r.patient.Address,
+			DOB:                     r.patient.DOB,
+			AssignedDoctorID:        doctor.ID,
+			AssignedDoctorFirstName: doctor.FirstName,
+			AssignedDoctorLastName:  doctor.LastName,
+			CurrentPrescribedMeds:   s.currentMedications(r.patient.PatientID),
+			CurrentDiseases:         s.currentDiagnoses(r.patient.PatientID),
+		}
+		if v := r.vitals; v != nil {
+			view.BodyTemperature = &v.BodyTemperature
+			view.PulseRate = &v.PulseRate
+			view.RespirationRate = &v.RespirationRate
+			view.SystolicPressure = &v.SystolicPressure
+			view.DiastolicPressure = &v.DiastolicPressure
+			view.OxygenSaturation = v.OxygenSaturation
+			view.SupplementalOxygen = v.SupplementalOxygen
+			view.Consciousness = v.Consciousness
+			view.NEWS2Score = &v.NEWS2Score
+			view.NEWS2Rank = &v.NEWS2Rank
+			view.VitalsRecordedAt = &v.IssueTime
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *Store) SelectNurseDashboard(ctx context.Context, p policy.Principal, nid int, q repository.DashboardQuery) ([]model.NurseDashboardView, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.nurses[nid]; !ok {
+		return []model.NurseDashboardView{}, nil
+	}
+	var rows []dashboardRow
+	for pid, nurses := range s.patientNurses {
+		for _, n := range nurses {
+			if n == nid {
+				rows = append(rows, dashboardRow{patient: s.patients[pid], nurseID: nid})
+				break
+			}
+		}
+	}
+	views := []model.NurseDashboardView{}
+	for _, r := range s.page(p, q, rows) {
+		nurse := s.nurses[r.nurseID]
+		doctor := s.doctors[r.patient.DoctorID]
+		view := model.NurseDashboardView{
+			NurseID:                 nurse.ID,
+			NurseFirstName:          nurse.FirstName,
+			NurseLastName:           nurse.LastName,
+			PatientID:               r.patient.PatientID,
+			PatientFirstName:        r.patient.FirstName,
+			PatientLastName:         r.patient.LastName,
+			Age:                     r.age,
+			Sex:                     r.patient.Sex,
+			BloodType:               r.patient.BloodType,
+			PhoneNumber:             r.patient.PhoneNumber,
+			Address:                 r.patient.Address,
+			DOB:                     r.patient.DOB,
+			AssignedDoctorID:        doctor.ID,
+			AssignedDoctorFirstName: doctor.FirstName,
+			AssignedDoctorLastName:  doctor.LastName,
+			CurrentPrescribedMeds:   s.currentMedications(r.patient.PatientID),
+			CurrentDiseases:         s.currentDiagnoses(r.patient.PatientID),
+		}
+		if v := r.vitals; v != nil {
+			view.BodyTemperature = &v.BodyTemperature
+			view.PulseRate = &v.PulseRate
+			view.RespirationRate = &v.RespirationRate
+			view.SystolicPressure = &v.SystolicPressure
+			view.DiastolicPressure = &v.DiastolicPressure
+			view.OxygenSaturation = v.OxygenSaturation
+			view.SupplementalOxygen = v.SupplementalOxygen
+			view.Consciousness = v.Consciousness
+			view.NEWS2Score = &v.NEWS2Score
+			view.NEWS2Rank = &v.NEWS2Rank
+			view.VitalsRecordedAt = &v.IssueTime
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// page drops the rows the views or the scope exclude, then filters, sorts,
+// and cuts out the page of the query like repository.DashboardQuery does in
+// SQL.
+func (s *Store) page(p policy.Principal, q repository.DashboardQuery, rows []dashboardRow) []dashboardRow {
+	var kept []dashboardRow
+	for _, r := range rows {
+		r.age = r.patient.AgeAt(s.Now())
+		_, hasDoctor := s.doctors[r.patient.DoctorID]
+		if !hasDoctor || r.patient.DischargedAt != nil || !s.inScope(p, r.patient.PatientID) || !s.matches(q, r) {
+			continue
+		}
+		r.vitals = s.latestVitalSign(r.patient.PatientID)
+		kept = append(kept, r)
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		return before(q, sortValue(q.Sort, kept[i]), kept[i].patient.PatientID, sortValue(q.Sort, kept[j]), kept[j].patient.PatientID)
+	})
+	if c := q.After; c != nil {
+		i := sort.Search(len(kept), func(i int) bool {
+			return before(q, c.Value, c.PatientID, sortValue(q.Sort, kept[i]), kept[i].patient.PatientID)
+		})
+		kept = kept[i:]
+	}
+	if q.Limit > 0 && len(kept) > q.Limit {
+		kept = kept[:q.Limit]
+	}
+	return kept
+}
+
+// sortValue is the value of the row the query sorts by, typed like the values
+// of repository.DashboardCursor; nil when the row has none.
+func sortValue(by repository.DashboardSort, r dashboardRow) interface{} {
+	switch by {
+	case repository.SortByAge:
+		return r.age
+	case repository.SortByVitalsTime:
+		if r.vitals == nil {
+			return nil
+		}
+		return r.vitals.IssueTime
+	case repository.SortByRisk:
+		if r.vitals == nil {
+			return nil
+		}
+		return r.vitals.NEWS2Rank
+	}
+	return r.patient.LastName
+}
+
+// before reports whether the row with sort value a and patient id aid comes
+// before the one with b and bid: rows without a value last, then by value in
+// the query's direction, then by patient id.
+func before(q repository.DashboardQuery, a interface{}, aid int, b interface{}, bid int) bool {
+	if (a == nil) != (b == nil) {
+		return b == nil
+	}
+	if a != nil {
+		c := compare(a, b)
+		if q.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c < 0
+		}
+	}
+	return aid < bid
+}
+
+func compare(a, b interface{}) int {
+	switch a := a.(type) {
+	case int:
+		b := b.(int)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+		return 0
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case string:
+		return strings.Compare(a, b.(string))
+	}
+	return 0
+}
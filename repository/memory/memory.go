@@ -0,0 +1,312 @@
+// Package memory implements the dashboard and access repositories on plain Go
+// values, so that the dashboard handlers can be tested without a database.
+// It is no general test backend: the other handlers are tested against an
+// in-memory SQLite database, see repository.NewGormDatabase. Queries with a
+// done context fail with the context's error, as database queries do.
+//
+// A Store holds the rows of the tables it needs and answers with the
+// semantics of the SQL repositories: the same joins and filters as the
+// dashboard views, the same patient scope, the same sort orders and
+// cursors. Strings are compared byte-wise, while postgres sorts by the
+// collation of the database, so names that differ only in case or accents
+// may come out in a different order.
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"health-care-backend/news2"
+	"health-care-backend/policy"
+	"health-care-backend/repository"
+	model "health-care-backend/repository/model"
+)
+
+var (
+	_ repository.Dashboard = (*Store)(nil)
+	_ repository.Access    = (*Store)(nil)
+)
+
+// Store is safe for concurrent use.
+type Store struct {
+	mu            sync.RWMutex
+	doctors       map[int]model.StaffMember
+	nurses        map[int]model.StaffMember
+	patients      map[int]model.Patient
+	patientNurses map[int][]int // nurse ids by patient id
+	vitalSigns    map[int][]model.VitalSign
+	medications   map[int][]model.MedicationOrder
+	diagnoses     map[int][]model.Diagnosis
+	grants        []model.EmergencyGrant
+
+	// Now is the clock emergency grants are checked against.
+	Now func() time.Time
+}
+
+func NewStore() *Store {
+	return &Store{
+		doctors:       make(map[int]model.StaffMember),
+		nurses:        make(map[int]model.StaffMember),
+		patients:      make(map[int]model.Patient),
+		patientNurses: make(map[int][]int),
+		vitalSigns:    make(map[int][]model.VitalSign),
+		medications:   make(map[int][]model.MedicationOrder),
+		diagnoses:     make(map[int][]model.Diagnosis),
+		Now:           func() time.Time { return time.Now().UTC() },
+	}
+}
+
+func (s *Store) AddDoctor(d model.StaffMember) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doctors[d.ID] = d
+}
+
+func (s *Store) AddNurse(n model.StaffMember) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nurses[n.ID] = n
+}
+
+// AddPatient adds or replaces a patient, e.g. to reassign or discharge them.
+func (s *Store) AddPatient(p model.Patient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.patients[p.PatientID] = p
+}
+
+func (s *Store) AssignNurse(pid, nid int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.patientNurses[pid] = append(s.patientNurses[pid], nid)
+}
+
+// AddVitalSign scores the reading like repository.VitalSigns does.
+func (s *Store) AddVitalSign(v model.VitalSign) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	score := news2.Compute(v.NEWS2Inputs())
+	v.NEWS2Score, v.NEWS2Rank = score.Total, score.Rank()
+	s.vitalSigns[v.PatientID] = append(s.vitalSigns[v.PatientID], v)
+}
+
+func (s *Store) AddMedicationOrder(o model.MedicationOrder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.medications[o.PatientID] = append(s.medications[o.PatientID], o)
+}
+
+func (s *Store) AddDiagnosis(d model.Diagnosis) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.diagnoses[d.PatientID] = append(s.diagnoses[d.PatientID], d)
+}
+
+func (s *Store) AddEmergencyGrant(g model.EmergencyGrant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.grants = append(s.grants, g)
+}
+
+// CanAccessPatient reports whether the patient exists and is in the
+// principal's scope.
+func (s *Store) CanAccessPatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.patients[pid]
+	return ok && s.inScope(p, pid), nil
+}
+
+// CanManagePatient is CanAccessPatient without emergency grants.
+func (s *Store) CanManagePatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.patients[pid]
+	return ok && s.assigned(p, pid), nil
+}
+
+// NextGrantExpiry returns when the first active grant of the principal
+// expires.
+func (s *Store) NextGrantExpiry(ctx context.Context, p policy.Principal) (*time.Time, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p.Role != policy.Doctor && p.Role != policy.Nurse {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.Now()
+	var next *time.Time
+	for _, g := range s.grants {
+		if g.UserID == p.UserID && g.Active(now) && (next == nil || g.ExpiresAt.Before(*next)) {
+			expires := g.ExpiresAt
+			next = &expires
+		}
+	}
+	return next, nil
+}
+
+// inScope mirrors the patient scope of the SQL repositories.
+func (s *Store) inScope(p policy.Principal, pid int) bool {
+	if s.assigned(p, pid) {
+		return true
+	}
+	return (p.Role == policy.Doctor || p.Role == policy.Nurse) && s.hasGrant(p.UserID, pid)
+}
+
+// assigned mirrors the scope without emergency grants.
+func (s *Store) assigned(p policy.Principal, pid int) bool {
+	switch p.Role {
+	case policy.Admin:
+		return true
+	case policy.Doctor:
+		return s.patients[pid].DoctorID == p.DoctorID
+	case policy.Nurse:
+		for _, nid := range s.patientNurses[pid] {
+			if nid == p.NurseID {
+				return true
+			}
+		}
+	case policy.Patient:
+		return pid == p.PatientID
+	}
+	return false
+}
+
+func (s *Store) hasGrant(uid, pid int) bool {
+	now := s.Now()
+	for _, g := range s.grants {
+		if g.UserID == uid && g.PatientID == pid && g.Active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// latestVitalSign is nil for patients without readings.
+func (s *Store) latestVitalSign(pid int) *model.VitalSign {
+	var latest *model.VitalSign
+	for i, v := range s.vitalSigns[pid] {
+		if latest == nil || v.IssueTime.After(latest.IssueTime) {
+			latest = &s.vitalSigns[pid][i]
+		}
+	}
+	return latest
+}
+
+// currentMedications are the orders that are not discontinued, by start date
+// and name.
+func (s *Store) currentMedications(pid int) model.DashboardMedications {
+	meds := model.DashboardMedications{}
+	for _, o := range s.medications[pid] {
+		if o.Status == model.MedicationDiscontinued {
+			continue
+		}
+		meds = append(meds, model.DashboardMedication{
+			OrderID:             o.OrderID,
+			Name:                o.Name,
+			Dose:                o.Dose,
+			Unit:                o.Unit,
+			Route:               o.Route,
+			Frequency:           o.Frequency,
+			StartDate:           o.StartDate,
+			StopDate:            o.StopDate,
+			PrescribingDoctorID: o.PrescribingDoctorID,
+			Status:              o.Status,
+		})
+	}
+	sort.Slice(meds, func(i, j int) bool {
+		a, b := meds[i], meds[j]
+		if !a.StartDate.Equal(b.StartDate.Time) {
+			return a.StartDate.Before(b.StartDate.Time)
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.OrderID < b.OrderID
+	})
+	return meds
+}
+
+// currentDiagnoses are the unresolved diagnoses, the primary one first, then
+// by onset date, undated last, and description.
+func (s *Store) currentDiagnoses(pid int) model.DashboardDiagnoses {
+	diagnoses := model.DashboardDiagnoses{}
+	for _, d := range s.diagnoses[pid] {
+		if d.ResolvedDate != nil {
+			continue
+		}
+		diagnoses = append(diagnoses, model.DashboardDiagnosis{
+			DiagnosisID:  d.DiagnosisID,
+			ICD10Code:    d.ICD10Code,
+			Description:  d.Description,
+			OnsetDate:    d.OnsetDate,
+			ResolvedDate: d.ResolvedDate,
+			IsPrimary:    d.IsPrimary,
+		})
+	}
+	sort.Slice(diagnoses, func(i, j int) bool {
+		a, b := diagnoses[i], diagnoses[j]
+		if a.IsPrimary != b.IsPrimary {
+			return a.IsPrimary
+		}
+		if (a.OnsetDate == nil) != (b.OnsetDate == nil) {
+			return b.OnsetDate == nil
+		}
+		if a.OnsetDate != nil && !a.OnsetDate.Equal(b.OnsetDate.Time) {
+			return a.OnsetDate.Before(b.OnsetDate.Time)
+		}
+		if a.Description != b.Description {
+			return a.Description < b.Description
+		}
+		return a.DiagnosisID < b.DiagnosisID
+	})
+	return diagnoses
+}
+
+// matches applies the filters of the query.
+func (s *Store) matches(q repository.DashboardQuery, r dashboardRow) bool {
+	p := r.patient
+	if q.BloodType != "" && p.BloodType != q.BloodType {
+		return false
+	}
+	if (q.MinAge != nil && r.age < *q.MinAge) || (q.MaxAge != nil && r.age > *q.MaxAge) {
+		return false
+	}
+	if q.Disease != "" {
+		found := false
+		for _, d := range s.currentDiagnoses(p.PatientID) {
+			if (d.ICD10Code != nil && strings.HasPrefix(*d.ICD10Code, strings.ToUpper(q.Disease))) ||
+				strings.Contains(strings.ToLower(d.Description), strings.ToLower(q.Disease)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Medication != "" {
+		found := false
+		for _, o := range s.currentMedications(p.PatientID) {
+			if strings.Contains(strings.ToLower(o.Name), strings.ToLower(q.Medication)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
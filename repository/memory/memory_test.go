@@ -0,0 +1,195 @@
+package memory
+
+import (
+	"context"
+	"health-care-backend/policy"
+	"health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var day = time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+
+// bornYearsAgo is the date of birth of someone turning age yesterday.
+func bornYearsAgo(age int) time.Time {
+	return time.Now().UTC().AddDate(-age, 0, -1)
+}
+
+func date(t time.Time) *model.Date {
+	return &model.Date{Time: t}
+}
+
+// ward has doctor 1 with patients 1 to 4 and nurse 1 looking after 1 to 3.
+// Patient 4 has been discharged, patient 3 has no readings.
+func ward() *Store {
+	s := NewStore()
+	s.AddDoctor(model.StaffMember{ID: 1, FirstName: "Gregory", LastName: "House"})
+	s.AddDoctor(model.StaffMember{ID: 2, FirstName: "Lisa", LastName: "Cuddy"})
+	s.AddNurse(model.StaffMember{ID: 1, FirstName: "Carla", LastName: "Espinosa"})
+	discharged := day
+	for _, p := range []model.Patient{
+		{PatientID: 1, LastName: "Miller", DOB: bornYearsAgo(70), BloodType: "A+", DoctorID: 1},
+		{PatientID: 2, LastName: "Adams", DOB: bornYearsAgo(35), BloodType: "O-", DoctorID: 1},
+		{PatientID: 3, LastName: "Baker", DOB: bornYearsAgo(52), BloodType: "A+", DoctorID: 1},
+		{PatientID: 4, LastName: "Clark", DOB: bornYearsAgo(44), BloodType: "B+", DoctorID: 1, DischargedAt: &discharged},
+		{PatientID: 5, LastName: "Davis", DOB: bornYearsAgo(61), BloodType: "AB+", DoctorID: 2},
+	} {
+		s.AddPatient(p)
+	}
+	for pid := 1; pid <= 3; pid++ {
+		s.AssignNurse(pid, 1)
+	}
+	// normal readings score 0; patient 1's latest scores 3 for the pulse
+	normal := model.VitalSign{BodyTemperature: 98.6, PulseRate: 70, RespirationRate: 16, SystolicPressure: 120, DiastolicPressure: 80}
+	for _, v := range []struct {
+		pid   int
+		at    time.Time
+		pulse int
+	}{{1, day, 70}, {1, day.Add(2 * time.Hour), 140}, {2, day.Add(time.Hour), 70}, {5, day, 70}} {
+		reading := normal
+		reading.PatientID, reading.IssueTime, reading.PulseRate = v.pid, v.at, v.pulse
+		s.AddVitalSign(reading)
+	}
+	s.AddMedicationOrder(model.MedicationOrder{OrderID: 3, PatientID: 1, Name: "Metformin", StartDate: model.Date{Time: day}, Status: model.MedicationActive})
+	s.AddMedicationOrder(model.MedicationOrder{OrderID: 2, PatientID: 1, Name: "Aspirin", StartDate: model.Date{Time: day}, Status: model.MedicationHeld})
+	s.AddMedicationOrder(model.MedicationOrder{OrderID: 1, PatientID: 1, Name: "Warfarin", StartDate: model.Date{Time: day}, Status: model.MedicationDiscontinued})
+	s.AddMedicationOrder(model.MedicationOrder{OrderID: 4, PatientID: 2, Name: "Lisinopril", StartDate: model.Date{Time: day}, Status: model.MedicationActive})
+	code := "E11.9"
+	s.AddDiagnosis(model.Diagnosis{DiagnosisID: 1, PatientID: 1, Description: "Hypertension", OnsetDate: date(day)})
+	s.AddDiagnosis(model.Diagnosis{DiagnosisID: 2, PatientID: 1, Description: "Gout"})
+	s.AddDiagnosis(model.Diagnosis{DiagnosisID: 3, PatientID: 1, ICD10Code: &code, Description: "Type 2 diabetes", IsPrimary: true})
+	s.AddDiagnosis(model.Diagnosis{DiagnosisID: 4, PatientID: 3, Description: "Asthma", ResolvedDate: date(day)})
+	return s
+}
+
+func patientIDs(views []model.DoctorDashboardView) []int {
+	ids := []int{}
+	for _, v := range views {
+		ids = append(ids, v.PatientID)
+	}
+	return ids
+}
+
+func Test_PatientDashboard(t *testing.T) {
+	s := ward()
+	views, err := s.SelectPatientDashboard(context.Background(), policy.Principal{Role: policy.Patient, PatientID: 1}, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, views, 1) {
+		v := views[0]
+		assert.Equal(t, "House", v.AssignedDoctorLastName)
+		assert.Equal(t, 140, *v.PulseRate)
+		assert.Equal(t, 3, *v.NEWS2Score)
+		assert.Equal(t, day.Add(2*time.Hour), *v.VitalsRecordedAt)
+		assert.Equal(t, []int{2, 3}, []int{v.CurrentPrescribedMeds[0].OrderID, v.CurrentPrescribedMeds[1].OrderID})
+		assert.Equal(t, []int{3, 1, 2}, []int{v.CurrentDiseases[0].DiagnosisID, v.CurrentDiseases[1].DiagnosisID, v.CurrentDiseases[2].DiagnosisID})
+	}
+
+	views, _ = s.SelectPatientDashboard(context.Background(), policy.Principal{Role: policy.Patient, PatientID: 2}, 1)
+	assert.Empty(t, views)
+	// discharged patients keep their own dashboard
+	views, _ = s.SelectPatientDashboard(context.Background(), policy.Principal{Role: policy.Admin}, 4)
+	assert.Len(t, views, 1)
+	views, _ = s.SelectPatientDashboard(context.Background(), policy.Principal{Role: policy.Admin}, 3)
+	if assert.Len(t, views, 1) {
+		assert.Nil(t, views[0].NEWS2Score)
+		assert.Empty(t, views[0].CurrentDiseases)
+	}
+}
+
+func Test_DashboardScope(t *testing.T) {
+	s := ward()
+	doctor := policy.Principal{Role: policy.Doctor, UserID: 7, DoctorID: 2}
+	ok, err := s.CanAccessPatient(context.Background(), doctor, 1)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	views, _ := s.SelectDoctorDashboard(context.Background(), doctor, 1, repository.DashboardQuery{})
+	assert.Empty(t, views)
+
+	expires := time.Now().Add(time.Hour)
+	s.AddEmergencyGrant(model.EmergencyGrant{UserID: 7, PatientID: 1, ExpiresAt: expires})
+	ok, _ = s.CanAccessPatient(context.Background(), doctor, 1)
+	assert.True(t, ok)
+	next, _ := s.NextGrantExpiry(context.Background(), doctor)
+	if assert.NotNil(t, next) {
+		assert.Equal(t, expires, *next)
+	}
+	// grants only let their holder read
+	ok, _ = s.CanManagePatient(context.Background(), doctor, 1)
+	assert.False(t, ok)
+	views, _ = s.SelectDoctorDashboard(context.Background(), doctor, 1, repository.DashboardQuery{})
+	assert.Equal(t, []int{1}, patientIDs(views))
+
+	s.Now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	ok, _ = s.CanAccessPatient(context.Background(), doctor, 1)
+	assert.False(t, ok)
+	next, _ = s.NextGrantExpiry(context.Background(), doctor)
+	assert.Nil(t, next)
+
+	nurse := policy.Principal{Role: policy.Nurse, NurseID: 1}
+	nurseViews, _ := s.SelectNurseDashboard(context.Background(), nurse, 1, repository.DashboardQuery{})
+	assert.Len(t, nurseViews, 3)
+	ok, _ = s.CanAccessPatient(context.Background(), nurse, 5)
+	assert.False(t, ok)
+	ok, _ = s.CanAccessPatient(context.Background(), policy.Principal{Role: policy.Admin}, 9)
+	assert.False(t, ok)
+}
+
+func Test_DashboardQuery(t *testing.T) {
+	s := ward()
+	admin := policy.Principal{Role: policy.Admin}
+	cases := []struct {
+		q   repository.DashboardQuery
+		ids []int
+	}{
+		{repository.DashboardQuery{}, []int{2, 3, 1}},
+		{repository.DashboardQuery{Descending: true}, []int{1, 3, 2}},
+		{repository.DashboardQuery{Sort: repository.SortByAge}, []int{2, 3, 1}},
+		{repository.DashboardQuery{Sort: repository.SortByRisk, Descending: true}, []int{1, 2, 3}},
+		{repository.DashboardQuery{Sort: repository.SortByVitalsTime, Descending: true}, []int{1, 2, 3}},
+		{repository.DashboardQuery{Sort: repository.SortByVitalsTime}, []int{2, 1, 3}},
+		{repository.DashboardQuery{Limit: 2}, []int{2, 3}},
+		{repository.DashboardQuery{After: &repository.DashboardCursor{Value: "Adams", PatientID: 2}}, []int{3, 1}},
+		{repository.DashboardQuery{Sort: repository.SortByRisk, Descending: true, After: &repository.DashboardCursor{Value: 0, PatientID: 2}}, []int{3}},
+		{repository.DashboardQuery{Sort: repository.SortByRisk, After: &repository.DashboardCursor{PatientID: 2}}, []int{3}},
+		{repository.DashboardQuery{Disease: "e11"}, []int{1}},
+		{repository.DashboardQuery{Disease: "gout"}, []int{1}},
+		{repository.DashboardQuery{Disease: "asthma"}, []int{}},
+		{repository.DashboardQuery{Medication: "warfarin"}, []int{}},
+		{repository.DashboardQuery{Medication: "PRIL"}, []int{2}},
+		{repository.DashboardQuery{BloodType: "A+"}, []int{3, 1}},
+		{repository.DashboardQuery{MinAge: intPtr(40), MaxAge: intPtr(60)}, []int{3}},
+	}
+	for _, c := range cases {
+		views, err := s.SelectDoctorDashboard(context.Background(), admin, 1, c.q)
+		assert.NoError(t, err)
+		assert.Equal(t, c.ids, patientIDs(views), "%+v", c.q)
+	}
+}
+
+func Test_SortByRiskBand(t *testing.T) {
+	s := NewStore()
+	s.AddDoctor(model.StaffMember{ID: 1, FirstName: "Gregory", LastName: "House"})
+	s.AddPatient(model.Patient{PatientID: 1, LastName: "Miller", DoctorID: 1})
+	s.AddPatient(model.Patient{PatientID: 2, LastName: "Adams", DoctorID: 1})
+	// patient 1 totals 4 without a red parameter, patient 2 scores 3 for the
+	// pulse alone and is in the higher band
+	s.AddVitalSign(model.VitalSign{PatientID: 1, IssueTime: day, BodyTemperature: 98.6, PulseRate: 95,
+		RespirationRate: 22, SystolicPressure: 105, DiastolicPressure: 80})
+	s.AddVitalSign(model.VitalSign{PatientID: 2, IssueTime: day, BodyTemperature: 98.6, PulseRate: 140,
+		RespirationRate: 16, SystolicPressure: 120, DiastolicPressure: 80})
+	views, err := s.SelectDoctorDashboard(context.Background(), policy.Principal{Role: policy.Admin}, 1,
+		repository.DashboardQuery{Sort: repository.SortByRisk, Descending: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 1}, patientIDs(views))
+	if assert.Len(t, views, 2) {
+		assert.Equal(t, 4, *views[1].NEWS2Score)
+		assert.Equal(t, 3, *views[0].NEWS2Score)
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -1,274 +1,275 @@
 package repository
 
 import (
-	"gorm.io/driver/postgres"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"health-care-backend/logging"
+
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
 )
 
+// migrationLockID is the key of the postgres advisory lock held while
+// migrating, so that replicas booting at the same time migrate one by one.
+const migrationLockID = 5500_0001
+
 type GormDatabase struct {
 	DB *gorm.DB
+	// QueryTimeout bounds every repository call, on top of the deadline of
+	// the caller's context. Zero leaves calls unbounded.
+	QueryTimeout time.Duration
+}
+
+// Migration is a single versioned schema change. Up and Down may hold several
+// statements separated by semicolons.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// redactingWriter passes gorm's log lines through logging.RedactText.
+type redactingWriter struct {
+	logger *log.Logger
 }
 
+func (w redactingWriter) Printf(format string, args ...interface{}) {
+	w.logger.Print(logging.RedactText(fmt.Sprintf(format, args...)))
+}
+
+// NewGormDatabase connects to postgres, or to SQLite when the DSN starts with
+// "sqlite:".
 func NewGormDatabase(dsn string, debug bool) (*GormDatabase, error) {
+	// queries are logged without their parameters, which hold patient data,
+	// and errors echoing values are redacted
+	logLevel := gormLogger.Warn
+	if debug {
+		logLevel = gormLogger.Info
+	}
 	config := &gorm.Config{
 		DisableForeignKeyConstraintWhenMigrating: true,
+		Logger: gormLogger.New(redactingWriter{log.New(os.Stdout, "\r\n", log.LstdFlags)}, gormLogger.Config{
+			SlowThreshold:        200 * time.Millisecond,
+			LogLevel:             logLevel,
+			ParameterizedQueries: true,
+			Colorful:             true,
+		}),
 	}
 
-	if debug {
-		config.Logger = gormLogger.Default.LogMode(gormLogger.Info)
-	}
-
-	db, err := gorm.Open(postgres.Open(dsn), config)
+	db, err := gorm.Open(dialector(dsn), config)
 	if err != nil {
 		return nil, err
 	}
+	if dialectOf(db) == SQLite {
+		// an in-memory database lives and dies with its connection, and a
+		// single writer never runs into SQLITE_BUSY
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
 	return &GormDatabase{DB: db}, nil
 }
 
-func (d *GormDatabase) AutoMigrate() error {
-	// here we don't actually need to use the gorm library. We can just use the raw sql
-	if err := d.DB.Exec(`
-	CREATE TABLE DOCTOR (
-	DOCTOR_ID INT,
-	FIRST_NAME VARCHAR(50) NOT NULL,
-	LAST_NAME VARCHAR(50) NOT NULL,
-	PRIMARY KEY (DOCTOR_ID));`).Error; err != nil {
-		return err
-	}
+// PoolConfig sizes the connection pool. Zero values keep the defaults of
+// database/sql.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
 
-	if err := d.DB.Exec(`
-	CREATE TABLE PATIENT (
-	PATIENT_ID INT,
-    FIRST_NAME VARCHAR(50) NOT NULL,
-	LAST_NAME VARCHAR(50) NOT NULL,
-    AGE INT NOT NULL,
-    SEX CHAR NOT NULL,
-	PHONE_NUMBER VARCHAR(50) NOT NULL,
-	ADDRESS VARCHAR(50) NOT NULL,
-    BLOOD_TYPE CHAR(2) NOT NULL,
-    DOB DATE NOT NULL,
-    DOCTOR_ID INT NOT NULL,
-    PRIMARY KEY (PATIENT_ID),
-    CONSTRAINT PATIENT_FK_DOCTOR_ID FOREIGN KEY (DOCTOR_ID) REFERENCES DOCTOR(DOCTOR_ID));`).Error; err != nil {
-		return err
+// ConfigurePool applies c to the connection pool. SQLite databases keep their
+// single connection for good, an in-memory database would be lost with it.
+func (d *GormDatabase) ConfigurePool(c PoolConfig) error {
+	if d.Dialect() == SQLite {
+		return nil
 	}
-
-	if err := d.DB.Exec(`
-	CREATE TABLE VITAL_SIGN (
-    PATIENT_ID INT,
-    ISSUE_TIME TIMESTAMP,
-    BODY_TEMPERATURE FLOAT NOT NULL,
-    PULSE_RATE INT NOT NULL,
-    RESPIRATION_RATE INT NOT NULL,
-    SYSTOLIC_PRESSURE INT NOT NULL,
-    DIASTOLIC_PRESSURE INT NOT NULL,
-    PRIMARY KEY(PATIENT_ID, ISSUE_TIME),
-    CONSTRAINT VITAL_SIGN_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));`).Error; err != nil {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
 		return err
 	}
-
-	if err := d.DB.Exec(`
-	CREATE TABLE PATIENT_MEDICATIONS (
-	PATIENT_ID INT,
-	PRESCRIBED_MEDICATIONS VARCHAR(50),
-    PRIMARY KEY(PATIENT_ID, PRESCRIBED_MEDICATIONS),
-    CONSTRAINT PATIENT_MEDICATIONS_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));`).Error; err != nil {
-		return err
+	if c.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(c.MaxOpenConns)
 	}
-
-	if err := d.DB.Exec(`
-	CREATE TABLE PATIENT_DISEASE (
-	PATIENT_ID INT,
-	DISEASE VARCHAR(50),
-	PRIMARY KEY(PATIENT_ID, DISEASE),
-    CONSTRAINT PATIENT_DISEASE_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));`).Error; err != nil {
-		return err
+	if c.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(c.MaxIdleConns)
 	}
-
-	if err := d.DB.Exec(`
-	CREATE TABLE NURSE (
-	NURSE_ID INT,
-    FIRST_NAME VARCHAR(50) NOT NULL,
-	LAST_NAME VARCHAR(50) NOT NULL,
-	PRIMARY KEY (NURSE_ID));`).Error; err != nil {
-		return err
+	if c.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
 	}
+	return nil
+}
 
-	if err := d.DB.Exec(`
-	CREATE TABLE PATIENT_NURSE (
-	PATIENT_ID INT,
-    NURSE_ID INT,
-	PRIMARY KEY (PATIENT_ID, NURSE_ID),
-    CONSTRAINT PATIENT_NURSE_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
-    CONSTRAINT PATIENT_NURSE_FK_NURSE_ID FOREIGN KEY (NURSE_ID) REFERENCES NURSE(NURSE_ID));`).Error; err != nil {
+// Close closes the connection pool. Queries still running are finished
+// first.
+func (d *GormDatabase) Close() error {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
 		return err
 	}
+	return sqlDB.Close()
+}
 
-	// insert some doctors
-	if err := d.DB.Exec(`
-	INSERT INTO DOCTOR (DOCTOR_ID, FIRST_NAME, LAST_NAME)
-		VALUES (1, 'John', 'Doe'),
-       (2, 'Jane', 'Smith'),
-       (3, 'Michael', 'Johnson');`).Error; err != nil {
-		return err
+// conn binds the database to ctx, cut off after QueryTimeout, so that a query
+// stops once its request is gone. cancel must be called when the query or
+// transaction is done.
+func (d *GormDatabase) conn(ctx context.Context) (db *gorm.DB, cancel context.CancelFunc) {
+	if d.QueryTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, d.QueryTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
 	}
+	return d.DB.WithContext(ctx), cancel
+}
 
-	// insert some patients
-	if err := d.DB.Exec(`
-	INSERT INTO PATIENT (PATIENT_ID, FIRST_NAME, LAST_NAME, AGE, SEX, BLOOD_TYPE, DOB, DOCTOR_ID, PHONE_NUMBER, ADDRESS)
-	VALUES (1, 'Alice', 'Johnson', 35, 'F', 'A+', '1988-03-12', 1, '123-456-7890', '123 Main St'),
-       (2, 'Bob', 'Smith', 45, 'M', 'B-', '1978-07-24', 2, '123-456-7891', '124 Main St'),
-       (3, 'Carol', 'Davis', 28, 'F', 'O+', '1995-11-05', 1, '123-456-7892', '125 Second St'),
-       (4, 'Kenny', 'Kim', 35, 'F', 'O+', '1995-11-05', 1, '123-456-7882', '126 Second St');`).Error; err != nil {
-		return err
-	}
+// Migrate applies every pending migration in version order. Each migration
+// runs in its own transaction and is recorded in SCHEMA_MIGRATIONS.
+func (d *GormDatabase) Migrate() error {
+	return d.withMigrationLock(func(conn *gorm.DB) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range d.migrations() {
+			if applied[m.Version] {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Up).Error; err != nil {
+					return err
+				}
+				return tx.Exec(`INSERT INTO SCHEMA_MIGRATIONS (VERSION, NAME) VALUES (?, ?)`, m.Version, m.Name).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+		}
+		return nil
+	})
+}
 
-	// insert some vital signs
-	if err := d.DB.Exec(`
-	INSERT INTO VITAL_SIGN (PATIENT_ID, ISSUE_TIME, BODY_TEMPERATURE, PULSE_RATE, RESPIRATION_RATE, SYSTOLIC_PRESSURE, DIASTOLIC_PRESSURE)
-	VALUES (1, '2023-05-01 10:30:00', 98.6, 70, 18, 120, 80),
-       (2, '2023-05-02 09:45:00', 99.2, 68, 16, 130, 85),
-       (3, '2023-05-03 15:15:00', 98.8, 72, 20, 125, 82),
-	   (4, '2023-03-02 11:15:00', 98.8, 72, 20, 125, 82);`).Error; err != nil {
-		return err
-	}
+// Rollback reverts the last steps applied migrations, newest first.
+func (d *GormDatabase) Rollback(steps int) error {
+	return d.withMigrationLock(func(conn *gorm.DB) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		migrations := d.migrations()
+		for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+			m := migrations[i]
+			if !applied[m.Version] {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(m.Down).Error; err != nil {
+					return err
+				}
+				return tx.Exec(`DELETE FROM SCHEMA_MIGRATIONS WHERE VERSION = ?`, m.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("rollback %d (%s): %w", m.Version, m.Name, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
 
-	// insert some medications
-	if err := d.DB.Exec(`
-	INSERT INTO PATIENT_MEDICATIONS (PATIENT_ID, PRESCRIBED_MEDICATIONS)
-	VALUES (1, 'Aspirin'),
-       (1, 'Antibiotic'),
-       (2, 'Painkiller'),
-       (3, 'Antihistamine'),
-       (4, 'Antihistamine');`).Error; err != nil {
-		return err
+// PendingMigrations returns the migrations that have not been applied yet,
+// without changing the database.
+func (d *GormDatabase) PendingMigrations() ([]Migration, error) {
+	applied := map[int]bool{}
+	if d.DB.Migrator().HasTable("schema_migrations") {
+		var err error
+		if applied, err = appliedVersions(d.DB); err != nil {
+			return nil, err
+		}
 	}
-
-	// insert some diseases
-	if err := d.DB.Exec(`
-	INSERT INTO PATIENT_DISEASE (PATIENT_ID, DISEASE)
-	VALUES (1, 'Hypertension'),
-       (2, 'Diabetes'),
-       (3, 'Asthma'),
-	   (4, 'Fever');`).Error; err != nil {
-		return err
+	var pending []Migration
+	for _, m := range d.migrations() {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
 	}
+	return pending, nil
+}
 
-	// insert some nurses
-	if err := d.DB.Exec(`
-	INSERT INTO NURSE (NURSE_ID, FIRST_NAME, LAST_NAME)
-	VALUES (1, 'Emily', 'Wilson'),
-       (2, 'David', 'Brown'),
-       (3, 'Sophia', 'Anderson');`).Error; err != nil {
-		return err
-	}
+// SchemaVersion returns the newest migration version this binary knows for
+// the database's dialect.
+func (d *GormDatabase) SchemaVersion() int {
+	migrations := d.migrations()
+	return migrations[len(migrations)-1].Version
+}
 
-	// insert some patient-nurse relationships
-	if err := d.DB.Exec(`
-	INSERT INTO PATIENT_NURSE (PATIENT_ID, NURSE_ID)
-	VALUES (1, 1),
-       (2, 2),
-       (3, 3),
-	   (4, 1);`).Error; err != nil {
-		return err
+// migrations returns the migrations of the database's dialect.
+func (d *GormDatabase) migrations() []Migration {
+	if d.Dialect() == SQLite {
+		return sqliteMigrations
 	}
+	return migrations
+}
 
-	// generate some views
-	if err := d.DB.Exec(`
-	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
-    SELECT DISTINCT
-        p.patient_id AS ID,
-        p.first_name,
-        p.last_name,
-        p.age,
-        p.sex,
-        p.blood_type,
-        p.dob AS DOB,
-        p.doctor_id AS assigned_doctor_ID,
-		doc.first_name AS assigned_doctor_first_name,
-		doc.last_name AS assigned_doctor_last_name,
-        v.body_temperature,
-        v.pulse_rate,
-        v.respiration_rate,
-        v.systolic_pressure,
-        v.diastolic_pressure,
-        m.prescribed_medications AS current_prescribed_med,
-        d.disease AS current_disease
-		FROM PATIENT AS p
-		JOIN vital_sign AS v ON p.patient_id = v.patient_id
-		JOIN patient_medications AS m ON p.patient_id = m.patient_id
-		JOIN patient_disease AS d ON p.patient_id = d.patient_id
-		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);`).Error; err != nil {
-		return err
-	}
+// withMigrationLock pins a single connection, takes the advisory lock on it
+// and makes sure SCHEMA_MIGRATIONS exists before running fc. SQLite databases
+// have a single connection and need no lock.
+func (d *GormDatabase) withMigrationLock(fc func(conn *gorm.DB) error) error {
+	return d.DB.Connection(func(conn *gorm.DB) error {
+		if d.Dialect() == Postgres {
+			if err := conn.Exec(`SELECT pg_advisory_lock(?)`, migrationLockID).Error; err != nil {
+				return err
+			}
+			defer conn.Exec(`SELECT pg_advisory_unlock(?)`, migrationLockID)
+		}
 
-	if err := d.DB.Exec(`
-	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
-		SELECT DISTINCT
-		n.nurse_id,
-		n.first_name AS nurse_first_name,
-		n.last_name AS nurse_last_name,
-		p.patient_id,
-		p.first_name AS patient_first_name,
-		p.last_name AS patient_last_name,
-		p.age,
-		p.sex,
-		p.blood_type,
-		p.phone_number,
-		p.address,
-		p.dob AS DOB,
-		p.doctor_id AS assigned_doctor_ID,
-		doc.first_name AS assigned_doctor_first_name,
-		doc.last_name AS assigned_doctor_last_name,
-		v.body_temperature,
-		v.pulse_rate,
-		v.respiration_rate,
-		v.systolic_pressure,
-		v.diastolic_pressure,
-		m.prescribed_medications AS current_prescribed_med,
-		d.disease AS current_disease
-		FROM nurse AS n
-		JOIN patient_nurse AS PN ON n.nurse_id = pn.nurse_id
-		JOIN patient AS p ON pn.patient_id = p.patient_id
-		JOIN vital_sign AS v ON p.patient_id = v.patient_id
-		JOIN patient_medications AS m ON p.patient_id = m.patient_id
-		JOIN patient_disease AS d ON p.patient_id = d.patient_id
-		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);`).Error; err != nil {
-		return err
-	}
+		// SQLite keeps the case of table names, which PendingMigrations looks
+		// up in lower case
+		if err := conn.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+		VERSION INT,
+		NAME VARCHAR(100) NOT NULL,
+		APPLIED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (VERSION));`).Error; err != nil {
+			return err
+		}
+		// only postgres databases predate the migrations
+		if d.Dialect() == Postgres {
+			if err := adoptLegacySchema(conn, migrations[0]); err != nil {
+				return err
+			}
+		}
+		return fc(conn)
+	})
+}
 
-	if err := d.DB.Exec(`
-	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
-		SELECT DISTINCT
-		p.patient_id,
-		p.first_name,
-		p.last_name,
-		p.age,
-		p.sex,
-		p.blood_type,
-		p.phone_number,
-		p.address,
-		p.dob AS DOB,
-		p.doctor_id AS assigned_doctor_ID,
-		doc.first_name AS assigned_doctor_first_name,
-		doc.last_name AS assigned_doctor_last_name,
-		v.body_temperature,
-		v.pulse_rate,
-		v.respiration_rate,
-		v.systolic_pressure,
-		v.diastolic_pressure,
-		m.prescribed_medications AS current_prescribed_med,
-		d.disease AS current_disease
-		FROM nurse AS n
-		JOIN patient_nurse AS PN ON n.nurse_id = pn.nurse_id
-		JOIN patient AS p ON pn.patient_id = p.patient_id
-		JOIN vital_sign AS v ON p.patient_id = v.patient_id
-		JOIN patient_medications AS m ON p.patient_id = m.patient_id
-		JOIN patient_disease AS d ON p.patient_id = d.patient_id
-		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);`).Error; err != nil {
+// adoptLegacySchema marks the initial migration as applied on databases
+// created by the old one-shot AutoMigrate, which never recorded a version.
+func adoptLegacySchema(conn *gorm.DB, initial Migration) error {
+	var count int64
+	if err := conn.Raw(`SELECT COUNT(*) FROM SCHEMA_MIGRATIONS`).Scan(&count).Error; err != nil {
 		return err
 	}
+	if count > 0 || !conn.Migrator().HasTable("doctor") {
+		return nil
+	}
+	return conn.Exec(`INSERT INTO SCHEMA_MIGRATIONS (VERSION, NAME) VALUES (?, ?)`, initial.Version, initial.Name).Error
+}
 
-	return nil
+func appliedVersions(conn *gorm.DB) (map[int]bool, error) {
+	var versions []int
+	if err := conn.Raw(`SELECT VERSION FROM SCHEMA_MIGRATIONS`).Scan(&versions).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+	return applied, nil
 }
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// shippedMigrations are the SHA-256 sums of the Up and Down SQL of every
+// released migration. A mismatch means a shipped migration was edited; add a
+// new version instead and append its sum here.
+var shippedMigrations = map[int]string{
+	1:  "f094692bef708631bde03e568762149b6ad8b7f9f3099eadc95647ba2cad5d59",
+	2:  "ad91cb66adea48d3ab519addfa83d9f9cd77f56eeb08d0932f16b932b1b19dce",
+	3:  "2d2bea91f7e944d0dd44c0d651b11680ef00d4256c3bcffde9ab4c7a1ddf19d2",
+	4:  "6108df194861824c9b829e87d8b091d9921b76ed85d177feccdba8d99a74c200",
+	5:  "dc36cfb5e2acde1da07b9d1b3a155ee58108a0c6e3063e788a808e32e1eee887",
+	6:  "e593f1153b9421808a75c2ff8b7b48538fc526ac1d8eaf6e1d2a2d3c987ff217",
+	7:  "5b29355a267dcb2dfc3f591c64526e2f07f9c4ed5e14fce83735b76ffd3f2abd",
+	8:  "6dbe372e514d8c4f2f9ffebc796957ca5f450f54962519a49bf8bc5db84a8eac",
+	9:  "5c1bfd2c21aa7db217e9dc8f28a74e0f019f66ff7c49863a21b6b71eb81455c1",
+	10: "4b8c4dd256cc025836c5703bb230ea6c4d0251c09d6f1186bd189c12a84e23f0",
+	11: "194ede3a95e156b9ab89f80c4f322d59b8a00e62f367bc9b0610caa7eff6bb99",
+	12: "c2935c8f4fae639407c742a6c1f42f3991d408ce409dfffb67570328f67f92e0",
+	13: "531c1bafb436f528dc1ad1d5b13fcfceea9e4f7cec1545e7e43c2bd7825dce0c",
+	14: "819c2150a9b35a882b2ae209c1ca4be1f1f58c9b143908568360a788e087e1e7",
+	15: "fdace303f3fa67d4d658ecfc5807d5d1d0c07705eb2d3670ee2c0530942907da",
+	16: "d4af46c519049b0776e40f122e2c373c34bc5a8713e0af46225d14b269ef67d9",
+	17: "ffa43fdfbbb00141bd2a240a0bf3c249f9e3376fc3e82933898cc8ced5b1b071",
+	18: "015363585bebe7e875b402ffdf614e5cea9712b7a6575f190a641948fe3cf769",
+	19: "9b008730e2083979c20508d998c2c6a6c44a6a279e07613ec1ccf67fb86ea20b",
+	20: "9415e20266ec2ec68b7690fb9cf8ceb21bc0ee72243b9e39b8f6f09f4af45f9f",
+}
+
+// shippedSQLiteMigrations are the sums of sqliteMigrations, like
+// shippedMigrations.
+var shippedSQLiteMigrations = map[int]string{
+	16: "b1f2cb26e8098d905722de1c0d10a7bbbfeaf38112446364c7eae780e49f8dd0",
+	17: "51399a5cee68c3d2e18f66271ca56d8630e9ef920840547a204140f4c9f94528",
+	18: "5b7f67d96168650d02493c1e96107e199879e35d2f703279966010750ce59da9",
+	19: "51399a5cee68c3d2e18f66271ca56d8630e9ef920840547a204140f4c9f94528",
+	20: "603b671de255fea6d9893ff182c58942eb8b71b7cecf028e48bc2cb73b19e2f6",
+}
+
+func Test_ShippedMigrationsAreFrozen(t *testing.T) {
+	for dialect, shipped := range map[Dialect]struct {
+		migrations []Migration
+		sums       map[int]string
+	}{
+		Postgres: {migrations, shippedMigrations},
+		SQLite:   {sqliteMigrations, shippedSQLiteMigrations},
+	} {
+		for _, m := range shipped.migrations {
+			sum, ok := shipped.sums[m.Version]
+			if assert.True(t, ok, "%s version %d has no recorded sum", dialect, m.Version) {
+				assert.Equal(t, sum, fmt.Sprintf("%x", sha256.Sum256([]byte(m.Up+m.Down))), "%s version %d (%s) was edited", dialect, m.Version, m.Name)
+			}
+		}
+	}
+}
+
+func Test_MigrationsAreOrdered(t *testing.T) {
+	for _, list := range [][]Migration{migrations, sqliteMigrations} {
+		for i := 1; i < len(list); i++ {
+			assert.Less(t, list[i-1].Version, list[i].Version, "%s follows %s", list[i].Name, list[i-1].Name)
+		}
+	}
+}
+
+// emptySQLite returns a fresh in-memory SQLite database without a schema.
+func emptySQLite(t *testing.T) *GormDatabase {
+	t.Helper()
+	db, err := NewGormDatabase(sqliteScheme+":memory:", false)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// recordedMigrations returns the versions in SCHEMA_MIGRATIONS in order.
+func recordedMigrations(t *testing.T, db *GormDatabase) []Migration {
+	t.Helper()
+	var recorded []Migration
+	require.NoError(t, db.DB.Raw(`SELECT VERSION AS version, NAME AS name FROM SCHEMA_MIGRATIONS ORDER BY VERSION`).Scan(&recorded).Error)
+	return recorded
+}
+
+func versionsOf(list []Migration) []int {
+	versions := make([]int, 0, len(list))
+	for _, m := range list {
+		versions = append(versions, m.Version)
+	}
+	return versions
+}
+
+func Test_MigrateRecordsVersions(t *testing.T) {
+	db := emptySQLite(t)
+	pending, err := db.PendingMigrations()
+	require.NoError(t, err)
+	assert.Equal(t, versionsOf(sqliteMigrations), versionsOf(pending))
+
+	require.NoError(t, db.Migrate())
+	recorded := recordedMigrations(t, db)
+	if assert.Len(t, recorded, len(sqliteMigrations)) {
+		for i, m := range sqliteMigrations {
+			assert.Equal(t, m.Version, recorded[i].Version)
+			assert.Equal(t, m.Name, recorded[i].Name)
+		}
+	}
+}
+
+func Test_RollbackRunsDownNewestFirst(t *testing.T) {
+	db := emptySQLite(t)
+	require.NoError(t, db.Migrate())
+	migrator := db.DB.Migrator()
+	require.True(t, migrator.HasColumn("vital_sign", "news2_rank"))
+	require.False(t, migrator.HasColumn("patient", "age"))
+
+	n := len(sqliteMigrations)
+	require.NoError(t, db.Rollback(1))
+	assert.False(t, migrator.HasColumn("vital_sign", "news2_rank"), "version 20 rolled back")
+	assert.False(t, migrator.HasColumn("patient", "age"), "version 18 still applied")
+	assert.Equal(t, versionsOf(sqliteMigrations[:n-1]), versionsOf(recordedMigrations(t, db)))
+
+	require.NoError(t, db.Rollback(2))
+	assert.True(t, migrator.HasColumn("patient", "age"), "version 18 rolled back")
+	assert.Equal(t, versionsOf(sqliteMigrations[:n-3]), versionsOf(recordedMigrations(t, db)))
+	pending, err := db.PendingMigrations()
+	require.NoError(t, err)
+	assert.Equal(t, versionsOf(sqliteMigrations[n-3:]), versionsOf(pending))
+
+	require.NoError(t, db.Migrate())
+	assert.True(t, migrator.HasColumn("vital_sign", "news2_rank"))
+	assert.False(t, migrator.HasColumn("patient", "age"))
+	assert.Equal(t, versionsOf(sqliteMigrations), versionsOf(recordedMigrations(t, db)))
+
+	// rolling back more than was applied stops at the baseline
+	require.NoError(t, db.Rollback(n+1))
+	assert.Empty(t, recordedMigrations(t, db))
+	assert.False(t, migrator.HasTable("patient"))
+}
+
+func Test_AdoptLegacySchema(t *testing.T) {
+	initial := Migration{Version: 1, Name: "initial"}
+	db := emptySQLite(t)
+	require.NoError(t, db.withMigrationLock(func(conn *gorm.DB) error { return nil }))
+
+	// an empty database is left to the migrations
+	require.NoError(t, adoptLegacySchema(db.DB, initial))
+	assert.Empty(t, recordedMigrations(t, db))
+
+	// tables without versions come from the old AutoMigrate
+	require.NoError(t, db.DB.Exec(`CREATE TABLE doctor (id INTEGER PRIMARY KEY)`).Error)
+	require.NoError(t, adoptLegacySchema(db.DB, initial))
+	assert.Equal(t, []Migration{{Version: 1, Name: "initial"}}, recordedMigrations(t, db))
+
+	// once a version is recorded the database is not adopted again
+	require.NoError(t, adoptLegacySchema(db.DB, Migration{Version: 2, Name: "other"}))
+	assert.Equal(t, []int{1}, versionsOf(recordedMigrations(t, db)))
+}
+
+func Test_SQLiteIsNeverAdopted(t *testing.T) {
+	db := emptySQLite(t)
+	require.NoError(t, db.DB.Exec(`CREATE TABLE doctor (id INTEGER PRIMARY KEY)`).Error)
+	pending, err := db.PendingMigrations()
+	require.NoError(t, err)
+	assert.Len(t, pending, len(sqliteMigrations))
+	require.NoError(t, db.withMigrationLock(func(conn *gorm.DB) error { return nil }))
+	assert.Empty(t, recordedMigrations(t, db))
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+
+	AlertWarning  = "warning"
+	AlertCritical = "critical"
+
+	// AlertAbove and AlertBelow compare the reading with Threshold,
+	// AlertRising and AlertFalling look for a strict trend over the last
+	// Readings readings.
+	AlertAbove   = "above"
+	AlertBelow   = "below"
+	AlertRising  = "rising"
+	AlertFalling = "falling"
+)
+
+// AlertRule is a row of ALERT_RULE. Rules without a PatientID apply to every
+// patient; a patient rule replaces the global rules of the same parameter and
+// condition for that patient.
+type AlertRule struct {
+	RuleID    int
+	PatientID *int
+	Parameter string
+	Condition string
+	Threshold *float64
+	Readings  *int
+	Severity  string
+	Active    bool
+	CreatedBy *int
+	CreatedAt time.Time
+}
+
+// Alert is a row of ALERT, raised when a reading matched a rule. Alerts go
+// from open to acknowledged to resolved; acknowledging may be skipped.
+type Alert struct {
+	AlertID        int
+	RuleID         int
+	PatientID      int
+	IssueTime      time.Time
+	Parameter      string
+	Value          float64
+	Severity       string
+	Message        string
+	Status         string
+	CreatedAt      time.Time
+	AcknowledgedAt *time.Time
+	AcknowledgedBy *int
+	ResolvedAt     *time.Time
+	ResolvedBy     *int
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// Assignment is a row of ASSIGNMENT_HISTORY joined with the staff member's
+// name. EffectiveTo is nil while the assignment is current.
+type Assignment struct {
+	AssignmentID   int
+	PatientID      int
+	StaffKind      string
+	StaffID        int
+	StaffFirstName string
+	StaffLastName  string
+	EffectiveFrom  time.Time
+	EffectiveTo    *time.Time
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// AuditGenesisHash is the previous hash of the first audit entry.
+var AuditGenesisHash = strings.Repeat("0", sha256.Size*2)
+
+// AuditEntry is a row of AUDIT_LOG: one request that read or wrote patient
+// data. PatientIDs are stored in AUDIT_LOG_PATIENT.
+//
+// Entries form a hash chain: Hash covers every field of the entry including
+// PrevHash, the Hash of the entry before it, so that editing, removing or
+// reordering entries breaks the chain from that point on.
+type AuditEntry struct {
+	EntryID    int64
+	OccurredAt time.Time
+	UserID     int
+	Username   string
+	Role       string
+	Action     string
+	Status     int
+	ClientIP   string
+	RequestID  string
+	PatientIDs []int `gorm:"-"`
+	PrevHash   string
+	Hash       string
+}
+
+// Digest returns the hash of the entry as it should be stored in Hash.
+// OccurredAt is hashed in UTC at the microsecond precision of the database,
+// so the digest is the same before and after a round trip. PatientIDs must
+// be sorted.
+func (e AuditEntry) Digest() string {
+	patientIDs := e.PatientIDs
+	if len(patientIDs) == 0 {
+		patientIDs = nil
+	}
+	canonical, _ := json.Marshal(struct {
+		EntryID    int64  `json:"entry_id"`
+		OccurredAt string `json:"occurred_at"`
+		UserID     int    `json:"user_id"`
+		Username   string `json:"username"`
+		Role       string `json:"role"`
+		Action     string `json:"action"`
+		Status     int    `json:"status"`
+		ClientIP   string `json:"client_ip"`
+		RequestID  string `json:"request_id"`
+		PatientIDs []int  `json:"patient_ids"`
+		PrevHash   string `json:"prev_hash"`
+	}{
+		EntryID:    e.EntryID,
+		OccurredAt: e.OccurredAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		UserID:     e.UserID,
+		Username:   e.Username,
+		Role:       e.Role,
+		Action:     e.Action,
+		Status:     e.Status,
+		ClientIP:   e.ClientIP,
+		RequestID:  e.RequestID,
+		PatientIDs: patientIDs,
+		PrevHash:   e.PrevHash,
+	})
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Diagnosis is a row of PATIENT_DIAGNOSIS. ICD10Code is nil for diagnoses
+// migrated from the old free-text disease table.
+type Diagnosis struct {
+	DiagnosisID  int
+	PatientID    int
+	ICD10Code    *string
+	Description  string
+	OnsetDate    *Date
+	ResolvedDate *Date
+	IsPrimary    bool
+	CreatedAt    time.Time
+}
+
+// DiagnosisCount is the number of patients with an unresolved diagnosis of
+// the code.
+type DiagnosisCount struct {
+	ICD10Code   string
+	Description string
+	Patients    int
+}
+
+// DashboardDiagnosis is an unresolved diagnosis as aggregated into the
+// dashboard views.
+type DashboardDiagnosis struct {
+	DiagnosisID  int     `json:"diagnosis_id"`
+	ICD10Code    *string `json:"icd10_code"`
+	Description  string  `json:"description"`
+	OnsetDate    *Date   `json:"onset_date"`
+	ResolvedDate *Date   `json:"resolved_date"`
+	IsPrimary    bool    `json:"is_primary"`
+}
+
+// DashboardDiagnoses is the JSON array of DashboardDiagnosis built by the
+// dashboard views.
+type DashboardDiagnoses []DashboardDiagnosis
+
+func (l *DashboardDiagnoses) Scan(src interface{}) error {
+	return scanJSON(src, l)
+}
+
+func (l DashboardDiagnoses) Value() (driver.Value, error) {
+	return valueJSON(l)
+}
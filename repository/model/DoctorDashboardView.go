@@ -4,6 +4,8 @@ import (
 	"time"
 )
 
+// DoctorDashboardView is a row of DOCTOR_DASHBOARD_VIEW, one per admitted
+// patient. The vital sign fields are nil when the patient has no readings yet.
 type DoctorDashboardView struct {
 	PatientID               int
 	FirstName               string
@@ -17,11 +19,17 @@ type DoctorDashboardView struct {
 	AssignedDoctorID        int
 	AssignedDoctorFirstName string
 	AssignedDoctorLastName  string
-	BodyTemperature         float64
-	PulseRate               int
-	RespirationRate         int
-	SystolicPressure        int
-	DiastolicPressure       int
-	CurrentPrescribedMed    string
-	CurrentDisease          string
+	BodyTemperature         *float64
+	PulseRate               *int
+	RespirationRate         *int
+	SystolicPressure        *int
+	DiastolicPressure       *int
+	OxygenSaturation        *int
+	SupplementalOxygen      *bool
+	Consciousness           *string
+	NEWS2Score              *int
+	NEWS2Rank               *int
+	VitalsRecordedAt        *time.Time
+	CurrentPrescribedMeds   DashboardMedications
+	CurrentDiseases         DashboardDiagnoses
 }
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// EmergencyGrant is a row of EMERGENCY_ACCESS: a break-the-glass grant that
+// puts a patient into a user's scope until it expires or is revoked.
+type EmergencyGrant struct {
+	GrantID   int
+	UserID    int
+	Username  string
+	PatientID int
+	Reason    string
+	ClientIP  string
+	GrantedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// Active reports whether the grant is in effect at now.
+func (g EmergencyGrant) Active(now time.Time) bool {
+	return g.RevokedAt == nil && now.Before(g.ExpiresAt)
+}
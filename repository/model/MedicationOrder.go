@@ -0,0 +1,57 @@
+package model
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+const (
+	MedicationActive       = "active"
+	MedicationHeld         = "held"
+	MedicationDiscontinued = "discontinued"
+)
+
+// MedicationOrder is a row of MEDICATION_ORDER. Dose, unit, route and
+// frequency are nil for orders migrated from the old name-only table.
+type MedicationOrder struct {
+	OrderID             int
+	PatientID           int
+	Name                string
+	Dose                *float64
+	Unit                *string
+	Route               *string
+	Frequency           *string
+	StartDate           Date
+	StopDate            *Date
+	PrescribingDoctorID *int
+	Status              string
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// DashboardMedication is a current medication order as aggregated into the
+// dashboard views.
+type DashboardMedication struct {
+	OrderID             int      `json:"order_id"`
+	Name                string   `json:"name"`
+	Dose                *float64 `json:"dose"`
+	Unit                *string  `json:"unit"`
+	Route               *string  `json:"route"`
+	Frequency           *string  `json:"frequency"`
+	StartDate           Date     `json:"start_date"`
+	StopDate            *Date    `json:"stop_date"`
+	PrescribingDoctorID *int     `json:"prescribing_doctor_id"`
+	Status              string   `json:"status"`
+}
+
+// DashboardMedications is the JSON array of DashboardMedication built by the
+// dashboard views.
+type DashboardMedications []DashboardMedication
+
+func (l *DashboardMedications) Scan(src interface{}) error {
+	return scanJSON(src, l)
+}
+
+func (l DashboardMedications) Value() (driver.Value, error) {
+	return valueJSON(l)
+}
@@ -4,6 +4,9 @@ import (
 	"time"
 )
 
+// NurseDashboardView is a row of NURSE_DASHBOARD_VIEW, one per nurse and
+// assigned patient. The vital sign fields are nil when the patient has no
+// readings yet.
 type NurseDashboardView struct {
 	NurseID                 int
 	NurseFirstName          string
@@ -20,11 +23,17 @@ type NurseDashboardView struct {
 	AssignedDoctorID        int
 	AssignedDoctorFirstName string
 	AssignedDoctorLastName  string
-	BodyTemperature         float64
-	PulseRate               int
-	RespirationRate         int
-	SystolicPressure        int
-	DiastolicPressure       int
-	CurrentPrescribedMed    string
-	CurrentDisease          string
+	BodyTemperature         *float64
+	PulseRate               *int
+	RespirationRate         *int
+	SystolicPressure        *int
+	DiastolicPressure       *int
+	OxygenSaturation        *int
+	SupplementalOxygen      *bool
+	Consciousness           *string
+	NEWS2Score              *int
+	NEWS2Rank               *int
+	VitalsRecordedAt        *time.Time
+	CurrentPrescribedMeds   DashboardMedications
+	CurrentDiseases         DashboardDiagnoses
 }
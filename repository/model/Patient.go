@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+)
+
+type Patient struct {
+	PatientID    int
+	FirstName    string
+	LastName     string
+	Sex          string
+	BloodType    string
+	DOB          time.Time
+	DoctorID     int
+	PhoneNumber  string
+	Address      string
+	DischargedAt *time.Time
+}
+
+// AgeAt returns the age of the patient in whole years at time now.
+func (p Patient) AgeAt(now time.Time) int {
+	age := now.Year() - p.DOB.Year()
+	if now.Month() < p.DOB.Month() || (now.Month() == p.DOB.Month() && now.Day() < p.DOB.Day()) {
+		age--
+	}
+	return age
+}
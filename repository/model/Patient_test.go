@@ -0,0 +1,14 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PatientAgeAt(t *testing.T) {
+	p := Patient{DOB: time.Date(2000, 6, 15, 0, 0, 0, 0, time.UTC)}
+	assert.Equal(t, 22, p.AgeAt(time.Date(2023, 6, 14, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 23, p.AgeAt(time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)))
+}
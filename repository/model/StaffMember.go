@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// StaffMember is a row of the DOCTOR or NURSE table.
+type StaffMember struct {
+	ID            int
+	FirstName     string
+	LastName      string
+	Active        bool
+	DeactivatedAt *time.Time
+}
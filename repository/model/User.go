@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	RoleAdmin   = "admin"
+	RoleDoctor  = "doctor"
+	RoleNurse   = "nurse"
+	RolePatient = "patient"
+)
+
+// User is a row of APP_USER. Doctor, nurse and patient users are linked to
+// the DOCTOR, NURSE or PATIENT row they log in as.
+type User struct {
+	UserID       int
+	Username     string
+	PasswordHash string
+	Role         string
+	DoctorID     *int
+	NurseID      *int
+	PatientID    *int
+	Active       bool
+	CreatedAt    time.Time
+}
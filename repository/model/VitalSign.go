@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"health-care-backend/news2"
+)
+
+// VitalSign is a row of VITAL_SIGN. OxygenSaturation, SupplementalOxygen and
+// Consciousness were added for NEWS2 and are nil on older readings.
+// Consciousness is a level of the ACVPU scale. NEWS2Score is the total
+// NEWS2 score of the reading and NEWS2Rank its news2.Score.Rank, stored so
+// that dashboards can be sorted by risk.
+type VitalSign struct {
+	PatientID          int
+	IssueTime          time.Time
+	BodyTemperature    float64
+	PulseRate          int
+	RespirationRate    int
+	SystolicPressure   int
+	DiastolicPressure  int
+	OxygenSaturation   *int
+	SupplementalOxygen *bool
+	Consciousness      *string
+	NEWS2Score         int
+	NEWS2Rank          int
+}
+
+// NEWS2Inputs are the parameters of the reading that NEWS2 scores.
+func (v VitalSign) NEWS2Inputs() news2.Inputs {
+	return news2.Inputs{
+		RespirationRate:    &v.RespirationRate,
+		OxygenSaturation:   v.OxygenSaturation,
+		SupplementalOxygen: v.SupplementalOxygen,
+		SystolicPressure:   &v.SystolicPressure,
+		PulseRate:          &v.PulseRate,
+		Consciousness:      v.Consciousness,
+		TemperatureF:       &v.BodyTemperature,
+	}
+}
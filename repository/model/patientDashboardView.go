@@ -4,6 +4,8 @@ import (
 	"time"
 )
 
+// PatientDashboardView is a row of PATIENT_DASHBOARD_VIEW. The vital sign
+// fields are nil when the patient has no readings yet.
 type PatientDashboardView struct {
 	ID                      int
 	FirstName               string
@@ -15,11 +17,17 @@ type PatientDashboardView struct {
 	AssignedDoctorID        int
 	AssignedDoctorFirstName string
 	AssignedDoctorLastName  string
-	BodyTemperature         float64
-	PulseRate               int
-	RespirationRate         int
-	SystolicPressure        int
-	DiastolicPressure       int
-	CurrentPrescribedMed    string
-	CurrentDisease          string
+	BodyTemperature         *float64
+	PulseRate               *int
+	RespirationRate         *int
+	SystolicPressure        *int
+	DiastolicPressure       *int
+	OxygenSaturation        *int
+	SupplementalOxygen      *bool
+	Consciousness           *string
+	NEWS2Score              *int
+	NEWS2Rank               *int
+	VitalsRecordedAt        *time.Time
+	CurrentPrescribedMeds   DashboardMedications
+	CurrentDiseases         DashboardDiagnoses
 }
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"health-care-backend/events"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// patientChangesChannel is the NOTIFY channel of the NOTIFY_PATIENT_CHANGE
+// trigger.
+const patientChangesChannel = "patient_changes"
+
+const (
+	minListenBackoff = time.Second
+	maxListenBackoff = 30 * time.Second
+)
+
+// patientChange is the payload of a patient_changes notification.
+type patientChange struct {
+	PatientID int    `json:"patient_id"`
+	Table     string `json:"table"`
+}
+
+// ListenPatientChanges calls publish with the id of every patient whose
+// data changed, until ctx is done. It holds a connection of its own, since
+// LISTEN does not mix with a pool. Lost connections are reopened with
+// backoff; publish is then called with events.AllPatients because changes
+// may have been missed in between.
+func ListenPatientChanges(ctx context.Context, dsn string, logger *zap.Logger, publish func(pid int)) {
+	backoff := minListenBackoff
+	for first := true; ; first = false {
+		err := listen(ctx, dsn, logger, func() {
+			backoff = minListenBackoff
+			if !first {
+				publish(events.AllPatients)
+			}
+		}, publish)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("listening for patient changes failed, retrying",
+			zap.Error(err), zap.Duration("backoff", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxListenBackoff {
+			backoff = maxListenBackoff
+		}
+	}
+}
+
+// listen runs a single connection. listening is called once LISTEN succeeded.
+func listen(ctx context.Context, dsn string, logger *zap.Logger, listening func(), publish func(pid int)) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+patientChangesChannel); err != nil {
+		return err
+	}
+	listening()
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change patientChange
+		if err := json.Unmarshal([]byte(n.Payload), &change); err != nil || change.PatientID == 0 {
+			logger.Warn("ignoring malformed patient change notification")
+			continue
+		}
+		publish(change.PatientID)
+	}
+}
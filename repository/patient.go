@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"health-care-backend/policy"
+	model "health-care-backend/repository/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Patients interface {
+	ListPatients(ctx context.Context, p policy.Principal, includeDischarged bool) ([]model.Patient, error)
+	SelectPatient(ctx context.Context, pid int) (model.Patient, error)
+	InsertPatient(ctx context.Context, p model.Patient) (model.Patient, error)
+	UpdatePatient(ctx context.Context, p model.Patient) (model.Patient, error)
+	DischargePatient(ctx context.Context, pid int) (model.Patient, error)
+}
+
+type patientRepo struct {
+	db *GormDatabase
+}
+
+func NewPatientRepo(db *GormDatabase) Patients {
+	return &patientRepo{db: db}
+}
+
+// ListPatients returns the patients in the principal's scope.
+func (r *patientRepo) ListPatients(ctx context.Context, p policy.Principal, includeDischarged bool) ([]model.Patient, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Patient
+	scope, args := patientScope(p, "pt.PATIENT_ID")
+	if err := db.Raw(`
+	SELECT * FROM PATIENT AS pt
+	WHERE (? OR pt.DISCHARGED_AT IS NULL) AND `+scope+`
+	ORDER BY pt.PATIENT_ID`, append([]interface{}{includeDischarged}, args...)...).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *patientRepo) SelectPatient(ctx context.Context, pid int) (model.Patient, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Patient
+	if err := db.Raw(`SELECT * FROM PATIENT WHERE PATIENT_ID = ?`, pid).Scan(&records).Error; err != nil {
+		return model.Patient{}, err
+	}
+	if len(records) == 0 {
+		return model.Patient{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// InsertPatient creates the patient and opens the attending doctor's
+// assignment. The database assigns the PatientID.
+func (r *patientRepo) InsertPatient(ctx context.Context, p model.Patient) (model.Patient, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Patient
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := requireActiveStaff(tx, DoctorStaff, p.DoctorID); err != nil {
+			return err
+		}
+		err := tx.Raw(`
+		INSERT INTO PATIENT (FIRST_NAME, LAST_NAME, SEX, BLOOD_TYPE, DOB, DOCTOR_ID, PHONE_NUMBER, ADDRESS)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING *`,
+			p.FirstName, p.LastName, p.Sex, p.BloodType, p.DOB, p.DoctorID, p.PhoneNumber, p.Address).Scan(&records).Error
+		if err != nil {
+			return translateError(err)
+		}
+		_, err = openAssignment(tx, records[0].PatientID, DoctorStaff, p.DoctorID)
+		return err
+	})
+	if err != nil {
+		return model.Patient{}, err
+	}
+	return records[0], nil
+}
+
+// UpdatePatient overwrites the demographic fields of an admitted patient.
+// Discharged patients are read-only and yield ErrConflict. A changed
+// DoctorID is recorded in the assignment history like ReassignDoctor does.
+func (r *patientRepo) UpdatePatient(ctx context.Context, p model.Patient) (model.Patient, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Patient
+	err := db.Transaction(func(tx *gorm.DB) error {
+		current, err := lockAdmittedPatient(tx, p.PatientID)
+		if err != nil {
+			return err
+		}
+		if current.DoctorID != p.DoctorID {
+			if err := requireActiveStaff(tx, DoctorStaff, p.DoctorID); err != nil {
+				return err
+			}
+			if _, err := moveDoctorAssignment(tx, p.PatientID, p.DoctorID); err != nil {
+				return err
+			}
+		}
+		return tx.Raw(`
+		UPDATE PATIENT SET
+			FIRST_NAME = ?, LAST_NAME = ?, SEX = ?, BLOOD_TYPE = ?,
+			DOB = ?, DOCTOR_ID = ?, PHONE_NUMBER = ?, ADDRESS = ?
+		WHERE PATIENT_ID = ?
+		RETURNING *`,
+			p.FirstName, p.LastName, p.Sex, p.BloodType,
+			p.DOB, p.DoctorID, p.PhoneNumber, p.Address, p.PatientID).Scan(&records).Error
+	})
+	if err != nil {
+		return model.Patient{}, err
+	}
+	return records[0], nil
+}
+
+// DischargePatient stamps the discharge time and releases the patient's
+// nurses. Patient rows are never deleted so that their history stays intact.
+func (r *patientRepo) DischargePatient(ctx context.Context, pid int) (model.Patient, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.Patient
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockAdmittedPatient(tx, pid); err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM PATIENT_NURSE WHERE PATIENT_ID = ?`, pid).Error; err != nil {
+			return err
+		}
+		if _, err := closeAssignments(tx, pid, NurseStaff, 0); err != nil {
+			return err
+		}
+		return tx.Raw(`
+		UPDATE PATIENT SET DISCHARGED_AT = ?
+		WHERE PATIENT_ID = ?
+		RETURNING *`, time.Now().UTC(), pid).Scan(&records).Error
+	})
+	if err != nil {
+		return model.Patient{}, err
+	}
+	return records[0], nil
+}
@@ -0,0 +1,2056 @@
+package repository
+
+// migrations is the ordered list of schema changes applied by Migrate. Once a
+// migration has shipped its Up/Down SQL must not be edited; add a new version
+// instead.
+var migrations = []Migration{
+	{
+		// the demo rows inserted here exist on every database until version
+		// 17 removes them again, see there
+		Version: 1,
+		Name:    "initial_schema",
+		Up: `
+	CREATE TABLE DOCTOR (
+	DOCTOR_ID INT,
+	FIRST_NAME VARCHAR(50) NOT NULL,
+	LAST_NAME VARCHAR(50) NOT NULL,
+	PRIMARY KEY (DOCTOR_ID));
+
+	CREATE TABLE PATIENT (
+	PATIENT_ID INT,
+	FIRST_NAME VARCHAR(50) NOT NULL,
+	LAST_NAME VARCHAR(50) NOT NULL,
+	AGE INT NOT NULL,
+	SEX CHAR NOT NULL,
+	PHONE_NUMBER VARCHAR(50) NOT NULL,
+	ADDRESS VARCHAR(50) NOT NULL,
+	BLOOD_TYPE CHAR(2) NOT NULL,
+	DOB DATE NOT NULL,
+	DOCTOR_ID INT NOT NULL,
+	PRIMARY KEY (PATIENT_ID),
+	CONSTRAINT PATIENT_FK_DOCTOR_ID FOREIGN KEY (DOCTOR_ID) REFERENCES DOCTOR(DOCTOR_ID));
+
+	CREATE TABLE VITAL_SIGN (
+	PATIENT_ID INT,
+	ISSUE_TIME TIMESTAMP,
+	BODY_TEMPERATURE FLOAT NOT NULL,
+	PULSE_RATE INT NOT NULL,
+	RESPIRATION_RATE INT NOT NULL,
+	SYSTOLIC_PRESSURE INT NOT NULL,
+	DIASTOLIC_PRESSURE INT NOT NULL,
+	PRIMARY KEY(PATIENT_ID, ISSUE_TIME),
+	CONSTRAINT VITAL_SIGN_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));
+
+	CREATE TABLE PATIENT_MEDICATIONS (
+	PATIENT_ID INT,
+	PRESCRIBED_MEDICATIONS VARCHAR(50),
+	PRIMARY KEY(PATIENT_ID, PRESCRIBED_MEDICATIONS),
+	CONSTRAINT PATIENT_MEDICATIONS_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));
+
+	CREATE TABLE PATIENT_DISEASE (
+	PATIENT_ID INT,
+	DISEASE VARCHAR(50),
+	PRIMARY KEY(PATIENT_ID, DISEASE),
+	CONSTRAINT PATIENT_DISEASE_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));
+
+	CREATE TABLE NURSE (
+	NURSE_ID INT,
+	FIRST_NAME VARCHAR(50) NOT NULL,
+	LAST_NAME VARCHAR(50) NOT NULL,
+	PRIMARY KEY (NURSE_ID));
+
+	CREATE TABLE PATIENT_NURSE (
+	PATIENT_ID INT,
+	NURSE_ID INT,
+	PRIMARY KEY (PATIENT_ID, NURSE_ID),
+	CONSTRAINT PATIENT_NURSE_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
+	CONSTRAINT PATIENT_NURSE_FK_NURSE_ID FOREIGN KEY (NURSE_ID) REFERENCES NURSE(NURSE_ID));
+
+	-- insert some doctors
+	INSERT INTO DOCTOR (DOCTOR_ID, FIRST_NAME, LAST_NAME)
+		VALUES (1, 'John', 'Doe'),
+		(2, 'Jane', 'Smith'),
+		(3, 'Michael', 'Johnson');
+
+	-- insert some patients
+	INSERT INTO PATIENT (PATIENT_ID, FIRST_NAME, LAST_NAME, AGE, SEX, BLOOD_TYPE, DOB, DOCTOR_ID, PHONE_NUMBER, ADDRESS)
+	VALUES (1, 'Alice', 'Johnson', 35, 'F', 'A+', '1988-03-12', 1, '123-456-7890', '123 Main St'),
+		(2, 'Bob', 'Smith', 45, 'M', 'B-', '1978-07-24', 2, '123-456-7891', '124 Main St'),
+		(3, 'Carol', 'Davis', 28, 'F', 'O+', '1995-11-05', 1, '123-456-7892', '125 Second St'),
+		(4, 'Kenny', 'Kim', 35, 'F', 'O+', '1995-11-05', 1, '123-456-7882', '126 Second St');
+
+	-- insert some vital signs
+	INSERT INTO VITAL_SIGN (PATIENT_ID, ISSUE_TIME, BODY_TEMPERATURE, PULSE_RATE, RESPIRATION_RATE, SYSTOLIC_PRESSURE, DIASTOLIC_PRESSURE)
+	VALUES (1, '2023-05-01 10:30:00', 98.6, 70, 18, 120, 80),
+		(2, '2023-05-02 09:45:00', 99.2, 68, 16, 130, 85),
+		(3, '2023-05-03 15:15:00', 98.8, 72, 20, 125, 82),
+		(4, '2023-03-02 11:15:00', 98.8, 72, 20, 125, 82);
+
+	-- insert some medications
+	INSERT INTO PATIENT_MEDICATIONS (PATIENT_ID, PRESCRIBED_MEDICATIONS)
+	VALUES (1, 'Aspirin'),
+		(1, 'Antibiotic'),
+		(2, 'Painkiller'),
+		(3, 'Antihistamine'),
+		(4, 'Antihistamine');
+
+	-- insert some diseases
+	INSERT INTO PATIENT_DISEASE (PATIENT_ID, DISEASE)
+	VALUES (1, 'Hypertension'),
+		(2, 'Diabetes'),
+		(3, 'Asthma'),
+		(4, 'Fever');
+
+	-- insert some nurses
+	INSERT INTO NURSE (NURSE_ID, FIRST_NAME, LAST_NAME)
+	VALUES (1, 'Emily', 'Wilson'),
+		(2, 'David', 'Brown'),
+		(3, 'Sophia', 'Anderson');
+
+	-- insert some patient-nurse relationships
+	INSERT INTO PATIENT_NURSE (PATIENT_ID, NURSE_ID)
+	VALUES (1, 1),
+		(2, 2),
+		(3, 3),
+		(4, 1);
+
+	-- generate some views` + dashboardViewsV1,
+		Down: `
+	DROP VIEW IF EXISTS DOCTOR_DASHBOARD_VIEW;
+	DROP VIEW IF EXISTS NURSE_DASHBOARD_VIEW;
+	DROP VIEW IF EXISTS PATIENT_DASHBOARD_VIEW;
+	DROP TABLE IF EXISTS PATIENT_NURSE;
+	DROP TABLE IF EXISTS NURSE;
+	DROP TABLE IF EXISTS PATIENT_DISEASE;
+	DROP TABLE IF EXISTS PATIENT_MEDICATIONS;
+	DROP TABLE IF EXISTS VITAL_SIGN;
+	DROP TABLE IF EXISTS PATIENT;
+	DROP TABLE IF EXISTS DOCTOR;`,
+	},
+	{
+		// patients get server-assigned ids, room for "AB+"/"AB-" blood types
+		// and a discharge timestamp instead of being deleted
+		Version: 2,
+		Name:    "patient_crud",
+		Up: dropDashboardViews + `
+	CREATE SEQUENCE PATIENT_PATIENT_ID_SEQ OWNED BY PATIENT.PATIENT_ID;
+	SELECT setval('patient_patient_id_seq', COALESCE((SELECT MAX(PATIENT_ID) FROM PATIENT), 0) + 1, false);
+	ALTER TABLE PATIENT ALTER COLUMN PATIENT_ID SET DEFAULT nextval('patient_patient_id_seq');
+	ALTER TABLE PATIENT ALTER COLUMN BLOOD_TYPE TYPE VARCHAR(3);
+	ALTER TABLE PATIENT ADD COLUMN DISCHARGED_AT TIMESTAMP;
+` + dashboardViewsV1,
+		Down: dropDashboardViews + `
+	ALTER TABLE PATIENT DROP COLUMN DISCHARGED_AT;
+	ALTER TABLE PATIENT ALTER COLUMN BLOOD_TYPE TYPE CHAR(2);
+	ALTER TABLE PATIENT ALTER COLUMN PATIENT_ID DROP DEFAULT;
+	DROP SEQUENCE PATIENT_PATIENT_ID_SEQ;
+` + dashboardViewsV1,
+	},
+	{
+		// staff get server-assigned ids and are deactivated instead of being
+		// deleted so their history stays intact
+		Version: 3,
+		Name:    "staff_management",
+		Up: `
+	CREATE SEQUENCE DOCTOR_DOCTOR_ID_SEQ OWNED BY DOCTOR.DOCTOR_ID;
+	SELECT setval('doctor_doctor_id_seq', COALESCE((SELECT MAX(DOCTOR_ID) FROM DOCTOR), 0) + 1, false);
+	ALTER TABLE DOCTOR ALTER COLUMN DOCTOR_ID SET DEFAULT nextval('doctor_doctor_id_seq');
+	ALTER TABLE DOCTOR ADD COLUMN ACTIVE BOOLEAN NOT NULL DEFAULT TRUE;
+	ALTER TABLE DOCTOR ADD COLUMN DEACTIVATED_AT TIMESTAMP;
+
+	CREATE SEQUENCE NURSE_NURSE_ID_SEQ OWNED BY NURSE.NURSE_ID;
+	SELECT setval('nurse_nurse_id_seq', COALESCE((SELECT MAX(NURSE_ID) FROM NURSE), 0) + 1, false);
+	ALTER TABLE NURSE ALTER COLUMN NURSE_ID SET DEFAULT nextval('nurse_nurse_id_seq');
+	ALTER TABLE NURSE ADD COLUMN ACTIVE BOOLEAN NOT NULL DEFAULT TRUE;
+	ALTER TABLE NURSE ADD COLUMN DEACTIVATED_AT TIMESTAMP;`,
+		Down: `
+	ALTER TABLE NURSE DROP COLUMN DEACTIVATED_AT;
+	ALTER TABLE NURSE DROP COLUMN ACTIVE;
+	ALTER TABLE NURSE ALTER COLUMN NURSE_ID DROP DEFAULT;
+	DROP SEQUENCE NURSE_NURSE_ID_SEQ;
+
+	ALTER TABLE DOCTOR DROP COLUMN DEACTIVATED_AT;
+	ALTER TABLE DOCTOR DROP COLUMN ACTIVE;
+	ALTER TABLE DOCTOR ALTER COLUMN DOCTOR_ID DROP DEFAULT;
+	DROP SEQUENCE DOCTOR_DOCTOR_ID_SEQ;`,
+	},
+	{
+		// PATIENT.DOCTOR_ID and PATIENT_NURSE keep the current assignments;
+		// ASSIGNMENT_HISTORY keeps every assignment with its validity period
+		Version: 4,
+		Name:    "assignment_history",
+		Up: `
+	CREATE TABLE ASSIGNMENT_HISTORY (
+	ASSIGNMENT_ID SERIAL,
+	PATIENT_ID INT NOT NULL,
+	STAFF_KIND VARCHAR(10) NOT NULL,
+	STAFF_ID INT NOT NULL,
+	EFFECTIVE_FROM TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	EFFECTIVE_TO TIMESTAMP,
+	PRIMARY KEY (ASSIGNMENT_ID),
+	CONSTRAINT ASSIGNMENT_HISTORY_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));
+
+	CREATE INDEX ASSIGNMENT_HISTORY_PATIENT_ID_IDX ON ASSIGNMENT_HISTORY (PATIENT_ID, EFFECTIVE_FROM);
+
+	INSERT INTO ASSIGNMENT_HISTORY (PATIENT_ID, STAFF_KIND, STAFF_ID)
+	SELECT PATIENT_ID, 'doctor', DOCTOR_ID FROM PATIENT;
+
+	INSERT INTO ASSIGNMENT_HISTORY (PATIENT_ID, STAFF_KIND, STAFF_ID)
+	SELECT PATIENT_ID, 'nurse', NURSE_ID FROM PATIENT_NURSE;`,
+		Down: `
+	DROP TABLE ASSIGNMENT_HISTORY;`,
+	},
+	{
+		Version: 5,
+		Name:    "dashboard_latest_vitals",
+		Up:      dropDashboardViews + dashboardViewsV5,
+		Down:    dropDashboardViews + dashboardViewsV1,
+	},
+	{
+		Version: 6,
+		Name:    "dashboard_outer_joins",
+		Up:      dropDashboardViews + dashboardViewsV6,
+		Down:    dropDashboardViews + dashboardViewsV5,
+	},
+	{
+		// medication names become full orders; existing names are carried
+		// over as active orders by the patient's attending doctor
+		Version: 7,
+		Name:    "medication_orders",
+		Up: dropDashboardViews + `
+	CREATE TABLE MEDICATION_ORDER (
+	ORDER_ID SERIAL,
+	PATIENT_ID INT NOT NULL,
+	NAME VARCHAR(100) NOT NULL,
+	DOSE NUMERIC(10, 3),
+	UNIT VARCHAR(20),
+	ROUTE VARCHAR(20),
+	FREQUENCY VARCHAR(50),
+	START_DATE DATE NOT NULL DEFAULT CURRENT_DATE,
+	STOP_DATE DATE,
+	PRESCRIBING_DOCTOR_ID INT,
+	STATUS VARCHAR(20) NOT NULL DEFAULT 'active',
+	CREATED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UPDATED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (ORDER_ID),
+	CONSTRAINT MEDICATION_ORDER_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
+	CONSTRAINT MEDICATION_ORDER_FK_DOCTOR_ID FOREIGN KEY (PRESCRIBING_DOCTOR_ID) REFERENCES DOCTOR(DOCTOR_ID),
+	CONSTRAINT MEDICATION_ORDER_STATUS_CHECK CHECK (STATUS IN ('active', 'held', 'discontinued')));
+
+	CREATE INDEX MEDICATION_ORDER_PATIENT_ID_IDX ON MEDICATION_ORDER (PATIENT_ID);
+
+	INSERT INTO MEDICATION_ORDER (PATIENT_ID, NAME, PRESCRIBING_DOCTOR_ID)
+	SELECT m.PATIENT_ID, m.PRESCRIBED_MEDICATIONS, p.DOCTOR_ID
+	FROM PATIENT_MEDICATIONS AS m
+	JOIN PATIENT AS p ON p.PATIENT_ID = m.PATIENT_ID;
+
+	DROP TABLE PATIENT_MEDICATIONS;
+` + dashboardViewsV7,
+		Down: dropDashboardViews + `
+	CREATE TABLE PATIENT_MEDICATIONS (
+	PATIENT_ID INT,
+	PRESCRIBED_MEDICATIONS VARCHAR(50),
+	PRIMARY KEY(PATIENT_ID, PRESCRIBED_MEDICATIONS),
+	CONSTRAINT PATIENT_MEDICATIONS_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));
+
+	INSERT INTO PATIENT_MEDICATIONS (PATIENT_ID, PRESCRIBED_MEDICATIONS)
+	SELECT DISTINCT PATIENT_ID, LEFT(NAME, 50) FROM MEDICATION_ORDER
+	WHERE STATUS <> 'discontinued';
+
+	DROP TABLE MEDICATION_ORDER;
+` + dashboardViewsV6,
+	},
+	{
+		// free-text diseases become diagnoses; existing ones are carried over
+		// uncoded so that they show up until a clinician codes or resolves them
+		Version: 8,
+		Name:    "coded_diagnoses",
+		Up: dropDashboardViews + `
+	CREATE TABLE PATIENT_DIAGNOSIS (
+	DIAGNOSIS_ID SERIAL,
+	PATIENT_ID INT NOT NULL,
+	ICD10_CODE VARCHAR(8),
+	DESCRIPTION VARCHAR(255) NOT NULL,
+	ONSET_DATE DATE,
+	RESOLVED_DATE DATE,
+	IS_PRIMARY BOOLEAN NOT NULL DEFAULT FALSE,
+	CREATED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (DIAGNOSIS_ID),
+	CONSTRAINT PATIENT_DIAGNOSIS_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
+	CONSTRAINT PATIENT_DIAGNOSIS_RESOLVED_CHECK CHECK (RESOLVED_DATE IS NULL OR ONSET_DATE IS NULL OR RESOLVED_DATE >= ONSET_DATE));
+
+	CREATE INDEX PATIENT_DIAGNOSIS_PATIENT_ID_IDX ON PATIENT_DIAGNOSIS (PATIENT_ID);
+	CREATE INDEX PATIENT_DIAGNOSIS_ICD10_CODE_IDX ON PATIENT_DIAGNOSIS (ICD10_CODE);
+	-- at most one open primary diagnosis per patient
+	CREATE UNIQUE INDEX PATIENT_DIAGNOSIS_PRIMARY_IDX ON PATIENT_DIAGNOSIS (PATIENT_ID)
+		WHERE IS_PRIMARY AND RESOLVED_DATE IS NULL;
+
+	INSERT INTO PATIENT_DIAGNOSIS (PATIENT_ID, DESCRIPTION)
+	SELECT PATIENT_ID, DISEASE FROM PATIENT_DISEASE;
+
+	DROP TABLE PATIENT_DISEASE;
+` + dashboardViewsV8,
+		Down: dropDashboardViews + `
+	CREATE TABLE PATIENT_DISEASE (
+	PATIENT_ID INT,
+	DISEASE VARCHAR(50),
+	PRIMARY KEY(PATIENT_ID, DISEASE),
+	CONSTRAINT PATIENT_DISEASE_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID));
+
+	INSERT INTO PATIENT_DISEASE (PATIENT_ID, DISEASE)
+	SELECT DISTINCT PATIENT_ID, LEFT(DESCRIPTION, 50) FROM PATIENT_DIAGNOSIS
+	WHERE RESOLVED_DATE IS NULL;
+
+	DROP TABLE PATIENT_DIAGNOSIS;
+` + dashboardViewsV7,
+	},
+	{
+		// login accounts; doctor, nurse and patient accounts are linked to
+		// the row they act as
+		Version: 9,
+		Name:    "app_users",
+		Up: `
+	CREATE TABLE APP_USER (
+	USER_ID SERIAL,
+	USERNAME VARCHAR(50) NOT NULL,
+	PASSWORD_HASH VARCHAR(100) NOT NULL,
+	ROLE VARCHAR(20) NOT NULL,
+	DOCTOR_ID INT,
+	NURSE_ID INT,
+	PATIENT_ID INT,
+	ACTIVE BOOLEAN NOT NULL DEFAULT TRUE,
+	CREATED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (USER_ID),
+	CONSTRAINT APP_USER_FK_DOCTOR_ID FOREIGN KEY (DOCTOR_ID) REFERENCES DOCTOR(DOCTOR_ID),
+	CONSTRAINT APP_USER_FK_NURSE_ID FOREIGN KEY (NURSE_ID) REFERENCES NURSE(NURSE_ID),
+	CONSTRAINT APP_USER_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
+	CONSTRAINT APP_USER_ROLE_CHECK CHECK (
+		(ROLE = 'admin' AND DOCTOR_ID IS NULL AND NURSE_ID IS NULL AND PATIENT_ID IS NULL) OR
+		(ROLE = 'doctor' AND DOCTOR_ID IS NOT NULL AND NURSE_ID IS NULL AND PATIENT_ID IS NULL) OR
+		(ROLE = 'nurse' AND NURSE_ID IS NOT NULL AND DOCTOR_ID IS NULL AND PATIENT_ID IS NULL) OR
+		(ROLE = 'patient' AND PATIENT_ID IS NOT NULL AND DOCTOR_ID IS NULL AND NURSE_ID IS NULL)));
+
+	CREATE UNIQUE INDEX APP_USER_USERNAME_IDX ON APP_USER (LOWER(USERNAME));`,
+		Down: `
+	DROP TABLE APP_USER;`,
+	},
+	{
+		// break-the-glass grants; rows are kept after they expire for the
+		// compliance report
+		Version: 10,
+		Name:    "emergency_access",
+		Up: `
+	CREATE TABLE EMERGENCY_ACCESS (
+	GRANT_ID SERIAL,
+	USER_ID INT NOT NULL,
+	PATIENT_ID INT NOT NULL,
+	REASON VARCHAR(500) NOT NULL,
+	CLIENT_IP VARCHAR(45) NOT NULL DEFAULT '',
+	GRANTED_AT TIMESTAMP NOT NULL,
+	EXPIRES_AT TIMESTAMP NOT NULL,
+	REVOKED_AT TIMESTAMP,
+	PRIMARY KEY (GRANT_ID),
+	CONSTRAINT EMERGENCY_ACCESS_FK_USER_ID FOREIGN KEY (USER_ID) REFERENCES APP_USER(USER_ID),
+	CONSTRAINT EMERGENCY_ACCESS_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
+	CONSTRAINT EMERGENCY_ACCESS_EXPIRES_CHECK CHECK (EXPIRES_AT > GRANTED_AT));
+
+	CREATE INDEX EMERGENCY_ACCESS_USER_PATIENT_IDX ON EMERGENCY_ACCESS (USER_ID, PATIENT_ID, EXPIRES_AT);
+	CREATE INDEX EMERGENCY_ACCESS_GRANTED_AT_IDX ON EMERGENCY_ACCESS (GRANTED_AT);`,
+		Down: `
+	DROP TABLE EMERGENCY_ACCESS;`,
+	},
+	{
+		Version: 11,
+		Name:    "audit_log",
+		Up: `
+	CREATE TABLE AUDIT_LOG (
+	ENTRY_ID BIGINT NOT NULL,
+	OCCURRED_AT TIMESTAMP NOT NULL,
+	USER_ID INT NOT NULL,
+	USERNAME VARCHAR(50) NOT NULL,
+	ROLE VARCHAR(10) NOT NULL,
+	ACTION VARCHAR(200) NOT NULL,
+	STATUS INT NOT NULL,
+	CLIENT_IP VARCHAR(45) NOT NULL DEFAULT '',
+	REQUEST_ID VARCHAR(64) NOT NULL DEFAULT '',
+	PREV_HASH CHAR(64) NOT NULL,
+	HASH CHAR(64) NOT NULL,
+	PRIMARY KEY (ENTRY_ID));
+
+	CREATE INDEX AUDIT_LOG_OCCURRED_AT_IDX ON AUDIT_LOG (OCCURRED_AT);
+	CREATE INDEX AUDIT_LOG_USER_IDX ON AUDIT_LOG (USER_ID, OCCURRED_AT);
+
+	CREATE TABLE AUDIT_LOG_PATIENT (
+	ENTRY_ID BIGINT NOT NULL,
+	PATIENT_ID INT NOT NULL,
+	PRIMARY KEY (ENTRY_ID, PATIENT_ID),
+	CONSTRAINT AUDIT_LOG_PATIENT_FK_ENTRY_ID FOREIGN KEY (ENTRY_ID) REFERENCES AUDIT_LOG(ENTRY_ID));
+
+	CREATE INDEX AUDIT_LOG_PATIENT_PATIENT_IDX ON AUDIT_LOG_PATIENT (PATIENT_ID, ENTRY_ID);
+
+	CREATE TABLE AUDIT_LOG_HEAD (
+	ID INT NOT NULL,
+	LAST_ENTRY_ID BIGINT NOT NULL,
+	LAST_HASH CHAR(64) NOT NULL,
+	PRIMARY KEY (ID),
+	CONSTRAINT AUDIT_LOG_HEAD_SINGLE_ROW CHECK (ID = 1));
+
+	INSERT INTO AUDIT_LOG_HEAD (ID, LAST_ENTRY_ID, LAST_HASH)
+	VALUES (1, 0, '0000000000000000000000000000000000000000000000000000000000000000');
+
+	CREATE FUNCTION AUDIT_LOG_APPEND_ONLY() RETURNS TRIGGER AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit log is append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER AUDIT_LOG_NO_CHANGE BEFORE UPDATE OR DELETE ON AUDIT_LOG
+	FOR EACH ROW EXECUTE FUNCTION AUDIT_LOG_APPEND_ONLY();
+	CREATE TRIGGER AUDIT_LOG_NO_TRUNCATE BEFORE TRUNCATE ON AUDIT_LOG
+	FOR EACH STATEMENT EXECUTE FUNCTION AUDIT_LOG_APPEND_ONLY();
+	CREATE TRIGGER AUDIT_LOG_PATIENT_NO_CHANGE BEFORE UPDATE OR DELETE ON AUDIT_LOG_PATIENT
+	FOR EACH ROW EXECUTE FUNCTION AUDIT_LOG_APPEND_ONLY();
+	CREATE TRIGGER AUDIT_LOG_PATIENT_NO_TRUNCATE BEFORE TRUNCATE ON AUDIT_LOG_PATIENT
+	FOR EACH STATEMENT EXECUTE FUNCTION AUDIT_LOG_APPEND_ONLY();`,
+		Down: `
+	DROP TABLE AUDIT_LOG_HEAD;
+	DROP TABLE AUDIT_LOG_PATIENT;
+	DROP TABLE AUDIT_LOG;
+	DROP FUNCTION AUDIT_LOG_APPEND_ONLY();`,
+	},
+	{
+		Version: 12,
+		Name:    "news2_inputs",
+		Up: dropDashboardViews + `
+	ALTER TABLE VITAL_SIGN ADD COLUMN OXYGEN_SATURATION INT;
+	ALTER TABLE VITAL_SIGN ADD COLUMN SUPPLEMENTAL_OXYGEN BOOLEAN;
+	ALTER TABLE VITAL_SIGN ADD COLUMN CONSCIOUSNESS CHAR(1);
+	ALTER TABLE VITAL_SIGN ADD CONSTRAINT VITAL_SIGN_OXYGEN_SATURATION_CHECK
+		CHECK (OXYGEN_SATURATION BETWEEN 50 AND 100);
+	ALTER TABLE VITAL_SIGN ADD CONSTRAINT VITAL_SIGN_CONSCIOUSNESS_CHECK
+		CHECK (CONSCIOUSNESS IN ('A', 'C', 'V', 'P', 'U'));
+` + dashboardViewsV12,
+		Down: dropDashboardViews + `
+	ALTER TABLE VITAL_SIGN DROP COLUMN CONSCIOUSNESS;
+	ALTER TABLE VITAL_SIGN DROP COLUMN SUPPLEMENTAL_OXYGEN;
+	ALTER TABLE VITAL_SIGN DROP COLUMN OXYGEN_SATURATION;
+` + dashboardViewsV8,
+	},
+	{
+		Version: 13,
+		Name:    "vital_sign_alerts",
+		Up: `
+	CREATE TABLE ALERT_RULE (
+	RULE_ID SERIAL,
+	PATIENT_ID INT,
+	PARAMETER VARCHAR(30) NOT NULL,
+	CONDITION VARCHAR(10) NOT NULL,
+	THRESHOLD FLOAT,
+	READINGS INT,
+	SEVERITY VARCHAR(10) NOT NULL,
+	ACTIVE BOOLEAN NOT NULL DEFAULT TRUE,
+	CREATED_BY INT,
+	CREATED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (RULE_ID),
+	CONSTRAINT ALERT_RULE_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
+	CONSTRAINT ALERT_RULE_FK_CREATED_BY FOREIGN KEY (CREATED_BY) REFERENCES APP_USER(USER_ID),
+	CONSTRAINT ALERT_RULE_CONDITION_CHECK CHECK (
+		(CONDITION IN ('above', 'below') AND THRESHOLD IS NOT NULL AND READINGS IS NULL) OR
+		(CONDITION IN ('rising', 'falling') AND THRESHOLD IS NULL AND READINGS >= 2)),
+	CONSTRAINT ALERT_RULE_SEVERITY_CHECK CHECK (SEVERITY IN ('warning', 'critical')));
+
+	CREATE INDEX ALERT_RULE_PATIENT_IDX ON ALERT_RULE (PATIENT_ID);
+
+	INSERT INTO ALERT_RULE (PARAMETER, CONDITION, THRESHOLD, SEVERITY) VALUES
+		('systolic_pressure', 'above', 180, 'critical'),
+		('systolic_pressure', 'below', 90, 'critical'),
+		('body_temperature', 'above', 101.5, 'warning'),
+		('pulse_rate', 'above', 130, 'critical'),
+		('pulse_rate', 'below', 40, 'critical'),
+		('respiration_rate', 'above', 24, 'warning'),
+		('oxygen_saturation', 'below', 92, 'critical');
+
+	CREATE TABLE ALERT (
+	ALERT_ID SERIAL,
+	RULE_ID INT NOT NULL,
+	PATIENT_ID INT NOT NULL,
+	ISSUE_TIME TIMESTAMP NOT NULL,
+	PARAMETER VARCHAR(30) NOT NULL,
+	VALUE FLOAT NOT NULL,
+	SEVERITY VARCHAR(10) NOT NULL,
+	MESSAGE VARCHAR(255) NOT NULL,
+	STATUS VARCHAR(15) NOT NULL DEFAULT 'open',
+	CREATED_AT TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	ACKNOWLEDGED_AT TIMESTAMP,
+	ACKNOWLEDGED_BY INT,
+	RESOLVED_AT TIMESTAMP,
+	RESOLVED_BY INT,
+	PRIMARY KEY (ALERT_ID),
+	CONSTRAINT ALERT_FK_RULE_ID FOREIGN KEY (RULE_ID) REFERENCES ALERT_RULE(RULE_ID),
+	CONSTRAINT ALERT_FK_PATIENT_ID FOREIGN KEY (PATIENT_ID) REFERENCES PATIENT(PATIENT_ID),
+	CONSTRAINT ALERT_FK_ACKNOWLEDGED_BY FOREIGN KEY (ACKNOWLEDGED_BY) REFERENCES APP_USER(USER_ID),
+	CONSTRAINT ALERT_FK_RESOLVED_BY FOREIGN KEY (RESOLVED_BY) REFERENCES APP_USER(USER_ID),
+	CONSTRAINT ALERT_STATUS_CHECK CHECK (STATUS IN ('open', 'acknowledged', 'resolved')));
+
+	CREATE INDEX ALERT_PATIENT_STATUS_IDX ON ALERT (PATIENT_ID, STATUS);
+	-- a rule raises no new alert for a patient while the last one is unresolved
+	CREATE UNIQUE INDEX ALERT_UNRESOLVED_IDX ON ALERT (RULE_ID, PATIENT_ID) WHERE STATUS <> 'resolved';`,
+		Down: `
+	DROP TABLE ALERT;
+	DROP TABLE ALERT_RULE;`,
+	},
+	{
+		Version: 14,
+		Name:    "patient_change_notifications",
+		Up: `
+	-- postgres folds identical notifications of a transaction into one, so a
+	-- batch of readings is announced once
+	CREATE FUNCTION NOTIFY_PATIENT_CHANGE() RETURNS TRIGGER AS $$
+	DECLARE
+		changed RECORD;
+	BEGIN
+		IF TG_OP = 'DELETE' THEN
+			changed := OLD;
+		ELSE
+			changed := NEW;
+		END IF;
+		PERFORM pg_notify('patient_changes', json_build_object(
+			'patient_id', changed.patient_id,
+			'table', lower(TG_TABLE_NAME))::text);
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+
+	CREATE TRIGGER PATIENT_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON PATIENT
+	FOR EACH ROW EXECUTE FUNCTION NOTIFY_PATIENT_CHANGE();
+	CREATE TRIGGER PATIENT_NURSE_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON PATIENT_NURSE
+	FOR EACH ROW EXECUTE FUNCTION NOTIFY_PATIENT_CHANGE();
+	CREATE TRIGGER VITAL_SIGN_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON VITAL_SIGN
+	FOR EACH ROW EXECUTE FUNCTION NOTIFY_PATIENT_CHANGE();
+	CREATE TRIGGER MEDICATION_ORDER_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON MEDICATION_ORDER
+	FOR EACH ROW EXECUTE FUNCTION NOTIFY_PATIENT_CHANGE();
+	CREATE TRIGGER PATIENT_DIAGNOSIS_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON PATIENT_DIAGNOSIS
+	FOR EACH ROW EXECUTE FUNCTION NOTIFY_PATIENT_CHANGE();
+	CREATE TRIGGER ALERT_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON ALERT
+	FOR EACH ROW EXECUTE FUNCTION NOTIFY_PATIENT_CHANGE();`,
+		Down: `
+	DROP TRIGGER PATIENT_NOTIFY ON PATIENT;
+	DROP TRIGGER PATIENT_NURSE_NOTIFY ON PATIENT_NURSE;
+	DROP TRIGGER VITAL_SIGN_NOTIFY ON VITAL_SIGN;
+	DROP TRIGGER MEDICATION_ORDER_NOTIFY ON MEDICATION_ORDER;
+	DROP TRIGGER PATIENT_DIAGNOSIS_NOTIFY ON PATIENT_DIAGNOSIS;
+	DROP TRIGGER ALERT_NOTIFY ON ALERT;
+	DROP FUNCTION NOTIFY_PATIENT_CHANGE();`,
+	},
+	{
+		Version: 15,
+		Name:    "dashboard_paging",
+		Up: dropDashboardViews + `
+	ALTER TABLE VITAL_SIGN ADD COLUMN NEWS2_SCORE INT;
+	-- readings recorded so far are scored with the bands of package news2;
+	-- from now on the score is computed when a reading is inserted
+	UPDATE VITAL_SIGN SET NEWS2_SCORE =
+		CASE WHEN RESPIRATION_RATE <= 8 THEN 3 WHEN RESPIRATION_RATE <= 11 THEN 1
+			WHEN RESPIRATION_RATE <= 20 THEN 0 WHEN RESPIRATION_RATE <= 24 THEN 2 ELSE 3 END +
+		CASE WHEN OXYGEN_SATURATION <= 91 THEN 3 WHEN OXYGEN_SATURATION <= 93 THEN 2
+			WHEN OXYGEN_SATURATION <= 95 THEN 1 ELSE 0 END +
+		CASE WHEN SUPPLEMENTAL_OXYGEN THEN 2 ELSE 0 END +
+		CASE WHEN SYSTOLIC_PRESSURE <= 90 THEN 3 WHEN SYSTOLIC_PRESSURE <= 100 THEN 2
+			WHEN SYSTOLIC_PRESSURE <= 110 THEN 1 WHEN SYSTOLIC_PRESSURE <= 219 THEN 0 ELSE 3 END +
+		CASE WHEN PULSE_RATE <= 40 THEN 3 WHEN PULSE_RATE <= 50 THEN 1 WHEN PULSE_RATE <= 90 THEN 0
+			WHEN PULSE_RATE <= 110 THEN 1 WHEN PULSE_RATE <= 130 THEN 2 ELSE 3 END +
+		CASE WHEN CONSCIOUSNESS <> 'A' THEN 3 ELSE 0 END +
+		(SELECT CASE WHEN c <= 35.0 THEN 3 WHEN c <= 36.0 THEN 1 WHEN c <= 38.0 THEN 0
+			WHEN c <= 39.0 THEN 1 ELSE 2 END
+			FROM (SELECT ROUND(((BODY_TEMPERATURE - 32) * 5 / 9)::NUMERIC, 1) AS c) AS celsius);
+	ALTER TABLE VITAL_SIGN ALTER COLUMN NEWS2_SCORE SET NOT NULL;
+	CREATE INDEX PATIENT_LAST_NAME_IDX ON PATIENT (LAST_NAME, PATIENT_ID);
+` + dashboardViewsV15,
+		Down: dropDashboardViews + `
+	DROP INDEX PATIENT_LAST_NAME_IDX;
+	ALTER TABLE VITAL_SIGN DROP COLUMN NEWS2_SCORE;
+` + dashboardViewsV12,
+	},
+	{
+		Version: 16,
+		Name:    "ordered_dashboard_lists",
+		Up:      dropDashboardViews + dashboardViewsV16,
+		Down:    dropDashboardViews + dashboardViewsV15,
+	},
+	{
+		// version 1 shipped with demo rows, which SEED_DEMO_DATA loads now.
+		// The rows are matched by their ids, so a patient, doctor or nurse
+		// is only removed while every column version 1 gave it still holds
+		// the demo value, and nothing but the migrations refers to it. Rows
+		// of real people that merely reuse a demo id never match all of
+		// them.
+		Version: 17,
+		Name:    "remove_demo_data",
+		Up: `
+	CREATE TEMPORARY TABLE DEMO_PATIENT ON COMMIT DROP AS
+	SELECT p.PATIENT_ID FROM PATIENT AS p
+	JOIN (VALUES
+		(1, 'Alice', 'Johnson', 35, 'F', 'A+', DATE '1988-03-12', 1, '123-456-7890', '123 Main St'),
+		(2, 'Bob', 'Smith', 45, 'M', 'B-', DATE '1978-07-24', 2, '123-456-7891', '124 Main St'),
+		(3, 'Carol', 'Davis', 28, 'F', 'O+', DATE '1995-11-05', 1, '123-456-7892', '125 Second St'),
+		(4, 'Kenny', 'Kim', 35, 'F', 'O+', DATE '1995-11-05', 1, '123-456-7882', '126 Second St'))
+		AS demo (PATIENT_ID, FIRST_NAME, LAST_NAME, AGE, SEX, BLOOD_TYPE, DOB, DOCTOR_ID, PHONE_NUMBER, ADDRESS)
+	ON demo.PATIENT_ID = p.PATIENT_ID AND demo.FIRST_NAME = p.FIRST_NAME
+		AND demo.LAST_NAME = p.LAST_NAME AND demo.AGE = p.AGE AND demo.SEX = p.SEX
+		AND demo.BLOOD_TYPE = p.BLOOD_TYPE AND demo.DOB = p.DOB AND demo.DOCTOR_ID = p.DOCTOR_ID
+		AND demo.PHONE_NUMBER = p.PHONE_NUMBER AND demo.ADDRESS = p.ADDRESS
+	WHERE NOT EXISTS (SELECT 1 FROM APP_USER AS u WHERE u.PATIENT_ID = p.PATIENT_ID)
+	AND NOT EXISTS (SELECT 1 FROM EMERGENCY_ACCESS AS ea WHERE ea.PATIENT_ID = p.PATIENT_ID)
+	AND NOT EXISTS (SELECT 1 FROM AUDIT_LOG_PATIENT AS ap WHERE ap.PATIENT_ID = p.PATIENT_ID)
+	AND NOT EXISTS (SELECT 1 FROM ALERT AS a WHERE a.PATIENT_ID = p.PATIENT_ID)
+	AND NOT EXISTS (SELECT 1 FROM ALERT_RULE AS r WHERE r.PATIENT_ID = p.PATIENT_ID);
+
+	DELETE FROM VITAL_SIGN WHERE PATIENT_ID IN (SELECT PATIENT_ID FROM DEMO_PATIENT);
+	DELETE FROM MEDICATION_ORDER WHERE PATIENT_ID IN (SELECT PATIENT_ID FROM DEMO_PATIENT);
+	DELETE FROM PATIENT_DIAGNOSIS WHERE PATIENT_ID IN (SELECT PATIENT_ID FROM DEMO_PATIENT);
+	DELETE FROM PATIENT_NURSE WHERE PATIENT_ID IN (SELECT PATIENT_ID FROM DEMO_PATIENT);
+	DELETE FROM ASSIGNMENT_HISTORY WHERE PATIENT_ID IN (SELECT PATIENT_ID FROM DEMO_PATIENT);
+	DELETE FROM PATIENT WHERE PATIENT_ID IN (SELECT PATIENT_ID FROM DEMO_PATIENT);
+
+	DELETE FROM NURSE AS n
+	USING (VALUES (1, 'Emily', 'Wilson'), (2, 'David', 'Brown'), (3, 'Sophia', 'Anderson'))
+		AS demo (NURSE_ID, FIRST_NAME, LAST_NAME)
+	WHERE demo.NURSE_ID = n.NURSE_ID AND demo.FIRST_NAME = n.FIRST_NAME AND demo.LAST_NAME = n.LAST_NAME
+	AND NOT EXISTS (SELECT 1 FROM PATIENT_NURSE AS pn WHERE pn.NURSE_ID = n.NURSE_ID)
+	AND NOT EXISTS (SELECT 1 FROM ASSIGNMENT_HISTORY AS h WHERE h.STAFF_KIND = 'nurse' AND h.STAFF_ID = n.NURSE_ID)
+	AND NOT EXISTS (SELECT 1 FROM APP_USER AS u WHERE u.NURSE_ID = n.NURSE_ID);
+
+	DELETE FROM DOCTOR AS d
+	USING (VALUES (1, 'John', 'Doe'), (2, 'Jane', 'Smith'), (3, 'Michael', 'Johnson'))
+		AS demo (DOCTOR_ID, FIRST_NAME, LAST_NAME)
+	WHERE demo.DOCTOR_ID = d.DOCTOR_ID AND demo.FIRST_NAME = d.FIRST_NAME AND demo.LAST_NAME = d.LAST_NAME
+	AND NOT EXISTS (SELECT 1 FROM PATIENT AS p WHERE p.DOCTOR_ID = d.DOCTOR_ID)
+	AND NOT EXISTS (SELECT 1 FROM ASSIGNMENT_HISTORY AS h WHERE h.STAFF_KIND = 'doctor' AND h.STAFF_ID = d.DOCTOR_ID)
+	AND NOT EXISTS (SELECT 1 FROM MEDICATION_ORDER AS m WHERE m.PRESCRIBING_DOCTOR_ID = d.DOCTOR_ID)
+	AND NOT EXISTS (SELECT 1 FROM APP_USER AS u WHERE u.DOCTOR_ID = d.DOCTOR_ID);`,
+		Down: `
+	-- irreversible: the deleted demo rows are not restored, SEED_DEMO_DATA
+	-- loads them again; rolling back only forgets that version 17 ran
+	SELECT 1;`,
+	},
+	{
+		// the stored age went stale; it is derived from the date of birth
+		// when read
+		Version: 18,
+		Name:    "age_from_dob",
+		Up: dropDashboardViews + `
+	ALTER TABLE PATIENT DROP COLUMN AGE;
+` + dashboardViewsV18,
+		Down: dropDashboardViews + `
+	ALTER TABLE PATIENT ADD COLUMN AGE INT;
+	UPDATE PATIENT SET AGE = EXTRACT(YEAR FROM AGE(CURRENT_DATE, DOB));
+	ALTER TABLE PATIENT ALTER COLUMN AGE SET NOT NULL;
+` + dashboardViewsV16,
+	},
+	{
+		// grants change what the dashboard streams of their holder show
+		Version: 19,
+		Name:    "emergency_access_notifications",
+		Up: `
+	CREATE TRIGGER EMERGENCY_ACCESS_NOTIFY AFTER INSERT OR UPDATE OR DELETE ON EMERGENCY_ACCESS
+	FOR EACH ROW EXECUTE FUNCTION NOTIFY_PATIENT_CHANGE();`,
+		Down: `
+	DROP TRIGGER EMERGENCY_ACCESS_NOTIFY ON EMERGENCY_ACCESS;`,
+	},
+	{
+		// sorting by the total put a single parameter scoring 3 below a
+		// total of 4
+		Version: 20,
+		Name:    "news2_risk_rank",
+		Up: dropDashboardViews + `
+	ALTER TABLE VITAL_SIGN ADD COLUMN NEWS2_RANK INT;
+	-- news2.Score.Rank: the risk band times 100 plus the total
+	UPDATE VITAL_SIGN SET NEWS2_RANK = NEWS2_SCORE + CASE
+		WHEN NEWS2_SCORE >= 7 THEN 300
+		WHEN NEWS2_SCORE >= 5 THEN 200
+		WHEN (RESPIRATION_RATE <= 8 OR RESPIRATION_RATE >= 25 OR OXYGEN_SATURATION <= 91
+			OR SYSTOLIC_PRESSURE <= 90 OR SYSTOLIC_PRESSURE >= 220 OR PULSE_RATE <= 40 OR PULSE_RATE >= 131
+			OR CONSCIOUSNESS <> 'A' OR ROUND(((BODY_TEMPERATURE - 32) * 5 / 9)::NUMERIC, 1) <= 35.0) THEN 100
+		ELSE 0 END;
+	ALTER TABLE VITAL_SIGN ALTER COLUMN NEWS2_RANK SET NOT NULL;
+` + dashboardViewsV20,
+		Down: dropDashboardViews + `
+	ALTER TABLE VITAL_SIGN DROP COLUMN NEWS2_RANK;
+` + dashboardViewsV18,
+	},
+}
+
+// dashboardViewsV1 creates the dashboard views as of schema version 1.
+// Migrations that have to drop the views to alter a column recreate them from
+// this constant, so it must stay frozen.
+const dashboardViewsV1 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT DISTINCT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		m.prescribed_medications AS current_prescribed_med,
+		d.disease AS current_disease
+		FROM PATIENT AS p
+		JOIN vital_sign AS v ON p.patient_id = v.patient_id
+		JOIN patient_medications AS m ON p.patient_id = m.patient_id
+		JOIN patient_disease AS d ON p.patient_id = d.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT DISTINCT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		m.prescribed_medications AS current_prescribed_med,
+		d.disease AS current_disease
+		FROM nurse AS n
+		JOIN patient_nurse AS PN ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN vital_sign AS v ON p.patient_id = v.patient_id
+		JOIN patient_medications AS m ON p.patient_id = m.patient_id
+		JOIN patient_disease AS d ON p.patient_id = d.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT DISTINCT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		m.prescribed_medications AS current_prescribed_med,
+		d.disease AS current_disease
+		FROM nurse AS n
+		JOIN patient_nurse AS PN ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN vital_sign AS v ON p.patient_id = v.patient_id
+		JOIN patient_medications AS m ON p.patient_id = m.patient_id
+		JOIN patient_disease AS d ON p.patient_id = d.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);
+`
+
+// dashboardViewsV5 joins only the latest vital sign of every patient.
+const dashboardViewsV5 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT DISTINCT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		m.prescribed_medications AS current_prescribed_med,
+		d.disease AS current_disease
+		FROM PATIENT AS p
+		JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		JOIN patient_medications AS m ON p.patient_id = m.patient_id
+		JOIN patient_disease AS d ON p.patient_id = d.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT DISTINCT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		m.prescribed_medications AS current_prescribed_med,
+		d.disease AS current_disease
+		FROM nurse AS n
+		JOIN patient_nurse AS PN ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		JOIN patient_medications AS m ON p.patient_id = m.patient_id
+		JOIN patient_disease AS d ON p.patient_id = d.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT DISTINCT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		m.prescribed_medications AS current_prescribed_med,
+		d.disease AS current_disease
+		FROM nurse AS n
+		JOIN patient_nurse AS PN ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		JOIN patient_medications AS m ON p.patient_id = m.patient_id
+		JOIN patient_disease AS d ON p.patient_id = d.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id);
+`
+
+// dashboardViewsV6 returns one row per patient. Patients without vitals,
+// medications, diagnoses or nurses are kept; medications and diseases are
+// aggregated into JSON arrays.
+const dashboardViewsV6 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(m.prescribed_medications), '[]')
+			FROM patient_medications AS m WHERE m.patient_id = p.patient_id) AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(d.disease), '[]')
+			FROM patient_disease AS d WHERE d.patient_id = p.patient_id) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(m.prescribed_medications), '[]')
+			FROM patient_medications AS m WHERE m.patient_id = p.patient_id) AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(d.disease), '[]')
+			FROM patient_disease AS d WHERE d.patient_id = p.patient_id) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(m.prescribed_medications), '[]')
+			FROM patient_medications AS m WHERE m.patient_id = p.patient_id) AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(d.disease), '[]')
+			FROM patient_disease AS d WHERE d.patient_id = p.patient_id) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+// dashboardViewsV7 aggregates the active and held medication orders instead
+// of the old medication names.
+const dashboardViewsV7 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(d.disease), '[]')
+			FROM patient_disease AS d WHERE d.patient_id = p.patient_id) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(d.disease), '[]')
+			FROM patient_disease AS d WHERE d.patient_id = p.patient_id) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(d.disease), '[]')
+			FROM patient_disease AS d WHERE d.patient_id = p.patient_id) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+// dashboardViewsV8 lists the unresolved coded diagnoses instead of the
+// free-text diseases.
+const dashboardViewsV8 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+// dashboardViewsV12 adds the NEWS2 inputs of the latest reading.
+const dashboardViewsV12 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+// dashboardViewsV15 adds the NEWS2 score of the latest reading, which the
+// doctor and nurse dashboards can be sorted by.
+const dashboardViewsV15 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+// dashboardViewsV16 adds the ids of medication orders and diagnoses and
+// aggregates them in a defined order: medications by start date and name,
+// diagnoses primary first, then by onset date and description.
+const dashboardViewsV16 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+// dashboardViewsV18 computes the age from the date of birth when the views
+// are read.
+const dashboardViewsV18 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		EXTRACT(YEAR FROM AGE(CURRENT_DATE, p.dob))::INT AS age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		EXTRACT(YEAR FROM AGE(CURRENT_DATE, p.dob))::INT AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		EXTRACT(YEAR FROM AGE(CURRENT_DATE, p.dob))::INT AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+// dashboardViewsV20 adds the NEWS2 risk rank to the views. It is the output
+// of dashboardViews(Postgres) as of version 20.
+const dashboardViewsV20 = `
+	CREATE VIEW PATIENT_DASHBOARD_VIEW AS (
+	SELECT
+		p.patient_id AS ID,
+		p.first_name,
+		p.last_name,
+		EXTRACT(YEAR FROM AGE(CURRENT_DATE, p.dob))::INT AS age,
+		p.sex,
+		p.blood_type,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id));
+
+	CREATE VIEW NURSE_DASHBOARD_VIEW AS (
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		EXTRACT(YEAR FROM AGE(CURRENT_DATE, p.dob))::INT AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+
+	CREATE VIEW DOCTOR_DASHBOARD_VIEW AS (
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		EXTRACT(YEAR FROM AGE(CURRENT_DATE, p.dob))::INT AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob AS DOB,
+		p.doctor_id AS assigned_doctor_ID,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued') AS current_prescribed_meds,
+		(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL);
+`
+
+const dropDashboardViews = `
+	DROP VIEW IF EXISTS DOCTOR_DASHBOARD_VIEW;
+	DROP VIEW IF EXISTS NURSE_DASHBOARD_VIEW;
+	DROP VIEW IF EXISTS PATIENT_DASHBOARD_VIEW;
+`
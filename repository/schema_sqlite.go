@@ -0,0 +1,854 @@
+package repository
+
+// sqliteMigrations is the schema of SQLite databases. They serve tests and
+// development and never hold data worth upgrading, so instead of replaying
+// the history of migrations, which leans on postgres-only DDL, they start out
+// at the schema of version 16. Every later migration is added here under the
+// same version as in migrations, written for SQLite. Identifiers are lower
+// case because SQLite reports column names as they are declared. Since
+// version 20 the dashboard views of both dialects are written once, see
+// dashboardViews, and frozen per version like the postgres ones. Shipped
+// SQLite migrations are checksummed too, see shippedSQLiteMigrations.
+//
+// Changes are not announced: the NOTIFY triggers of versions 14 and 19 have
+// no SQLite counterpart.
+var sqliteMigrations = []Migration{
+	{
+		Version: 16,
+		Name:    "sqlite_schema",
+		Up: `
+	CREATE TABLE doctor (
+	doctor_id INTEGER PRIMARY KEY,
+	first_name VARCHAR(50) NOT NULL,
+	last_name VARCHAR(50) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	deactivated_at TIMESTAMP);
+
+	CREATE TABLE nurse (
+	nurse_id INTEGER PRIMARY KEY,
+	first_name VARCHAR(50) NOT NULL,
+	last_name VARCHAR(50) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	deactivated_at TIMESTAMP);
+
+	CREATE TABLE patient (
+	patient_id INTEGER PRIMARY KEY,
+	first_name VARCHAR(50) NOT NULL,
+	last_name VARCHAR(50) NOT NULL,
+	age INT NOT NULL,
+	sex CHAR NOT NULL,
+	phone_number VARCHAR(50) NOT NULL,
+	address VARCHAR(50) NOT NULL,
+	blood_type VARCHAR(3) NOT NULL,
+	dob DATE NOT NULL,
+	doctor_id INT NOT NULL,
+	discharged_at TIMESTAMP,
+	CONSTRAINT patient_fk_doctor_id FOREIGN KEY (doctor_id) REFERENCES doctor(doctor_id));
+
+	CREATE INDEX patient_last_name_idx ON patient (last_name, patient_id);
+
+	CREATE TABLE vital_sign (
+	patient_id INT,
+	issue_time TIMESTAMP,
+	body_temperature FLOAT NOT NULL,
+	pulse_rate INT NOT NULL,
+	respiration_rate INT NOT NULL,
+	systolic_pressure INT NOT NULL,
+	diastolic_pressure INT NOT NULL,
+	oxygen_saturation INT,
+	supplemental_oxygen BOOLEAN,
+	consciousness CHAR(1),
+	news2_score INT NOT NULL,
+	PRIMARY KEY (patient_id, issue_time),
+	CONSTRAINT vital_sign_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT vital_sign_oxygen_saturation_check CHECK (oxygen_saturation BETWEEN 50 AND 100),
+	CONSTRAINT vital_sign_consciousness_check CHECK (consciousness IN ('A', 'C', 'V', 'P', 'U')));
+
+	CREATE TABLE patient_nurse (
+	patient_id INT,
+	nurse_id INT,
+	PRIMARY KEY (patient_id, nurse_id),
+	CONSTRAINT patient_nurse_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT patient_nurse_fk_nurse_id FOREIGN KEY (nurse_id) REFERENCES nurse(nurse_id));
+
+	CREATE TABLE assignment_history (
+	assignment_id INTEGER PRIMARY KEY,
+	patient_id INT NOT NULL,
+	staff_kind VARCHAR(10) NOT NULL,
+	staff_id INT NOT NULL,
+	effective_from TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	effective_to TIMESTAMP,
+	CONSTRAINT assignment_history_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id));
+
+	CREATE INDEX assignment_history_patient_id_idx ON assignment_history (patient_id, effective_from);
+
+	CREATE TABLE medication_order (
+	order_id INTEGER PRIMARY KEY,
+	patient_id INT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	dose NUMERIC(10, 3),
+	unit VARCHAR(20),
+	route VARCHAR(20),
+	frequency VARCHAR(50),
+	start_date DATE NOT NULL DEFAULT CURRENT_DATE,
+	stop_date DATE,
+	prescribing_doctor_id INT,
+	status VARCHAR(20) NOT NULL DEFAULT 'active',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT medication_order_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT medication_order_fk_doctor_id FOREIGN KEY (prescribing_doctor_id) REFERENCES doctor(doctor_id),
+	CONSTRAINT medication_order_status_check CHECK (status IN ('active', 'held', 'discontinued')));
+
+	CREATE INDEX medication_order_patient_id_idx ON medication_order (patient_id);
+
+	CREATE TABLE patient_diagnosis (
+	diagnosis_id INTEGER PRIMARY KEY,
+	patient_id INT NOT NULL,
+	icd10_code VARCHAR(8),
+	description VARCHAR(255) NOT NULL,
+	onset_date DATE,
+	resolved_date DATE,
+	is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT patient_diagnosis_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT patient_diagnosis_resolved_check CHECK (resolved_date IS NULL OR onset_date IS NULL OR resolved_date >= onset_date));
+
+	CREATE INDEX patient_diagnosis_patient_id_idx ON patient_diagnosis (patient_id);
+	CREATE INDEX patient_diagnosis_icd10_code_idx ON patient_diagnosis (icd10_code);
+	-- at most one open primary diagnosis per patient
+	CREATE UNIQUE INDEX patient_diagnosis_primary_idx ON patient_diagnosis (patient_id)
+		WHERE is_primary AND resolved_date IS NULL;
+
+	CREATE TABLE app_user (
+	user_id INTEGER PRIMARY KEY,
+	username VARCHAR(50) NOT NULL,
+	password_hash VARCHAR(100) NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	doctor_id INT,
+	nurse_id INT,
+	patient_id INT,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT app_user_fk_doctor_id FOREIGN KEY (doctor_id) REFERENCES doctor(doctor_id),
+	CONSTRAINT app_user_fk_nurse_id FOREIGN KEY (nurse_id) REFERENCES nurse(nurse_id),
+	CONSTRAINT app_user_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT app_user_role_check CHECK (
+		(role = 'admin' AND doctor_id IS NULL AND nurse_id IS NULL AND patient_id IS NULL) OR
+		(role = 'doctor' AND doctor_id IS NOT NULL AND nurse_id IS NULL AND patient_id IS NULL) OR
+		(role = 'nurse' AND nurse_id IS NOT NULL AND doctor_id IS NULL AND patient_id IS NULL) OR
+		(role = 'patient' AND patient_id IS NOT NULL AND doctor_id IS NULL AND nurse_id IS NULL)));
+
+	CREATE UNIQUE INDEX app_user_username_idx ON app_user (LOWER(username));
+
+	CREATE TABLE emergency_access (
+	grant_id INTEGER PRIMARY KEY,
+	user_id INT NOT NULL,
+	patient_id INT NOT NULL,
+	reason VARCHAR(500) NOT NULL,
+	client_ip VARCHAR(45) NOT NULL DEFAULT '',
+	granted_at TIMESTAMP NOT NULL,
+	expires_at TIMESTAMP NOT NULL,
+	revoked_at TIMESTAMP,
+	CONSTRAINT emergency_access_fk_user_id FOREIGN KEY (user_id) REFERENCES app_user(user_id),
+	CONSTRAINT emergency_access_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT emergency_access_expires_check CHECK (expires_at > granted_at));
+
+	CREATE INDEX emergency_access_user_patient_idx ON emergency_access (user_id, patient_id, expires_at);
+	CREATE INDEX emergency_access_granted_at_idx ON emergency_access (granted_at);
+
+	CREATE TABLE audit_log (
+	entry_id BIGINT NOT NULL,
+	occurred_at TIMESTAMP NOT NULL,
+	user_id INT NOT NULL,
+	username VARCHAR(50) NOT NULL,
+	role VARCHAR(10) NOT NULL,
+	action VARCHAR(200) NOT NULL,
+	status INT NOT NULL,
+	client_ip VARCHAR(45) NOT NULL DEFAULT '',
+	request_id VARCHAR(64) NOT NULL DEFAULT '',
+	prev_hash CHAR(64) NOT NULL,
+	hash CHAR(64) NOT NULL,
+	PRIMARY KEY (entry_id));
+
+	CREATE INDEX audit_log_occurred_at_idx ON audit_log (occurred_at);
+	CREATE INDEX audit_log_user_idx ON audit_log (user_id, occurred_at);
+
+	CREATE TABLE audit_log_patient (
+	entry_id BIGINT NOT NULL,
+	patient_id INT NOT NULL,
+	PRIMARY KEY (entry_id, patient_id),
+	CONSTRAINT audit_log_patient_fk_entry_id FOREIGN KEY (entry_id) REFERENCES audit_log(entry_id));
+
+	CREATE INDEX audit_log_patient_patient_idx ON audit_log_patient (patient_id, entry_id);
+
+	CREATE TABLE audit_log_head (
+	id INT NOT NULL,
+	last_entry_id BIGINT NOT NULL,
+	last_hash CHAR(64) NOT NULL,
+	PRIMARY KEY (id),
+	CONSTRAINT audit_log_head_single_row CHECK (id = 1));
+
+	INSERT INTO audit_log_head (id, last_entry_id, last_hash)
+	VALUES (1, 0, '0000000000000000000000000000000000000000000000000000000000000000');
+
+	-- SQLite has no TRUNCATE; a DELETE without WHERE fires the triggers
+	CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+	CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+	CREATE TRIGGER audit_log_patient_no_update BEFORE UPDATE ON audit_log_patient
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+	CREATE TRIGGER audit_log_patient_no_delete BEFORE DELETE ON audit_log_patient
+	BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END;
+
+	CREATE TABLE alert_rule (
+	rule_id INTEGER PRIMARY KEY,
+	patient_id INT,
+	parameter VARCHAR(30) NOT NULL,
+	condition VARCHAR(10) NOT NULL,
+	threshold FLOAT,
+	readings INT,
+	severity VARCHAR(10) NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_by INT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT alert_rule_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT alert_rule_fk_created_by FOREIGN KEY (created_by) REFERENCES app_user(user_id),
+	CONSTRAINT alert_rule_condition_check CHECK (
+		(condition IN ('above', 'below') AND threshold IS NOT NULL AND readings IS NULL) OR
+		(condition IN ('rising', 'falling') AND threshold IS NULL AND readings >= 2)),
+	CONSTRAINT alert_rule_severity_check CHECK (severity IN ('warning', 'critical')));
+
+	CREATE INDEX alert_rule_patient_idx ON alert_rule (patient_id);
+
+	INSERT INTO alert_rule (parameter, condition, threshold, severity) VALUES
+		('systolic_pressure', 'above', 180, 'critical'),
+		('systolic_pressure', 'below', 90, 'critical'),
+		('body_temperature', 'above', 101.5, 'warning'),
+		('pulse_rate', 'above', 130, 'critical'),
+		('pulse_rate', 'below', 40, 'critical'),
+		('respiration_rate', 'above', 24, 'warning'),
+		('oxygen_saturation', 'below', 92, 'critical');
+
+	CREATE TABLE alert (
+	alert_id INTEGER PRIMARY KEY,
+	rule_id INT NOT NULL,
+	patient_id INT NOT NULL,
+	issue_time TIMESTAMP NOT NULL,
+	parameter VARCHAR(30) NOT NULL,
+	value FLOAT NOT NULL,
+	severity VARCHAR(10) NOT NULL,
+	message VARCHAR(255) NOT NULL,
+	status VARCHAR(15) NOT NULL DEFAULT 'open',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	acknowledged_at TIMESTAMP,
+	acknowledged_by INT,
+	resolved_at TIMESTAMP,
+	resolved_by INT,
+	CONSTRAINT alert_fk_rule_id FOREIGN KEY (rule_id) REFERENCES alert_rule(rule_id),
+	CONSTRAINT alert_fk_patient_id FOREIGN KEY (patient_id) REFERENCES patient(patient_id),
+	CONSTRAINT alert_fk_acknowledged_by FOREIGN KEY (acknowledged_by) REFERENCES app_user(user_id),
+	CONSTRAINT alert_fk_resolved_by FOREIGN KEY (resolved_by) REFERENCES app_user(user_id),
+	CONSTRAINT alert_status_check CHECK (status IN ('open', 'acknowledged', 'resolved')));
+
+	CREATE INDEX alert_patient_status_idx ON alert (patient_id, status);
+	-- a rule raises no new alert for a patient while the last one is unresolved
+	CREATE UNIQUE INDEX alert_unresolved_idx ON alert (rule_id, patient_id) WHERE status <> 'resolved';
+` + sqliteDashboardViewsV16,
+		Down: dropDashboardViews + `
+	DROP TABLE alert;
+	DROP TABLE alert_rule;
+	DROP TABLE audit_log_head;
+	DROP TABLE audit_log_patient;
+	DROP TABLE audit_log;
+	DROP TABLE emergency_access;
+	DROP TABLE app_user;
+	DROP TABLE patient_diagnosis;
+	DROP TABLE medication_order;
+	DROP TABLE assignment_history;
+	DROP TABLE patient_nurse;
+	DROP TABLE vital_sign;
+	DROP TABLE patient;
+	DROP TABLE nurse;
+	DROP TABLE doctor;`,
+	},
+	{
+		// the schema of version 16 never held the demo rows
+		Version: 17,
+		Name:    "remove_demo_data",
+		Up: `
+	SELECT 1;`,
+		Down: `
+	SELECT 1;`,
+	},
+	{
+		Version: 18,
+		Name:    "age_from_dob",
+		Up: dropDashboardViews + `
+	ALTER TABLE patient DROP COLUMN age;
+` + sqliteDashboardViewsV18,
+		Down: dropDashboardViews + `
+	ALTER TABLE patient ADD COLUMN age INT NOT NULL DEFAULT 0;
+	UPDATE patient SET age = CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', dob) AS INTEGER)
+		- (strftime('%m-%d', 'now') < strftime('%m-%d', dob));
+` + sqliteDashboardViewsV16,
+	},
+	{
+		Version: 19,
+		Name:    "emergency_access_notifications",
+		Up: `
+	SELECT 1;`,
+		Down: `
+	SELECT 1;`,
+	},
+	{
+		Version: 20,
+		Name:    "news2_risk_rank",
+		Up: dropDashboardViews + `
+	ALTER TABLE vital_sign ADD COLUMN news2_rank INT NOT NULL DEFAULT 0;
+	UPDATE vital_sign SET news2_rank = news2_score + CASE
+		WHEN news2_score >= 7 THEN 300
+		WHEN news2_score >= 5 THEN 200
+		WHEN (respiration_rate <= 8 OR respiration_rate >= 25 OR oxygen_saturation <= 91
+			OR systolic_pressure <= 90 OR systolic_pressure >= 220 OR pulse_rate <= 40 OR pulse_rate >= 131
+			OR consciousness <> 'A' OR ROUND((body_temperature - 32) * 5 / 9, 1) <= 35.0) THEN 100
+		ELSE 0 END;
+` + sqliteDashboardViewsV20,
+		Down: dropDashboardViews + `
+	ALTER TABLE vital_sign DROP COLUMN news2_rank;
+` + sqliteDashboardViewsV18,
+	},
+}
+
+// sqliteDashboardViewsV16 are the dashboard views of version 16 for SQLite.
+// json_group_array keeps the order of the rows it is fed, and booleans are
+// spelled out since SQLite stores them as integers.
+const sqliteDashboardViewsV16 = `
+	CREATE VIEW patient_dashboard_view AS
+	SELECT
+		p.patient_id AS id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id);
+
+	CREATE VIEW nurse_dashboard_view AS
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL;
+
+	CREATE VIEW doctor_dashboard_view AS
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		p.age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL;
+`
+
+// sqliteDashboardViewsV18 computes the age from the date of birth when the
+// views are read.
+const sqliteDashboardViewsV18 = `
+	CREATE VIEW patient_dashboard_view AS
+	SELECT
+		p.patient_id AS id,
+		p.first_name,
+		p.last_name,
+		CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', p.dob) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', p.dob)) AS age,
+		p.sex,
+		p.blood_type,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id);
+
+	CREATE VIEW nurse_dashboard_view AS
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', p.dob) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', p.dob)) AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL;
+
+	CREATE VIEW doctor_dashboard_view AS
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', p.dob) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', p.dob)) AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL;
+`
+
+// sqliteDashboardViewsV20 adds the NEWS2 risk rank to the views. It is the
+// output of dashboardViews(SQLite) as of version 20.
+const sqliteDashboardViewsV20 = `
+	CREATE VIEW patient_dashboard_view AS
+	SELECT
+		p.patient_id AS id,
+		p.first_name,
+		p.last_name,
+		CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', p.dob) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', p.dob)) AS age,
+		p.sex,
+		p.blood_type,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id);
+
+	CREATE VIEW nurse_dashboard_view AS
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', p.dob) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', p.dob)) AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL;
+
+	CREATE VIEW doctor_dashboard_view AS
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', p.dob) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', p.dob)) AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		p.dob,
+		p.doctor_id AS assigned_doctor_id,
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m) AS current_prescribed_meds,
+		(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d) AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL;
+`
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"strings"
+)
+
+// dashboardViews returns the current dashboard views of the dialect. They are
+// written once, in dashboardViewsTemplate, with the parts that postgres and
+// SQLite spell differently left as placeholders, so that the views of the
+// two dialects expose the same columns.
+//
+// Migrations never embed the template itself, since a shipped migration must
+// not change: a migration that changes the views freezes the output of both
+// dialects in a dashboardViewsVn and a sqliteDashboardViewsVn const, like
+// version 20 did. Test_ViewTemplateIsFrozen keeps the template in step with
+// the newest of them.
+func dashboardViews(d Dialect) string {
+	if d == SQLite {
+		return sqliteViewParts.Replace(dashboardViewsTemplate)
+	}
+	return postgresViewParts.Replace(dashboardViewsTemplate)
+}
+
+var postgresViewParts = strings.NewReplacer(
+	"{patient_dashboard_view}", "PATIENT_DASHBOARD_VIEW",
+	"{nurse_dashboard_view}", "NURSE_DASHBOARD_VIEW",
+	"{doctor_dashboard_view}", "DOCTOR_DASHBOARD_VIEW",
+	"{begin}", " (",
+	"{end}", ")",
+	"{id}", "ID",
+	"{dob}", "p.dob AS DOB",
+	"{assigned_doctor_id}", "assigned_doctor_ID",
+	"{age}", `EXTRACT(YEAR FROM AGE(CURRENT_DATE, p.dob))::INT`,
+	"{current_prescribed_meds}", `(SELECT COALESCE(json_agg(json_build_object(
+			'order_id', o.order_id,
+			'name', o.name,
+			'dose', o.dose,
+			'unit', o.unit,
+			'route', o.route,
+			'frequency', o.frequency,
+			'start_date', o.start_date,
+			'stop_date', o.stop_date,
+			'prescribing_doctor_id', o.prescribing_doctor_id,
+			'status', o.status)
+			ORDER BY o.start_date, o.name, o.order_id), '[]')
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued')`,
+	"{current_diseases}", `(SELECT COALESCE(json_agg(json_build_object(
+			'diagnosis_id', d.diagnosis_id,
+			'icd10_code', d.icd10_code,
+			'description', d.description,
+			'onset_date', d.onset_date,
+			'resolved_date', d.resolved_date,
+			'is_primary', d.is_primary)
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id), '[]')
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL)`,
+)
+
+// sqliteViewParts builds the JSON arrays from ordered subqueries, since
+// json_group_array has no ORDER BY of its own.
+var sqliteViewParts = strings.NewReplacer(
+	"{patient_dashboard_view}", "patient_dashboard_view",
+	"{nurse_dashboard_view}", "nurse_dashboard_view",
+	"{doctor_dashboard_view}", "doctor_dashboard_view",
+	"{begin}", "",
+	"{end}", "",
+	"{id}", "id",
+	"{dob}", "p.dob",
+	"{assigned_doctor_id}", "assigned_doctor_id",
+	"{age}", `CAST(strftime('%Y', 'now') AS INTEGER) - CAST(strftime('%Y', p.dob) AS INTEGER)
+			- (strftime('%m-%d', 'now') < strftime('%m-%d', p.dob))`,
+	"{current_prescribed_meds}", `(SELECT json_group_array(json(m.medication)) FROM (
+			SELECT json_object(
+				'order_id', o.order_id,
+				'name', o.name,
+				'dose', o.dose,
+				'unit', o.unit,
+				'route', o.route,
+				'frequency', o.frequency,
+				'start_date', o.start_date,
+				'stop_date', o.stop_date,
+				'prescribing_doctor_id', o.prescribing_doctor_id,
+				'status', o.status) AS medication
+			FROM medication_order AS o
+			WHERE o.patient_id = p.patient_id AND o.status <> 'discontinued'
+			ORDER BY o.start_date, o.name, o.order_id) AS m)`,
+	"{current_diseases}", `(SELECT json_group_array(json(d.disease)) FROM (
+			SELECT json_object(
+				'diagnosis_id', d.diagnosis_id,
+				'icd10_code', d.icd10_code,
+				'description', d.description,
+				'onset_date', d.onset_date,
+				'resolved_date', d.resolved_date,
+				'is_primary', json(CASE WHEN d.is_primary THEN 'true' ELSE 'false' END)) AS disease
+			FROM patient_diagnosis AS d
+			WHERE d.patient_id = p.patient_id AND d.resolved_date IS NULL
+			ORDER BY d.is_primary DESC, d.onset_date NULLS LAST, d.description, d.diagnosis_id) AS d)`,
+)
+
+const dashboardViewsTemplate = `
+	CREATE VIEW {patient_dashboard_view} AS{begin}
+	SELECT
+		p.patient_id AS {id},
+		p.first_name,
+		p.last_name,
+		{age} AS age,
+		p.sex,
+		p.blood_type,
+		{dob},
+		p.doctor_id AS {assigned_doctor_id},
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		{current_prescribed_meds} AS current_prescribed_meds,
+		{current_diseases} AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id){end};
+
+	CREATE VIEW {nurse_dashboard_view} AS{begin}
+		SELECT
+		n.nurse_id,
+		n.first_name AS nurse_first_name,
+		n.last_name AS nurse_last_name,
+		p.patient_id,
+		p.first_name AS patient_first_name,
+		p.last_name AS patient_last_name,
+		{age} AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		{dob},
+		p.doctor_id AS {assigned_doctor_id},
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		{current_prescribed_meds} AS current_prescribed_meds,
+		{current_diseases} AS current_diseases
+		FROM nurse AS n
+		JOIN patient_nurse AS pn ON n.nurse_id = pn.nurse_id
+		JOIN patient AS p ON pn.patient_id = p.patient_id
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL{end};
+
+	CREATE VIEW {doctor_dashboard_view} AS{begin}
+		SELECT
+		p.patient_id,
+		p.first_name,
+		p.last_name,
+		{age} AS age,
+		p.sex,
+		p.blood_type,
+		p.phone_number,
+		p.address,
+		{dob},
+		p.doctor_id AS {assigned_doctor_id},
+		doc.first_name AS assigned_doctor_first_name,
+		doc.last_name AS assigned_doctor_last_name,
+		v.body_temperature,
+		v.pulse_rate,
+		v.respiration_rate,
+		v.systolic_pressure,
+		v.diastolic_pressure,
+		v.oxygen_saturation,
+		v.supplemental_oxygen,
+		v.consciousness,
+		v.news2_score,
+		v.news2_rank,
+		v.issue_time AS vitals_recorded_at,
+		{current_prescribed_meds} AS current_prescribed_meds,
+		{current_diseases} AS current_diseases
+		FROM patient AS p
+		JOIN doctor AS doc ON p.doctor_id = doc.doctor_id
+		LEFT JOIN vital_sign AS v ON p.patient_id = v.patient_id
+			AND v.issue_time = (SELECT MAX(issue_time) FROM vital_sign WHERE patient_id = p.patient_id)
+		WHERE p.discharged_at IS NULL{end};
+`
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SQLiteViewsMatchPostgres(t *testing.T) {
+	db := seededSQLite(t)
+	views := viewColumns(dashboardViews(Postgres))
+	require.Len(t, views, 3)
+	for view, columns := range views {
+		var sqliteColumns []string
+		require.NoError(t, db.DB.Raw(`SELECT name FROM pragma_table_info(?)`, view).Scan(&sqliteColumns).Error)
+		assert.Equal(t, columns, sqliteColumns, view)
+	}
+}
+
+func Test_ViewTemplateIsFrozen(t *testing.T) {
+	// after changing the template, freeze its output for the migration that
+	// ships it and compare against those consts instead
+	assert.Equal(t, dashboardViewsV20, dashboardViews(Postgres))
+	assert.Equal(t, sqliteDashboardViewsV20, dashboardViews(SQLite))
+}
+
+// viewColumns returns the lower case column names of each CREATE VIEW of
+// the SQL, in order. The FROM of the view must start a line indented by two
+// tabs.
+func viewColumns(sql string) map[string][]string {
+	views := map[string][]string{}
+	for _, create := range strings.Split(sql, "CREATE VIEW ")[1:] {
+		name := strings.ToLower(strings.Fields(create)[0])
+		list := create[strings.Index(create, "SELECT")+len("SELECT") : strings.Index(create, "\n\t\tFROM ")]
+		var columns []string
+		add := func(column string) {
+			column = strings.TrimSpace(column)
+			if as := strings.LastIndex(column, " AS "); as >= 0 {
+				column = column[as+len(" AS "):]
+			} else {
+				column = column[strings.LastIndex(column, ".")+1:]
+			}
+			columns = append(columns, strings.ToLower(column))
+		}
+		depth, start, quoted := 0, 0, false
+		for i, c := range list {
+			switch {
+			case c == '\'':
+				quoted = !quoted
+			case quoted:
+			case c == '(':
+				depth++
+			case c == ')':
+				depth--
+			case c == ',' && depth == 0:
+				add(list[start:i])
+				start = i + 1
+			}
+		}
+		add(list[start:])
+		views[name] = columns
+	}
+	return views
+}
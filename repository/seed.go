@@ -0,0 +1,285 @@
+package repository
+
+import (
+	_ "embed"
+	"fmt"
+	"health-care-backend/auth"
+	"health-care-backend/icd10"
+	"health-care-backend/news2"
+	model "health-care-backend/repository/model"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+//go:embed fixtures/demo.yaml
+var demoFixtures []byte
+
+// Fixtures is a data set that can be loaded with Seed. Fixture files are YAML;
+// since YAML is a superset of JSON, JSON files with the same keys work too.
+type Fixtures struct {
+	Doctors       []DoctorFixture       `json:"doctors" yaml:"doctors"`
+	Nurses        []NurseFixture        `json:"nurses" yaml:"nurses"`
+	Patients      []PatientFixture      `json:"patients" yaml:"patients"`
+	PatientNurses []PatientNurseFixture `json:"patient_nurses" yaml:"patient_nurses"`
+	VitalSigns    []VitalSignFixture    `json:"vital_signs" yaml:"vital_signs"`
+	Medications   []MedicationFixture   `json:"medications" yaml:"medications"`
+	Diseases      []DiseaseFixture      `json:"diseases" yaml:"diseases"`
+	Users         []UserFixture         `json:"users" yaml:"users"`
+}
+
+type DoctorFixture struct {
+	DoctorID  int    `json:"doctor_id" yaml:"doctor_id"`
+	FirstName string `json:"first_name" yaml:"first_name"`
+	LastName  string `json:"last_name" yaml:"last_name"`
+}
+
+type NurseFixture struct {
+	NurseID   int    `json:"nurse_id" yaml:"nurse_id"`
+	FirstName string `json:"first_name" yaml:"first_name"`
+	LastName  string `json:"last_name" yaml:"last_name"`
+}
+
+type PatientFixture struct {
+	PatientID   int    `json:"patient_id" yaml:"patient_id"`
+	FirstName   string `json:"first_name" yaml:"first_name"`
+	LastName    string `json:"last_name" yaml:"last_name"`
+	Sex         string `json:"sex" yaml:"sex"`
+	BloodType   string `json:"blood_type" yaml:"blood_type"`
+	DOB         string `json:"dob" yaml:"dob"`
+	DoctorID    int    `json:"doctor_id" yaml:"doctor_id"`
+	PhoneNumber string `json:"phone_number" yaml:"phone_number"`
+	Address     string `json:"address" yaml:"address"`
+}
+
+type PatientNurseFixture struct {
+	PatientID int `json:"patient_id" yaml:"patient_id"`
+	NurseID   int `json:"nurse_id" yaml:"nurse_id"`
+}
+
+// VitalSignFixture is a reading. The NEWS2 inputs oxygen_saturation,
+// supplemental_oxygen and consciousness may be left out.
+type VitalSignFixture struct {
+	PatientID          int       `json:"patient_id" yaml:"patient_id"`
+	IssueTime          time.Time `json:"issue_time" yaml:"issue_time"`
+	BodyTemperature    float64   `json:"body_temperature" yaml:"body_temperature"`
+	PulseRate          int       `json:"pulse_rate" yaml:"pulse_rate"`
+	RespirationRate    int       `json:"respiration_rate" yaml:"respiration_rate"`
+	SystolicPressure   int       `json:"systolic_pressure" yaml:"systolic_pressure"`
+	DiastolicPressure  int       `json:"diastolic_pressure" yaml:"diastolic_pressure"`
+	OxygenSaturation   *int      `json:"oxygen_saturation" yaml:"oxygen_saturation"`
+	SupplementalOxygen *bool     `json:"supplemental_oxygen" yaml:"supplemental_oxygen"`
+	Consciousness      *string   `json:"consciousness" yaml:"consciousness"`
+}
+
+// MedicationFixture is an order prescribed by the patient's attending doctor.
+// Status defaults to active.
+type MedicationFixture struct {
+	PatientID int      `json:"patient_id" yaml:"patient_id"`
+	Name      string   `json:"name" yaml:"name"`
+	Dose      *float64 `json:"dose" yaml:"dose"`
+	Unit      *string  `json:"unit" yaml:"unit"`
+	Route     *string  `json:"route" yaml:"route"`
+	Frequency *string  `json:"frequency" yaml:"frequency"`
+	Status    string   `json:"status" yaml:"status"`
+}
+
+// DiseaseFixture is a diagnosis of the patient. With ICD10Code set, Name
+// may be left empty and defaults to the code's description.
+type DiseaseFixture struct {
+	PatientID int     `json:"patient_id" yaml:"patient_id"`
+	Name      string  `json:"name" yaml:"name"`
+	ICD10Code *string `json:"icd10_code" yaml:"icd10_code"`
+	OnsetDate *string `json:"onset_date" yaml:"onset_date"`
+	IsPrimary bool    `json:"is_primary" yaml:"is_primary"`
+}
+
+// UserFixture is a login account. The password is hashed while seeding.
+type UserFixture struct {
+	Username  string `json:"username" yaml:"username"`
+	Password  string `json:"password" yaml:"password"`
+	Role      string `json:"role" yaml:"role"`
+	DoctorID  *int   `json:"doctor_id" yaml:"doctor_id"`
+	NurseID   *int   `json:"nurse_id" yaml:"nurse_id"`
+	PatientID *int   `json:"patient_id" yaml:"patient_id"`
+}
+
+// DemoFixtures returns the built-in demo data set.
+func DemoFixtures() (*Fixtures, error) {
+	return parseFixtures(demoFixtures)
+}
+
+// LoadFixtures reads a YAML or JSON fixture file.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseFixtures(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+func parseFixtures(data []byte) (*Fixtures, error) {
+	var f Fixtures
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Seed inserts the fixtures in a single transaction. Rows whose key already
+// exists are left untouched, so seeding the same data set twice is a no-op.
+func Seed(db *GormDatabase, f *Fixtures) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		for _, d := range f.Doctors {
+			if err := tx.Exec(`
+			INSERT INTO DOCTOR (DOCTOR_ID, FIRST_NAME, LAST_NAME)
+			VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+				d.DoctorID, d.FirstName, d.LastName).Error; err != nil {
+				return err
+			}
+		}
+		// fixtures carry explicit ids, keep the id sequences ahead of them;
+		// SQLite continues after the highest id by itself
+		sequences := dialectOf(tx) == Postgres
+		if len(f.Doctors) > 0 && sequences {
+			if err := tx.Exec(`SELECT setval('doctor_doctor_id_seq', (SELECT MAX(DOCTOR_ID) FROM DOCTOR))`).Error; err != nil {
+				return err
+			}
+		}
+		for _, n := range f.Nurses {
+			if err := tx.Exec(`
+			INSERT INTO NURSE (NURSE_ID, FIRST_NAME, LAST_NAME)
+			VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+				n.NurseID, n.FirstName, n.LastName).Error; err != nil {
+				return err
+			}
+		}
+		if len(f.Nurses) > 0 && sequences {
+			if err := tx.Exec(`SELECT setval('nurse_nurse_id_seq', (SELECT MAX(NURSE_ID) FROM NURSE))`).Error; err != nil {
+				return err
+			}
+		}
+		for _, p := range f.Patients {
+			if err := tx.Exec(`
+			INSERT INTO PATIENT (PATIENT_ID, FIRST_NAME, LAST_NAME, SEX, BLOOD_TYPE, DOB, DOCTOR_ID, PHONE_NUMBER, ADDRESS)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+				p.PatientID, p.FirstName, p.LastName, p.Sex, p.BloodType, p.DOB, p.DoctorID, p.PhoneNumber, p.Address).Error; err != nil {
+				return err
+			}
+		}
+		if len(f.Patients) > 0 && sequences {
+			if err := tx.Exec(`SELECT setval('patient_patient_id_seq', (SELECT MAX(PATIENT_ID) FROM PATIENT))`).Error; err != nil {
+				return err
+			}
+		}
+		for _, pn := range f.PatientNurses {
+			if err := tx.Exec(`
+			INSERT INTO PATIENT_NURSE (PATIENT_ID, NURSE_ID)
+			VALUES (?, ?) ON CONFLICT DO NOTHING`,
+				pn.PatientID, pn.NurseID).Error; err != nil {
+				return err
+			}
+		}
+		for _, v := range f.VitalSigns {
+			score := news2.Compute(model.VitalSign{
+				BodyTemperature:    v.BodyTemperature,
+				PulseRate:          v.PulseRate,
+				RespirationRate:    v.RespirationRate,
+				SystolicPressure:   v.SystolicPressure,
+				OxygenSaturation:   v.OxygenSaturation,
+				SupplementalOxygen: v.SupplementalOxygen,
+				Consciousness:      v.Consciousness,
+			}.NEWS2Inputs())
+			if err := tx.Exec(`
+			INSERT INTO VITAL_SIGN (PATIENT_ID, ISSUE_TIME, BODY_TEMPERATURE, PULSE_RATE, RESPIRATION_RATE, SYSTOLIC_PRESSURE, DIASTOLIC_PRESSURE,
+				OXYGEN_SATURATION, SUPPLEMENTAL_OXYGEN, CONSCIOUSNESS, NEWS2_SCORE, NEWS2_RANK)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+				v.PatientID, v.IssueTime, v.BodyTemperature, v.PulseRate, v.RespirationRate, v.SystolicPressure, v.DiastolicPressure,
+				v.OxygenSaturation, v.SupplementalOxygen, v.Consciousness, score.Total, score.Rank()).Error; err != nil {
+				return err
+			}
+		}
+		// orders have no natural key, a patient is given each name only once
+		for _, m := range f.Medications {
+			exists, err := seededFor(tx, "MEDICATION_ORDER", m.PatientID, "NAME", m.Name)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			status := m.Status
+			if status == "" {
+				status = model.MedicationActive
+			}
+			if err := tx.Exec(`
+			INSERT INTO MEDICATION_ORDER (PATIENT_ID, NAME, DOSE, UNIT, ROUTE, FREQUENCY, PRESCRIBING_DOCTOR_ID, STATUS)
+			VALUES (?, ?, ?, ?, ?, ?, (SELECT DOCTOR_ID FROM PATIENT WHERE PATIENT_ID = ?), ?)`,
+				m.PatientID, m.Name, m.Dose, m.Unit, m.Route, m.Frequency, m.PatientID, status).Error; err != nil {
+				return err
+			}
+		}
+		// diagnoses have no natural key either, each description is seeded once
+		for _, d := range f.Diseases {
+			name := d.Name
+			if d.ICD10Code != nil {
+				code, ok := icd10.Lookup(*d.ICD10Code)
+				if !ok {
+					return fmt.Errorf("disease of patient %d: unknown ICD-10 code %q", d.PatientID, *d.ICD10Code)
+				}
+				d.ICD10Code = &code.Code
+				if name == "" {
+					name = code.Description
+				}
+			}
+			var onset *model.Date
+			if d.OnsetDate != nil {
+				parsed, err := model.ParseDate(*d.OnsetDate)
+				if err != nil {
+					return fmt.Errorf("disease of patient %d: onset_date: %w", d.PatientID, err)
+				}
+				onset = &parsed
+			}
+			exists, err := seededFor(tx, "PATIENT_DIAGNOSIS", d.PatientID, "DESCRIPTION", name)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			if err := tx.Exec(`
+			INSERT INTO PATIENT_DIAGNOSIS (PATIENT_ID, ICD10_CODE, DESCRIPTION, ONSET_DATE, IS_PRIMARY)
+			VALUES (?, ?, ?, ?, ?)`,
+				d.PatientID, d.ICD10Code, name, onset, d.IsPrimary).Error; err != nil {
+				return err
+			}
+		}
+		for _, u := range f.Users {
+			hash, err := auth.HashPassword(u.Password)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(`
+			INSERT INTO APP_USER (USERNAME, PASSWORD_HASH, ROLE, DOCTOR_ID, NURSE_ID, PATIENT_ID)
+			VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+				u.Username, hash, u.Role, u.DoctorID, u.NurseID, u.PatientID).Error; err != nil {
+				return err
+			}
+		}
+		return backfillAssignmentHistory(tx)
+	})
+}
+
+// seededFor reports whether the patient already has a row in table whose
+// column equals value.
+func seededFor(tx *gorm.DB, table string, pid int, column, value string) (bool, error) {
+	var count int64
+	err := tx.Raw(fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE PATIENT_ID = ? AND %s = ?`, table, column), pid, value).Scan(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"health-care-backend/news2"
+	"health-care-backend/policy"
+	model "health-care-backend/repository/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seededSQLite returns a fresh in-memory SQLite database with the demo data.
+func seededSQLite(t *testing.T) *GormDatabase {
+	t.Helper()
+	db, err := NewGormDatabase(sqliteScheme+":memory:", false)
+	require.NoError(t, err)
+	require.Equal(t, SQLite, db.Dialect())
+	require.NoError(t, db.Migrate())
+	fixtures, err := DemoFixtures()
+	require.NoError(t, err)
+	require.NoError(t, Seed(db, fixtures))
+	return db
+}
+
+func Test_SQLiteMigrationsFollowPostgres(t *testing.T) {
+	versions := map[int]string{}
+	for _, m := range migrations {
+		versions[m.Version] = m.Name
+	}
+	for _, m := range sqliteMigrations[1:] {
+		assert.Equal(t, versions[m.Version], m.Name, "version %d", m.Version)
+	}
+	assert.Equal(t, migrations[len(migrations)-1].Version, sqliteMigrations[len(sqliteMigrations)-1].Version)
+}
+
+func Test_SQLiteMigrate(t *testing.T) {
+	db := seededSQLite(t)
+	pending, err := db.PendingMigrations()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	assert.Equal(t, sqliteMigrations[len(sqliteMigrations)-1].Version, db.SchemaVersion())
+	// migrating and seeding again are no-ops
+	assert.NoError(t, db.Migrate())
+	fixtures, _ := DemoFixtures()
+	assert.NoError(t, Seed(db, fixtures))
+
+	assert.NoError(t, db.Rollback(1))
+	pending, err = db.PendingMigrations()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.NoError(t, db.Migrate())
+}
+
+func Test_SQLiteDashboard(t *testing.T) {
+	db := seededSQLite(t)
+	repo := NewDashboardRepo(db)
+	admin := policy.Principal{Role: policy.Admin}
+
+	views, err := repo.SelectPatientDashboard(context.Background(), admin, 1)
+	require.NoError(t, err)
+	if assert.Len(t, views, 1) {
+		v := views[0]
+		assert.Equal(t, "Doe", v.AssignedDoctorLastName)
+		// the age follows the date of birth instead of a stored value
+		assert.Equal(t, model.Patient{DOB: time.Date(1988, 3, 12, 0, 0, 0, 0, time.UTC)}.AgeAt(time.Now()), v.Age)
+		assert.Equal(t, time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC), v.VitalsRecordedAt.UTC())
+		assert.Equal(t, false, *v.SupplementalOxygen)
+		if assert.Len(t, v.CurrentPrescribedMeds, 2) {
+			assert.Equal(t, "Antibiotic", v.CurrentPrescribedMeds[0].Name)
+			assert.Equal(t, 500.0, *v.CurrentPrescribedMeds[0].Dose)
+		}
+		if assert.Len(t, v.CurrentDiseases, 1) {
+			assert.True(t, v.CurrentDiseases[0].IsPrimary)
+			assert.Equal(t, "2019-06-01", v.CurrentDiseases[0].OnsetDate.String())
+		}
+	}
+
+	doctor := policy.Principal{Role: policy.Doctor, DoctorID: 1}
+	var ids []int
+	q := DashboardQuery{Sort: SortByRisk, Descending: true, Limit: 2}
+	for {
+		page, err := repo.SelectDoctorDashboard(context.Background(), doctor, 1, q)
+		require.NoError(t, err)
+		for _, v := range page {
+			ids = append(ids, v.PatientID)
+		}
+		if len(page) < q.Limit {
+			break
+		}
+		last := page[len(page)-1]
+		q.After = &DashboardCursor{Value: *last.NEWS2Rank, PatientID: last.PatientID}
+	}
+	assert.Equal(t, []int{3, 1, 4}, ids)
+
+	page, err := repo.SelectDoctorDashboard(context.Background(), doctor, 1, DashboardQuery{Disease: "j45"})
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, 3, page[0].PatientID)
+	}
+	// patients 3 and 4 share a date of birth
+	page, err = repo.SelectDoctorDashboard(context.Background(), doctor, 1, DashboardQuery{Medication: "antihist", MinAge: intPtr(30)})
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, 3, page[0].PatientID)
+		assert.Equal(t, 4, page[1].PatientID)
+	}
+	page, err = repo.SelectDoctorDashboard(context.Background(), doctor, 1, DashboardQuery{Medication: "antihist", MaxAge: intPtr(29)})
+	assert.NoError(t, err)
+	assert.Empty(t, page)
+
+	nurseViews, err := repo.SelectNurseDashboard(context.Background(), policy.Principal{Role: policy.Nurse, NurseID: 1}, 1, DashboardQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, nurseViews, 2)
+}
+
+func Test_SQLiteWrites(t *testing.T) {
+	db := seededSQLite(t)
+
+	patients := NewPatientRepo(db)
+	inserted, err := patients.InsertPatient(context.Background(), model.Patient{FirstName: "Dana", LastName: "Lee", Sex: "F",
+		BloodType: "AB+", DOB: time.Date(1973, 1, 2, 0, 0, 0, 0, time.UTC), DoctorID: 2, PhoneNumber: "555", Address: "1 Elm St"})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, inserted.PatientID)
+	_, err = patients.InsertPatient(context.Background(), model.Patient{FirstName: "Eli", LastName: "Ng", Sex: "M",
+		BloodType: "O+", DoctorID: 99, PhoneNumber: "555", Address: "1 Elm St"})
+	assert.ErrorIs(t, err, ErrInvalidReference)
+
+	_, err = NewUserRepo(db).InsertUser(context.Background(), model.User{Username: "ADMIN", PasswordHash: "x", Role: model.RoleAdmin})
+	assert.ErrorIs(t, err, ErrConflict)
+
+	// a reading above the pulse rule raises one alert
+	readings, err := NewVitalSignRepo(db).InsertVitalSigns(context.Background(), 1, []model.VitalSign{{IssueTime: time.Date(2023, 5, 2, 8, 0, 0, 0, time.UTC),
+		BodyTemperature: 98.6, PulseRate: 140, RespirationRate: 16, SystolicPressure: 120, DiastolicPressure: 80}})
+	assert.NoError(t, err)
+	if assert.Len(t, readings, 1) {
+		assert.Equal(t, 3, readings[0].NEWS2Score)
+	}
+	alerts, err := NewAlertRepo(db).ListAlerts(context.Background(), policy.Principal{Role: policy.Admin}, AlertFilter{PatientID: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, "pulse_rate", alerts[0].Parameter)
+	}
+
+	audit := NewAuditRepo(db)
+	entry := model.AuditEntry{OccurredAt: time.Now(), UserID: 1, Username: "admin", Role: "admin",
+		Action: "GET /api/patients/1", Status: 200, PatientIDs: []int{1}}
+	for i := 0; i < 2; i++ {
+		chained, err := audit.AppendAuditEntries(context.Background(), []model.AuditEntry{entry, entry})
+		assert.NoError(t, err)
+		if assert.Len(t, chained, 2) {
+			assert.Equal(t, int64(2*i+2), chained[1].EntryID)
+			assert.Equal(t, chained[0].Hash, chained[1].PrevHash)
+		}
+	}
+	verification, err := audit.VerifyAuditChain(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, verification.Valid)
+	assert.Equal(t, int64(4), verification.Entries)
+	assert.Error(t, db.DB.Exec(`UPDATE AUDIT_LOG SET STATUS = 500`).Error)
+	assert.Error(t, db.DB.Exec(`DELETE FROM AUDIT_LOG_PATIENT`).Error)
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func Test_SQLiteQueryTimeout(t *testing.T) {
+	db := seededSQLite(t)
+	patients := NewPatientRepo(db)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	_, err := patients.SelectPatient(expired, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = patients.DischargePatient(expired, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	db.QueryTimeout = time.Nanosecond
+	_, err = patients.ListPatients(context.Background(), policy.Principal{Role: policy.Admin}, false)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	db.QueryTimeout = time.Minute
+	p, err := patients.SelectPatient(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Nil(t, p.DischargedAt)
+}
+
+func Test_SQLiteKeepsOneConnection(t *testing.T) {
+	db := seededSQLite(t)
+	assert.NoError(t, db.ConfigurePool(PoolConfig{MaxOpenConns: 10, MaxIdleConns: 5, ConnMaxLifetime: time.Nanosecond}))
+	sqlDB, err := db.DB.DB()
+	require.NoError(t, err)
+	assert.Equal(t, 1, sqlDB.Stats().MaxOpenConnections)
+	// the in-memory database outlives the configured lifetime
+	time.Sleep(time.Millisecond)
+	_, err = NewPatientRepo(db).SelectPatient(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+}
+
+func Test_SQLiteNextGrantExpiry(t *testing.T) {
+	db := seededSQLite(t)
+	access := NewAccessRepo(db)
+	// jane.smith, doctor 2
+	doctor := policy.Principal{Role: policy.Doctor, DoctorID: 2, UserID: 3}
+	next, err := access.NextGrantExpiry(context.Background(), doctor)
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+
+	grants := NewEmergencyGrantRepo(db)
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, expires := range []time.Time{now.Add(2 * time.Hour), now.Add(time.Hour), now.Add(-time.Hour)} {
+		_, err := grants.InsertEmergencyGrant(context.Background(), model.EmergencyGrant{UserID: 3, PatientID: 1,
+			Reason: "unresponsive", GrantedAt: now.Add(-2 * time.Hour), ExpiresAt: expires})
+		require.NoError(t, err)
+	}
+	next, err = access.NextGrantExpiry(context.Background(), doctor)
+	assert.NoError(t, err)
+	if assert.NotNil(t, next) {
+		assert.Equal(t, now.Add(time.Hour), next.UTC())
+	}
+}
+
+// news2Readings vary one parameter at a time across the NEWS2 bands, and
+// combine parameters to reach every risk band, so that the SQL backfills can
+// be compared with package news2.
+func news2Readings() []model.VitalSign {
+	base := func() model.VitalSign {
+		return model.VitalSign{BodyTemperature: 98.6, PulseRate: 70, RespirationRate: 16, SystolicPressure: 120, DiastolicPressure: 60}
+	}
+	var readings []model.VitalSign
+	add := func(change func(v *model.VitalSign)) {
+		v := base()
+		change(&v)
+		readings = append(readings, v)
+	}
+	for _, rr := range []int{8, 9, 11, 12, 20, 21, 24, 25} {
+		rr := rr
+		add(func(v *model.VitalSign) { v.RespirationRate = rr })
+	}
+	for _, spo2 := range []int{91, 92, 93, 94, 95, 96} {
+		spo2 := spo2
+		add(func(v *model.VitalSign) { v.OxygenSaturation = &spo2 })
+	}
+	for _, o2 := range []bool{false, true} {
+		o2 := o2
+		add(func(v *model.VitalSign) { v.SupplementalOxygen = &o2 })
+	}
+	for _, sbp := range []int{90, 91, 100, 101, 110, 111, 219, 220} {
+		sbp := sbp
+		add(func(v *model.VitalSign) { v.SystolicPressure = sbp })
+	}
+	for _, hr := range []int{40, 41, 50, 51, 90, 91, 110, 111, 130, 131} {
+		hr := hr
+		add(func(v *model.VitalSign) { v.PulseRate = hr })
+	}
+	for _, acvpu := range []string{news2.Alert, news2.NewConfusion, news2.Unresponsive} {
+		acvpu := acvpu
+		add(func(v *model.VitalSign) { v.Consciousness = &acvpu })
+	}
+	// 35.0, 35.1, 36.0, 36.1, 38.0, 38.1, 39.0 and 39.1 °C
+	for _, f := range []float64{95.0, 95.2, 96.8, 97.0, 100.4, 100.6, 102.2, 102.4} {
+		f := f
+		add(func(v *model.VitalSign) { v.BodyTemperature = f })
+	}
+	// totals of 4 to 7 without a single 3, and a 3 with a total of 5
+	add(func(v *model.VitalSign) { v.RespirationRate, v.PulseRate = 22, 120 })
+	add(func(v *model.VitalSign) { v.RespirationRate, v.PulseRate, v.BodyTemperature = 22, 120, 96.8 })
+	add(func(v *model.VitalSign) { v.RespirationRate, v.PulseRate, v.SystolicPressure = 22, 120, 100 })
+	add(func(v *model.VitalSign) {
+		v.RespirationRate, v.PulseRate, v.SystolicPressure, v.BodyTemperature = 22, 120, 100, 96.8
+	})
+	add(func(v *model.VitalSign) { v.RespirationRate, v.PulseRate = 22, 140 })
+	return readings
+}
+
+// migrationStatement returns the statement of the migration's Up that starts
+// with prefix.
+func migrationStatement(t *testing.T, list []Migration, version int, prefix string) string {
+	t.Helper()
+	for _, m := range list {
+		if m.Version == version {
+			start := strings.Index(m.Up, prefix)
+			require.GreaterOrEqual(t, start, 0, "version %d has no %q", version, prefix)
+			return m.Up[start : start+strings.Index(m.Up[start:], ";")+1]
+		}
+	}
+	t.Fatalf("no version %d", version)
+	return ""
+}
+
+func Test_SQLiteNEWS2Backfills(t *testing.T) {
+	db := seededSQLite(t)
+	readings := news2Readings()
+	for i := range readings {
+		readings[i].IssueTime = time.Date(2023, 6, 1, 0, i, 0, 0, time.UTC)
+	}
+	_, err := NewVitalSignRepo(db).InsertVitalSigns(context.Background(), 1, readings)
+	require.NoError(t, err)
+
+	// the postgres statements only differ by their casts
+	sqlite := strings.NewReplacer("::NUMERIC", "")
+	backfills := []struct {
+		name, column, sql string
+		want              func(news2.Score) int
+	}{
+		{"postgres version 15", "NEWS2_SCORE", sqlite.Replace(migrationStatement(t, migrations, 15, "UPDATE VITAL_SIGN SET NEWS2_SCORE")),
+			func(s news2.Score) int { return s.Total }},
+		{"postgres version 20", "NEWS2_RANK", sqlite.Replace(migrationStatement(t, migrations, 20, "UPDATE VITAL_SIGN SET NEWS2_RANK")),
+			news2.Score.Rank},
+		{"sqlite version 20", "NEWS2_RANK", migrationStatement(t, sqliteMigrations, 20, "UPDATE vital_sign SET news2_rank"),
+			news2.Score.Rank},
+	}
+	for _, backfill := range backfills {
+		require.NoError(t, db.DB.Exec(`UPDATE VITAL_SIGN SET `+backfill.column+` = -1`).Error)
+		require.NoError(t, db.DB.Exec(backfill.sql).Error, backfill.name)
+		var stored []model.VitalSign
+		require.NoError(t, db.DB.Raw(`SELECT * FROM VITAL_SIGN`).Scan(&stored).Error)
+		require.Len(t, stored, len(readings)+4)
+		for _, v := range stored {
+			got := v.NEWS2Score
+			if backfill.column == "NEWS2_RANK" {
+				got = v.NEWS2Rank
+			}
+			assert.Equal(t, backfill.want(news2.Compute(v.NEWS2Inputs())), got, "%s: reading of %s", backfill.name, v.IssueTime)
+		}
+	}
+}
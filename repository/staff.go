@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	model "health-care-backend/repository/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StaffKind selects the staff table a Staff method works on.
+type StaffKind string
+
+const (
+	DoctorStaff StaffKind = "doctor"
+	NurseStaff  StaffKind = "nurse"
+)
+
+type Staff interface {
+	ListStaff(ctx context.Context, kind StaffKind, includeInactive bool) ([]model.StaffMember, error)
+	SelectStaff(ctx context.Context, kind StaffKind, id int) (model.StaffMember, error)
+	InsertStaff(ctx context.Context, kind StaffKind, m model.StaffMember) (model.StaffMember, error)
+	UpdateStaff(ctx context.Context, kind StaffKind, m model.StaffMember) (model.StaffMember, error)
+	DeactivateStaff(ctx context.Context, kind StaffKind, id int) (model.StaffMember, error)
+	ReactivateStaff(ctx context.Context, kind StaffKind, id int) (model.StaffMember, error)
+}
+
+type staffRepo struct {
+	db *GormDatabase
+}
+
+func NewStaffRepo(db *GormDatabase) Staff {
+	return &staffRepo{db: db}
+}
+
+// staffColumns selects a DOCTOR or NURSE row in the shape of model.StaffMember.
+func staffColumns(kind StaffKind) string {
+	return fmt.Sprintf(`%s_ID AS id, FIRST_NAME, LAST_NAME, ACTIVE, DEACTIVATED_AT`, kind)
+}
+
+func (r *staffRepo) ListStaff(ctx context.Context, kind StaffKind, includeInactive bool) ([]model.StaffMember, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.StaffMember
+	if err := db.Raw(fmt.Sprintf(`
+	SELECT %s FROM %s
+	WHERE ? OR ACTIVE
+	ORDER BY %s_ID`, staffColumns(kind), kind, kind), includeInactive).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *staffRepo) SelectStaff(ctx context.Context, kind StaffKind, id int) (model.StaffMember, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.StaffMember
+	if err := db.Raw(fmt.Sprintf(`SELECT %s FROM %s WHERE %s_ID = ?`, staffColumns(kind), kind, kind), id).Scan(&records).Error; err != nil {
+		return model.StaffMember{}, err
+	}
+	if len(records) == 0 {
+		return model.StaffMember{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// InsertStaff creates an active staff member. A zero ID lets the database
+// assign the next id; after an explicit one the id sequence is moved past it,
+// like Seed does, so that later inserts without an id do not collide.
+func (r *staffRepo) InsertStaff(ctx context.Context, kind StaffKind, m model.StaffMember) (model.StaffMember, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.StaffMember
+	if m.ID == 0 {
+		if err := db.Raw(fmt.Sprintf(`
+		INSERT INTO %s (FIRST_NAME, LAST_NAME) VALUES (?, ?)
+		RETURNING %s`, kind, staffColumns(kind)), m.FirstName, m.LastName).Scan(&records).Error; err != nil {
+			return model.StaffMember{}, translateError(err)
+		}
+		return records[0], nil
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(fmt.Sprintf(`
+		INSERT INTO %s (%s_ID, FIRST_NAME, LAST_NAME) VALUES (?, ?, ?)
+		RETURNING %s`, kind, kind, staffColumns(kind)), m.ID, m.FirstName, m.LastName).Scan(&records).Error; err != nil {
+			return translateError(err)
+		}
+		// SQLite continues after the highest id by itself
+		if dialectOf(tx) != Postgres {
+			return nil
+		}
+		return tx.Exec(fmt.Sprintf(`SELECT setval('%s_%s_id_seq', (SELECT MAX(%s_ID) FROM %s))`, kind, kind, kind, kind)).Error
+	})
+	if err != nil {
+		return model.StaffMember{}, err
+	}
+	return records[0], nil
+}
+
+func (r *staffRepo) UpdateStaff(ctx context.Context, kind StaffKind, m model.StaffMember) (model.StaffMember, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.StaffMember
+	if err := db.Raw(fmt.Sprintf(`
+	UPDATE %s SET FIRST_NAME = ?, LAST_NAME = ?
+	WHERE %s_ID = ?
+	RETURNING %s`, kind, kind, staffColumns(kind)), m.FirstName, m.LastName, m.ID).Scan(&records).Error; err != nil {
+		return model.StaffMember{}, err
+	}
+	if len(records) == 0 {
+		return model.StaffMember{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// DeactivateStaff takes a staff member out of service. Doctors still
+// attending admitted patients cannot be deactivated and yield ErrInUse; the
+// patients must be reassigned first. Nurses leave the care teams of their
+// patients, their assignment history is kept. The staff row stays locked
+// until the change commits, so no assignment can slip in meanwhile.
+func (r *staffRepo) DeactivateStaff(ctx context.Context, kind StaffKind, id int) (model.StaffMember, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var member model.StaffMember
+	err := db.Transaction(func(tx *gorm.DB) error {
+		active, err := lockStaff(tx, kind, id)
+		if err != nil {
+			return err
+		}
+		if !active {
+			return ErrConflict
+		}
+		switch kind {
+		case DoctorStaff:
+			var attending int64
+			if err := tx.Raw(`
+			SELECT COUNT(*) FROM PATIENT
+			WHERE DOCTOR_ID = ? AND DISCHARGED_AT IS NULL`, id).Scan(&attending).Error; err != nil {
+				return err
+			}
+			if attending > 0 {
+				return ErrInUse
+			}
+		case NurseStaff:
+			if err := tx.Exec(`DELETE FROM PATIENT_NURSE WHERE NURSE_ID = ?`, id).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec(`
+			UPDATE ASSIGNMENT_HISTORY SET EFFECTIVE_TO = ?
+			WHERE STAFF_KIND = ? AND STAFF_ID = ? AND EFFECTIVE_TO IS NULL`, time.Now().UTC(), NurseStaff, id).Error; err != nil {
+				return err
+			}
+		}
+		member, err = setActive(tx, kind, id, false)
+		return err
+	})
+	return member, err
+}
+
+// ReactivateStaff puts a staff member back into service. Nurses are not
+// reassigned to their former patients.
+func (r *staffRepo) ReactivateStaff(ctx context.Context, kind StaffKind, id int) (model.StaffMember, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var member model.StaffMember
+	err := db.Transaction(func(tx *gorm.DB) error {
+		active, err := lockStaff(tx, kind, id)
+		if err != nil {
+			return err
+		}
+		if active {
+			return ErrConflict
+		}
+		member, err = setActive(tx, kind, id, true)
+		return err
+	})
+	return member, err
+}
+
+// lockStaff locks the staff row for the rest of the transaction and reports
+// whether the staff member is active. Unknown staff yield ErrNotFound.
+func lockStaff(tx *gorm.DB, kind StaffKind, id int) (bool, error) {
+	var active []bool
+	if err := tx.Raw(forUpdate(tx, fmt.Sprintf(`SELECT ACTIVE FROM %s WHERE %s_ID = ?`, kind, kind)), id).Scan(&active).Error; err != nil {
+		return false, err
+	}
+	if len(active) == 0 {
+		return false, ErrNotFound
+	}
+	return active[0], nil
+}
+
+// setActive sets the ACTIVE flag and stamps or clears DEACTIVATED_AT.
+func setActive(tx *gorm.DB, kind StaffKind, id int, active bool) (model.StaffMember, error) {
+	var deactivatedAt *time.Time
+	if !active {
+		now := time.Now().UTC()
+		deactivatedAt = &now
+	}
+	var records []model.StaffMember
+	if err := tx.Raw(fmt.Sprintf(`
+	UPDATE %s SET ACTIVE = ?, DEACTIVATED_AT = ?
+	WHERE %s_ID = ?
+	RETURNING %s`, kind, kind, staffColumns(kind)), active, deactivatedAt, id).Scan(&records).Error; err != nil {
+		return model.StaffMember{}, err
+	}
+	return records[0], nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"health-care-backend/policy"
+	model "health-care-backend/repository/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DeactivateStaff(t *testing.T) {
+	db := seededSQLite(t)
+	staff := NewStaffRepo(db)
+	ctx := context.Background()
+
+	// doctor 1 attends patients 1, 3 and 4, doctor 3 nobody
+	_, err := staff.DeactivateStaff(ctx, DoctorStaff, 1)
+	assert.ErrorIs(t, err, ErrInUse)
+	doctor, err := staff.SelectStaff(ctx, DoctorStaff, 1)
+	assert.NoError(t, err)
+	assert.True(t, doctor.Active)
+	doctor, err = staff.DeactivateStaff(ctx, DoctorStaff, 3)
+	assert.NoError(t, err)
+	assert.False(t, doctor.Active)
+	assert.NotNil(t, doctor.DeactivatedAt)
+	_, err = staff.DeactivateStaff(ctx, DoctorStaff, 3)
+	assert.ErrorIs(t, err, ErrConflict)
+	_, err = staff.DeactivateStaff(ctx, DoctorStaff, 99)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// nurse 1 cares for patients 1 and 4
+	nurse := policy.Principal{UserID: 4, Role: policy.Nurse, NurseID: 1}
+	_, err = staff.DeactivateStaff(ctx, NurseStaff, 1)
+	require.NoError(t, err)
+	ok, err := NewAccessRepo(db).CanAccessPatient(ctx, nurse, 1)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assignments := NewAssignmentRepo(db)
+	for _, pid := range []int{1, 4} {
+		history, err := assignments.ListAssignmentHistory(ctx, pid, false)
+		assert.NoError(t, err)
+		var nurses int
+		for _, a := range history {
+			if a.StaffKind == string(NurseStaff) && a.StaffID == 1 {
+				nurses++
+				assert.NotNil(t, a.EffectiveTo, "patient %d", pid)
+			}
+		}
+		assert.Equal(t, 1, nurses, "patient %d", pid)
+	}
+	user, err := NewUserRepo(db).SelectUserByUsername(ctx, "emily.wilson")
+	assert.NoError(t, err)
+	assert.False(t, user.Active)
+	_, err = assignments.AssignNurse(ctx, 2, 1)
+	assert.ErrorIs(t, err, ErrInvalidReference)
+
+	member, err := staff.ReactivateStaff(ctx, NurseStaff, 1)
+	assert.NoError(t, err)
+	assert.True(t, member.Active)
+	assert.Nil(t, member.DeactivatedAt)
+	_, err = staff.ReactivateStaff(ctx, NurseStaff, 1)
+	assert.ErrorIs(t, err, ErrConflict)
+	user, _ = NewUserRepo(db).SelectUser(ctx, user.UserID)
+	assert.True(t, user.Active)
+	// former patients are not handed back
+	ok, _ = NewAccessRepo(db).CanAccessPatient(ctx, nurse, 1)
+	assert.False(t, ok)
+}
+
+func Test_InsertStaffWithID(t *testing.T) {
+	db := seededSQLite(t)
+	staff := NewStaffRepo(db)
+	ctx := context.Background()
+
+	for _, kind := range []StaffKind{DoctorStaff, NurseStaff} {
+		member, err := staff.InsertStaff(ctx, kind, model.StaffMember{ID: 50, FirstName: "Gregory", LastName: "House"})
+		require.NoError(t, err)
+		assert.Equal(t, 50, member.ID)
+		member, err = staff.InsertStaff(ctx, kind, model.StaffMember{FirstName: "Lisa", LastName: "Cuddy"})
+		require.NoError(t, err, "%s", kind)
+		assert.Equal(t, 51, member.ID, "%s", kind)
+		_, err = staff.InsertStaff(ctx, kind, model.StaffMember{ID: 50, FirstName: "James", LastName: "Wilson"})
+		assert.ErrorIs(t, err, ErrConflict, "%s", kind)
+	}
+}
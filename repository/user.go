@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	model "health-care-backend/repository/model"
+)
+
+type Users interface {
+	SelectUser(ctx context.Context, uid int) (model.User, error)
+	SelectUserByUsername(ctx context.Context, username string) (model.User, error)
+	InsertUser(ctx context.Context, u model.User) (model.User, error)
+}
+
+type userRepo struct {
+	db *GormDatabase
+}
+
+func NewUserRepo(db *GormDatabase) Users {
+	return &userRepo{db: db}
+}
+
+// selectUsers reports the accounts of inactive doctors and nurses as
+// inactive, so deactivating a staff member locks them out.
+const selectUsers = `
+	SELECT
+		u.user_id,
+		u.username,
+		u.password_hash,
+		u.role,
+		u.doctor_id,
+		u.nurse_id,
+		u.patient_id,
+		u.active AND COALESCE(doc.active, n.active, TRUE) AS active,
+		u.created_at
+	FROM app_user AS u
+	LEFT JOIN doctor AS doc ON doc.doctor_id = u.doctor_id
+	LEFT JOIN nurse AS n ON n.nurse_id = u.nurse_id`
+
+func (r *userRepo) SelectUser(ctx context.Context, uid int) (model.User, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.User
+	if err := db.Raw(selectUsers+` WHERE u.user_id = ?`, uid).Scan(&records).Error; err != nil {
+		return model.User{}, err
+	}
+	if len(records) == 0 {
+		return model.User{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// SelectUserByUsername matches the username case-insensitively.
+func (r *userRepo) SelectUserByUsername(ctx context.Context, username string) (model.User, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.User
+	if err := db.Raw(selectUsers+` WHERE LOWER(u.username) = LOWER(?)`, username).Scan(&records).Error; err != nil {
+		return model.User{}, err
+	}
+	if len(records) == 0 {
+		return model.User{}, ErrNotFound
+	}
+	return records[0], nil
+}
+
+// InsertUser creates an active user. A taken username yields ErrConflict and
+// an unknown doctor, nurse or patient ErrInvalidReference.
+func (r *userRepo) InsertUser(ctx context.Context, u model.User) (model.User, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	var records []model.User
+	if err := db.Raw(`
+	INSERT INTO APP_USER (USERNAME, PASSWORD_HASH, ROLE, DOCTOR_ID, NURSE_ID, PATIENT_ID)
+	VALUES (?, ?, ?, ?, ?, ?)
+	RETURNING *`,
+		u.Username, u.PasswordHash, u.Role, u.DoctorID, u.NurseID, u.PatientID).Scan(&records).Error; err != nil {
+		return model.User{}, translateError(err)
+	}
+	return records[0], nil
+}
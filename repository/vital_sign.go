@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"health-care-backend/news2"
+	model "health-care-backend/repository/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type VitalSigns interface {
+	InsertVitalSigns(ctx context.Context, pid int, readings []model.VitalSign) ([]model.VitalSign, error)
+	SelectVitalSigns(ctx context.Context, pid int, from, to *time.Time, limit int) ([]model.VitalSign, error)
+}
+
+type vitalSignRepo struct {
+	db *GormDatabase
+}
+
+func NewVitalSignRepo(db *GormDatabase) VitalSigns {
+	return &vitalSignRepo{db: db}
+}
+
+// InsertVitalSigns records all readings or none. Readings can only be added
+// for admitted patients; a second reading with the same issue time yields
+// ErrConflict. The readings are checked against the alert rules and
+// the alerts they raise are stored with them.
+func (r *vitalSignRepo) InsertVitalSigns(ctx context.Context, pid int, readings []model.VitalSign) ([]model.VitalSign, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	records := make([]model.VitalSign, 0, len(readings))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockAdmittedPatient(tx, pid); err != nil {
+			return err
+		}
+		for _, v := range readings {
+			var inserted []model.VitalSign
+			score := news2.Compute(v.NEWS2Inputs())
+			if err := tx.Raw(`
+			INSERT INTO VITAL_SIGN (PATIENT_ID, ISSUE_TIME, BODY_TEMPERATURE, PULSE_RATE, RESPIRATION_RATE, SYSTOLIC_PRESSURE, DIASTOLIC_PRESSURE,
+				OXYGEN_SATURATION, SUPPLEMENTAL_OXYGEN, CONSCIOUSNESS, NEWS2_SCORE, NEWS2_RANK)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			RETURNING *`,
+				pid, v.IssueTime, v.BodyTemperature, v.PulseRate, v.RespirationRate, v.SystolicPressure, v.DiastolicPressure,
+				v.OxygenSaturation, v.SupplementalOxygen, v.Consciousness, score.Total, score.Rank()).Scan(&inserted).Error; err != nil {
+				return translateError(err)
+			}
+			records = append(records, inserted...)
+		}
+		return raiseAlerts(tx, pid, records)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// SelectVitalSigns returns the latest limit readings issued within
+// [from, to], oldest first. Nil bounds are open.
+func (r *vitalSignRepo) SelectVitalSigns(ctx context.Context, pid int, from, to *time.Time, limit int) ([]model.VitalSign, error) {
+	db, cancel := r.db.conn(ctx)
+	defer cancel()
+	if _, err := NewPatientRepo(r.db).SelectPatient(ctx, pid); err != nil {
+		return nil, err
+	}
+	query := `SELECT * FROM VITAL_SIGN WHERE PATIENT_ID = ?`
+	args := []interface{}{pid}
+	if from != nil {
+		query += ` AND ISSUE_TIME >= ?`
+		args = append(args, *from)
+	}
+	if to != nil {
+		query += ` AND ISSUE_TIME <= ?`
+		args = append(args, *to)
+	}
+	args = append(args, limit)
+
+	var records []model.VitalSign
+	if err := db.Raw(`
+	SELECT * FROM (`+query+`
+		ORDER BY ISSUE_TIME DESC
+		LIMIT ?) AS latest
+	ORDER BY ISSUE_TIME`, args...).Scan(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
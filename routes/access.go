@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"health-care-backend/auth"
+	"health-care-backend/policy"
+	repository "health-care-backend/repository"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalFrom returns the policy principal of the authenticated caller.
+// Without an identity it returns a principal that is granted nothing.
+func principalFrom(ctx *gin.Context) policy.Principal {
+	id, ok := auth.IdentityFrom(ctx)
+	if !ok {
+		return policy.Principal{}
+	}
+	p := policy.Principal{UserID: id.UserID, Role: policy.Role(id.Role)}
+	if id.DoctorID != nil {
+		p.DoctorID = *id.DoctorID
+	}
+	if id.NurseID != nil {
+		p.NurseID = *id.NurseID
+	}
+	if id.PatientID != nil {
+		p.PatientID = *id.PatientID
+	}
+	return p
+}
+
+// requirePermission rejects callers whose role lacks the permission.
+func requirePermission(perm policy.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !principalFrom(ctx).Can(perm) {
+			respondError(ctx, http.StatusForbidden, CodeForbidden, "missing permission "+string(perm))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// requirePatientAccess rejects callers that may not see the patient of the
+// :id route parameter. Malformed ids are left to the handler.
+func requirePatientAccess(repo repository.Access) gin.HandlerFunc {
+	return requirePatient(repo, checkPatientAccess)
+}
+
+// requirePatientManagement rejects callers that may not change the patient of
+// the :id route parameter. Emergency grants only let their holder read.
+func requirePatientManagement(repo repository.Access) gin.HandlerFunc {
+	return requirePatient(repo, checkPatientManagement)
+}
+
+func requirePatient(repo repository.Access, check func(*gin.Context, repository.Access, int) bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		pid, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			ctx.Next()
+			return
+		}
+		if !check(ctx, repo, pid) {
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// checkPatientAccess writes 403 and returns false unless the caller may see
+// the patient. Unknown patients are forbidden too, so that callers cannot
+// probe which ids exist.
+func checkPatientAccess(ctx *gin.Context, repo repository.Access, pid int) bool {
+	ok, err := repo.CanAccessPatient(ctx.Request.Context(), principalFrom(ctx), pid)
+	return allowPatient(ctx, ok, err, "not allowed to access this patient")
+}
+
+// checkPatientManagement is checkPatientAccess for changes to the patient.
+func checkPatientManagement(ctx *gin.Context, repo repository.Access, pid int) bool {
+	ok, err := repo.CanManagePatient(ctx.Request.Context(), principalFrom(ctx), pid)
+	return allowPatient(ctx, ok, err, "not allowed to change this patient")
+}
+
+func allowPatient(ctx *gin.Context, ok bool, err error, message string) bool {
+	if err != nil {
+		respondInternalError(ctx, err)
+		return false
+	}
+	if !ok {
+		respondError(ctx, http.StatusForbidden, CodeForbidden, message)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmergencyGrantIsReadOnly(t *testing.T) {
+	api := newTestAPI(t)
+	// patient 1 is attended by john.doe
+	assert.Equal(t, http.StatusForbidden, api.do(janeSmith, http.MethodGet, "/api/patients/1", nil, nil))
+	assert.Equal(t, http.StatusCreated, api.do(janeSmith, http.MethodPost, "/api/patients/1/emergency-access",
+		EmergencyAccessReq{Reason: "patient unresponsive in corridor"}, nil))
+
+	assert.Equal(t, http.StatusOK, api.do(janeSmith, http.MethodGet, "/api/patients/1", nil, nil))
+	assert.Equal(t, http.StatusOK, api.do(janeSmith, http.MethodGet, "/api/patients/1/assignments", nil, nil))
+	assert.Equal(t, http.StatusForbidden, api.do(janeSmith, http.MethodPut, "/api/patients/1/doctor",
+		ReassignDoctorReq{DoctorID: 2}, nil))
+	assert.Equal(t, http.StatusForbidden, api.do(janeSmith, http.MethodPatch, "/api/patients/1",
+		map[string]interface{}{"doctor_id": 2}, nil))
+	assert.Equal(t, http.StatusForbidden, api.do(janeSmith, http.MethodDelete, "/api/patients/1", nil, nil))
+
+	var patient PatientResp
+	assert.Equal(t, http.StatusOK, api.do(johnDoe, http.MethodGet, "/api/patients/1", nil, &patient))
+	assert.Equal(t, 1, patient.DoctorID)
+	assert.Equal(t, http.StatusOK, api.do(johnDoe, http.MethodPut, "/api/patients/1/doctor", ReassignDoctorReq{DoctorID: 2}, nil))
+}
@@ -0,0 +1,307 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"health-care-backend/alerting"
+	"health-care-backend/policy"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AlertHandler serves the alerts raised when vital signs are recorded, and
+// the rules that raise them.
+type AlertHandler struct {
+	logger *zap.Logger
+	repo   repository.Alerts
+	access repository.Access
+}
+
+func NewAlertHandler(logger *zap.Logger, repo repository.Alerts, access repository.Access) *AlertHandler {
+	return &AlertHandler{
+		logger: logger,
+		repo:   repo,
+		access: access,
+	}
+}
+
+type AlertResp struct {
+	AlertID        int        `json:"alert_id"`
+	RuleID         int        `json:"rule_id"`
+	PatientID      int        `json:"patient_id"`
+	IssueTime      time.Time  `json:"issue_time"`
+	Parameter      string     `json:"parameter"`
+	Value          float64    `json:"value"`
+	Severity       string     `json:"severity"`
+	Message        string     `json:"message"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
+	AcknowledgedBy *int       `json:"acknowledged_by"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	ResolvedBy     *int       `json:"resolved_by"`
+}
+
+type AlertRuleResp struct {
+	RuleID    int       `json:"rule_id"`
+	PatientID *int      `json:"patient_id"`
+	Parameter string    `json:"parameter"`
+	Condition string    `json:"condition"`
+	Threshold *float64  `json:"threshold"`
+	Readings  *int      `json:"readings"`
+	Severity  string    `json:"severity"`
+	Active    bool      `json:"active"`
+	CreatedBy *int      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AlertRuleReq is a new rule. Above and below rules need a Threshold, rising
+// and falling rules the number of Readings the trend must span.
+type AlertRuleReq struct {
+	Parameter string   `json:"parameter"`
+	Condition string   `json:"condition"`
+	Threshold *float64 `json:"threshold"`
+	Readings  *int     `json:"readings"`
+	Severity  string   `json:"severity"`
+}
+
+// ListAlerts returns the alerts of the caller's patients, newest first,
+// optionally filtered by status and patient_id.
+func (h *AlertHandler) ListAlerts(ctx *gin.Context) {
+	var f repository.AlertFilter
+	if value := ctx.Query("patient_id"); value != "" {
+		pid, err := strconv.Atoi(value)
+		if err != nil || pid <= 0 {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "patient_id must be a positive integer")
+			return
+		}
+		f.PatientID = pid
+	}
+	h.listAlerts(ctx, f)
+}
+
+// ListPatientAlerts returns the alerts of one patient, newest first.
+func (h *AlertHandler) ListPatientAlerts(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	h.listAlerts(ctx, repository.AlertFilter{PatientID: pid})
+}
+
+func (h *AlertHandler) listAlerts(ctx *gin.Context, f repository.AlertFilter) {
+	if f.Status = ctx.Query("status"); f.Status != "" && !validAlertStatus(f.Status) {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "status must be one of open, acknowledged or resolved")
+		return
+	}
+	alerts, err := h.repo.ListAlerts(ctx.Request.Context(), principalFrom(ctx), f)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	resp := make([]AlertResp, 0, len(alerts))
+	for _, a := range alerts {
+		resp = append(resp, toAlertResp(a))
+		auditPatients(ctx, a.PatientID)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"alerts": resp})
+}
+
+// AcknowledgeAlert records that the caller has seen an open alert.
+func (h *AlertHandler) AcknowledgeAlert(ctx *gin.Context) {
+	h.updateAlert(ctx, h.repo.AcknowledgeAlert)
+}
+
+// ResolveAlert closes an alert. Its rule may raise a new alert afterwards.
+func (h *AlertHandler) ResolveAlert(ctx *gin.Context) {
+	h.updateAlert(ctx, h.repo.ResolveAlert)
+}
+
+func (h *AlertHandler) updateAlert(ctx *gin.Context, update func(context.Context, policy.Principal, int, time.Time) (model.Alert, error)) {
+	aid, err := strconv.Atoi(ctx.Param("alert_id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "alert id must be an integer")
+		return
+	}
+	principal := principalFrom(ctx)
+	alert, err := update(ctx.Request.Context(), principal, aid, time.Now().UTC())
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	auditPatients(ctx, alert.PatientID)
+	h.logger.Info("alert "+alert.Status, zap.Int("alert id", aid), zap.Int("user id", principal.UserID))
+	ctx.JSON(http.StatusOK, toAlertResp(alert))
+}
+
+// ListGlobalRules returns the active rules that apply to every patient.
+func (h *AlertHandler) ListGlobalRules(ctx *gin.Context) {
+	h.listRules(ctx, 0)
+}
+
+// ListPatientRules returns the active rules of a patient together with the
+// global ones, which a patient rule of the same parameter and condition
+// replaces.
+func (h *AlertHandler) ListPatientRules(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	h.listRules(ctx, pid)
+}
+
+func (h *AlertHandler) listRules(ctx *gin.Context, pid int) {
+	rules, err := h.repo.ListAlertRules(ctx.Request.Context(), pid)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	resp := make([]AlertRuleResp, 0, len(rules))
+	for _, r := range rules {
+		resp = append(resp, toAlertRuleResp(r))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"rules": resp})
+}
+
+// CreateGlobalRule adds a rule that applies to every patient.
+func (h *AlertHandler) CreateGlobalRule(ctx *gin.Context) {
+	h.createRule(ctx, nil)
+}
+
+// CreatePatientRule adds a rule for a single patient.
+func (h *AlertHandler) CreatePatientRule(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	h.createRule(ctx, &pid)
+}
+
+func (h *AlertHandler) createRule(ctx *gin.Context, pid *int) {
+	var req AlertRuleReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	rule := req.toAlertRule()
+	if err := alerting.Validate(rule); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	principal := principalFrom(ctx)
+	rule.PatientID = pid
+	rule.CreatedBy = &principal.UserID
+	rule, err := h.repo.InsertAlertRule(ctx.Request.Context(), rule)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	h.logger.Info("alert rule created", zap.Int("rule id", rule.RuleID), zap.Int("user id", principal.UserID))
+	ctx.JSON(http.StatusCreated, toAlertRuleResp(rule))
+}
+
+// DeactivateRule stops a rule from raising alerts. Global rules need
+// WriteGlobalAlertRules, patient rules the right to change the patient.
+func (h *AlertHandler) DeactivateRule(ctx *gin.Context) {
+	rid, err := strconv.Atoi(ctx.Param("rule_id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "rule id must be an integer")
+		return
+	}
+	rule, err := h.repo.SelectAlertRule(ctx.Request.Context(), rid)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	principal := principalFrom(ctx)
+	if rule.PatientID == nil {
+		if !principal.Can(policy.WriteGlobalAlertRules) {
+			respondError(ctx, http.StatusForbidden, CodeForbidden, "missing permission "+string(policy.WriteGlobalAlertRules))
+			return
+		}
+	} else if !checkPatientManagement(ctx, h.access, *rule.PatientID) {
+		return
+	}
+	if rule, err = h.repo.DeactivateAlertRule(ctx.Request.Context(), rid); err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	if rule.PatientID != nil {
+		auditPatients(ctx, *rule.PatientID)
+	}
+	h.logger.Info("alert rule deactivated", zap.Int("rule id", rid), zap.Int("user id", principal.UserID))
+	ctx.JSON(http.StatusOK, toAlertRuleResp(rule))
+}
+
+func (h *AlertHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "not found")
+	case errors.Is(err, repository.ErrInvalidReference):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, "alert or rule is already closed")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+func validAlertStatus(status string) bool {
+	switch status {
+	case model.AlertOpen, model.AlertAcknowledged, model.AlertResolved:
+		return true
+	}
+	return false
+}
+
+func (req AlertRuleReq) toAlertRule() model.AlertRule {
+	return model.AlertRule{
+		Parameter: strings.TrimSpace(req.Parameter),
+		Condition: strings.TrimSpace(req.Condition),
+		Threshold: req.Threshold,
+		Readings:  req.Readings,
+		Severity:  strings.TrimSpace(req.Severity),
+		Active:    true,
+	}
+}
+
+func toAlertResp(a model.Alert) AlertResp {
+	return AlertResp{
+		AlertID:        a.AlertID,
+		RuleID:         a.RuleID,
+		PatientID:      a.PatientID,
+		IssueTime:      a.IssueTime,
+		Parameter:      a.Parameter,
+		Value:          a.Value,
+		Severity:       a.Severity,
+		Message:        a.Message,
+		Status:         a.Status,
+		CreatedAt:      a.CreatedAt,
+		AcknowledgedAt: a.AcknowledgedAt,
+		AcknowledgedBy: a.AcknowledgedBy,
+		ResolvedAt:     a.ResolvedAt,
+		ResolvedBy:     a.ResolvedBy,
+	}
+}
+
+func toAlertRuleResp(r model.AlertRule) AlertRuleResp {
+	return AlertRuleResp{
+		RuleID:    r.RuleID,
+		PatientID: r.PatientID,
+		Parameter: r.Parameter,
+		Condition: r.Condition,
+		Threshold: r.Threshold,
+		Readings:  r.Readings,
+		Severity:  r.Severity,
+		Active:    r.Active,
+		CreatedBy: r.CreatedBy,
+		CreatedAt: r.CreatedAt,
+	}
+}
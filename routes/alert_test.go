@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"context"
+	"health-care-backend/auth"
+	"health-care-backend/policy"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// stubAlerts holds a single open alert of patient 1 and its global rule.
+type stubAlerts struct {
+	repository.Alerts
+	alert model.Alert
+	rule  model.AlertRule
+}
+
+func (s *stubAlerts) AcknowledgeAlert(ctx context.Context, p policy.Principal, alertID int, now time.Time) (model.Alert, error) {
+	if alertID != s.alert.AlertID {
+		return model.Alert{}, repository.ErrNotFound
+	}
+	if s.alert.Status != model.AlertOpen {
+		return model.Alert{}, repository.ErrConflict
+	}
+	s.alert.Status = model.AlertAcknowledged
+	s.alert.AcknowledgedAt = &now
+	s.alert.AcknowledgedBy = &p.UserID
+	return s.alert, nil
+}
+
+func (s *stubAlerts) SelectAlertRule(ctx context.Context, ruleID int) (model.AlertRule, error) {
+	if ruleID != s.rule.RuleID {
+		return model.AlertRule{}, repository.ErrNotFound
+	}
+	return s.rule, nil
+}
+
+func (s *stubAlerts) DeactivateAlertRule(ctx context.Context, ruleID int) (model.AlertRule, error) {
+	s.rule.Active = false
+	return s.rule, nil
+}
+
+func Test_AlertHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &stubAlerts{
+		alert: model.Alert{AlertID: 5, RuleID: 1, PatientID: 1, Status: model.AlertOpen},
+		rule:  model.AlertRule{RuleID: 1, Active: true},
+	}
+	h := NewAlertHandler(zap.NewNop(), repo, nil)
+	doctor := auth.Identity{UserID: 2, Role: "doctor", DoctorID: intPtr(1)}
+	router := gin.New()
+	api := router.Group("/api", func(ctx *gin.Context) { auth.SetIdentity(ctx, doctor) })
+	api.POST("/alerts/:alert_id/acknowledge", h.AcknowledgeAlert)
+	api.DELETE("/alert-rules/:rule_id", h.DeactivateRule)
+
+	serve := func(method, path string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, serve(http.MethodPost, "/api/alerts/5/acknowledge"))
+	assert.Equal(t, 2, *repo.alert.AcknowledgedBy)
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/api/alerts/5/acknowledge"))
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodPost, "/api/alerts/6/acknowledge"))
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodPost, "/api/alerts/x/acknowledge"))
+
+	// only admins may change the global rules
+	assert.Equal(t, http.StatusForbidden, serve(http.MethodDelete, "/api/alert-rules/1"))
+	assert.True(t, repo.rule.Active)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/api/alert-rules/2"))
+}
+
+func Test_AlertRuleReq(t *testing.T) {
+	rule := AlertRuleReq{Parameter: " systolic_pressure ", Condition: "above", Threshold: floatPtr(200), Severity: "critical"}.toAlertRule()
+	assert.Equal(t, "systolic_pressure", rule.Parameter)
+	assert.True(t, rule.Active)
+	assert.True(t, validAlertStatus(model.AlertAcknowledged))
+	assert.False(t, validAlertStatus("closed"))
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"errors"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AssignmentHandler struct {
+	logger *zap.Logger
+	repo   repository.Assignments
+}
+
+func NewAssignmentHandler(logger *zap.Logger, repo repository.Assignments) *AssignmentHandler {
+	return &AssignmentHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type AssignmentResp struct {
+	AssignmentID   int        `json:"assignment_id"`
+	PatientID      int        `json:"patient_id"`
+	StaffKind      string     `json:"staff_kind"`
+	StaffID        int        `json:"staff_id"`
+	StaffFirstName string     `json:"staff_first_name"`
+	StaffLastName  string     `json:"staff_last_name"`
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveTo    *time.Time `json:"effective_to"`
+}
+
+type AssignNurseReq struct {
+	NurseID int `json:"nurse_id"`
+}
+
+type ReassignDoctorReq struct {
+	DoctorID int `json:"doctor_id"`
+}
+
+func (h *AssignmentHandler) AssignNurse(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req AssignNurseReq
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.NurseID <= 0 {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "nurse_id must be a positive integer")
+		return
+	}
+	assignment, err := h.repo.AssignNurse(ctx.Request.Context(), pid, req.NurseID)
+	if err != nil {
+		h.writeError(ctx, "nurse", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toAssignmentResp(assignment))
+}
+
+func (h *AssignmentHandler) UnassignNurse(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	nid, err := strconv.Atoi(ctx.Param("nurse_id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "nurse id must be an integer")
+		return
+	}
+	assignment, err := h.repo.UnassignNurse(ctx.Request.Context(), pid, nid)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			respondError(ctx, http.StatusNotFound, CodeNotFound, "nurse is not assigned to this patient")
+			return
+		}
+		h.writeError(ctx, "nurse", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toAssignmentResp(assignment))
+}
+
+func (h *AssignmentHandler) ReassignDoctor(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req ReassignDoctorReq
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.DoctorID <= 0 {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "doctor_id must be a positive integer")
+		return
+	}
+	assignment, err := h.repo.ReassignDoctor(ctx.Request.Context(), pid, req.DoctorID)
+	if err != nil {
+		h.writeError(ctx, "doctor", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toAssignmentResp(assignment))
+}
+
+// ListAssignments returns the patient's assignment history, oldest first.
+// With current=true only the assignments still in effect are returned.
+func (h *AssignmentHandler) ListAssignments(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	currentOnly := ctx.Query("current") == "true"
+	assignments, err := h.repo.ListAssignmentHistory(ctx.Request.Context(), pid, currentOnly)
+	if err != nil {
+		h.writeError(ctx, "", err)
+		return
+	}
+	resp := make([]AssignmentResp, 0, len(assignments))
+	for _, a := range assignments {
+		resp = append(resp, toAssignmentResp(a))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"assignments": resp})
+}
+
+func (h *AssignmentHandler) writeError(ctx *gin.Context, staff string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, "patient is discharged or already assigned to this "+staff)
+	case errors.Is(err, repository.ErrInvalidReference):
+		respondError(ctx, http.StatusUnprocessableEntity, CodeInvalidReference, staff+" does not exist or is inactive")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+func toAssignmentResp(a model.Assignment) AssignmentResp {
+	return AssignmentResp{
+		AssignmentID:   a.AssignmentID,
+		PatientID:      a.PatientID,
+		StaffKind:      a.StaffKind,
+		StaffID:        a.StaffID,
+		StaffFirstName: a.StaffFirstName,
+		StaffLastName:  a.StaffLastName,
+		EffectiveFrom:  a.EffectiveFrom,
+		EffectiveTo:    a.EffectiveTo,
+	}
+}
@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AssignmentRoutes(t *testing.T) {
+	api := newTestAPI(t)
+
+	var assignment AssignmentResp
+	assert.Equal(t, http.StatusCreated, api.do(demoAdmin, http.MethodPost, "/api/patients/2/nurses", AssignNurseReq{NurseID: 1}, &assignment))
+	assert.Equal(t, 2, assignment.PatientID)
+	assert.Equal(t, "nurse", assignment.StaffKind)
+	assert.Equal(t, "Emily", assignment.StaffFirstName)
+	assert.Nil(t, assignment.EffectiveTo)
+	assert.Equal(t, http.StatusOK, api.do(emily, http.MethodGet, "/api/patients/2", nil, nil))
+
+	var errResp ErrorResp
+	assert.Equal(t, http.StatusConflict, api.do(demoAdmin, http.MethodPost, "/api/patients/2/nurses", AssignNurseReq{NurseID: 1}, &errResp))
+	assert.Equal(t, CodeConflict, errResp.Code)
+	assert.Equal(t, http.StatusBadRequest, api.do(demoAdmin, http.MethodPost, "/api/patients/2/nurses", AssignNurseReq{}, nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, api.do(demoAdmin, http.MethodPost, "/api/patients/2/nurses", AssignNurseReq{NurseID: 99}, &errResp))
+	assert.Equal(t, CodeInvalidReference, errResp.Code)
+	assert.Equal(t, http.StatusForbidden, api.do(emily, http.MethodPost, "/api/patients/1/nurses", AssignNurseReq{NurseID: 2}, nil))
+
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodDelete, "/api/patients/2/nurses/1", nil, &assignment))
+	assert.NotNil(t, assignment.EffectiveTo)
+	assert.Equal(t, http.StatusNotFound, api.do(demoAdmin, http.MethodDelete, "/api/patients/2/nurses/1", nil, &errResp))
+	assert.Equal(t, CodeNotFound, errResp.Code)
+	assert.Equal(t, http.StatusBadRequest, api.do(demoAdmin, http.MethodDelete, "/api/patients/2/nurses/x", nil, nil))
+
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodPut, "/api/patients/2/doctor", ReassignDoctorReq{DoctorID: 3}, &assignment))
+	assert.Equal(t, "doctor", assignment.StaffKind)
+	assert.Equal(t, 3, assignment.StaffID)
+	assert.Equal(t, http.StatusConflict, api.do(demoAdmin, http.MethodPut, "/api/patients/2/doctor", ReassignDoctorReq{DoctorID: 3}, nil))
+	assert.Equal(t, http.StatusBadRequest, api.do(demoAdmin, http.MethodPut, "/api/patients/2/doctor", ReassignDoctorReq{}, nil))
+
+	var list struct {
+		Assignments []AssignmentResp `json:"assignments"`
+	}
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodGet, "/api/patients/2/assignments?current=true", nil, &list))
+	var current []string
+	for _, a := range list.Assignments {
+		current = append(current, a.StaffKind+" "+a.StaffLastName)
+	}
+	assert.Equal(t, []string{"nurse Brown", "doctor Johnson"}, current)
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodGet, "/api/patients/2/assignments", nil, &list))
+	assert.Len(t, list.Assignments, 4)
+}
+
+func Test_AssignmentRoutesRejectInactiveStaff(t *testing.T) {
+	api := newTestAPI(t)
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodDelete, "/api/doctors/3", nil, nil))
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodDelete, "/api/nurses/3", nil, nil))
+
+	var errResp ErrorResp
+	assert.Equal(t, http.StatusUnprocessableEntity, api.do(demoAdmin, http.MethodPut, "/api/patients/1/doctor", ReassignDoctorReq{DoctorID: 3}, &errResp))
+	assert.Equal(t, CodeInvalidReference, errResp.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, api.do(demoAdmin, http.MethodPost, "/api/patients/1/nurses", AssignNurseReq{NurseID: 3}, &errResp))
+	assert.Equal(t, CodeInvalidReference, errResp.Code)
+}
+
+func Test_AssignmentRoutesOfDischargedPatients(t *testing.T) {
+	api := newTestAPI(t)
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodDelete, "/api/patients/2", nil, nil))
+
+	var errResp ErrorResp
+	assert.Equal(t, http.StatusConflict, api.do(demoAdmin, http.MethodPost, "/api/patients/2/nurses", AssignNurseReq{NurseID: 1}, &errResp))
+	assert.Equal(t, CodeConflict, errResp.Code)
+	assert.Equal(t, http.StatusConflict, api.do(demoAdmin, http.MethodPut, "/api/patients/2/doctor", ReassignDoctorReq{DoctorID: 3}, &errResp))
+	assert.Equal(t, CodeConflict, errResp.Code)
+}
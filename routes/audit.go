@@ -0,0 +1,214 @@
+package routes
+
+import (
+	"context"
+	"health-care-backend/auth"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	auditPatientsKey = "audit.patients"
+	auditRecordKey   = "audit.record"
+	// patientRoutePrefix is the path of the routes whose :id parameter is a
+	// patient id.
+	patientRoutePrefix = "/api/patients/:id"
+	// auditEnqueueTimeout bounds the wait for room in the audit queue, so
+	// that a stalled audit writer cannot pile up requests behind it.
+	auditEnqueueTimeout = 5 * time.Second
+)
+
+// auditQueue takes audit entries off the request path, see
+// repository.AuditWriter.
+type auditQueue interface {
+	Enqueue(ctx context.Context, e model.AuditEntry) error
+}
+
+// auditTrail records the request in the audit log once the handler is done,
+// including requests that were refused. The patients are the :id of the
+// patient routes plus those the handler reported with auditPatients. It must
+// run after auth.Middleware and before the permission checks.
+//
+// Entries are only queued here and written in the background. A failed
+// write cannot undo the response any more; it is logged as an error so that
+// alerting picks it up.
+func auditTrail(logger *zap.Logger, queue auditQueue) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		record := func(status int, patientIDs []int) {
+			id, _ := auth.IdentityFrom(ctx)
+			entry := model.AuditEntry{
+				OccurredAt: time.Now(),
+				UserID:     id.UserID,
+				Username:   id.Username,
+				Role:       id.Role,
+				Action:     ctx.Request.Method + " " + ctx.FullPath(),
+				Status:     status,
+				ClientIP:   ctx.ClientIP(),
+				RequestID:  requestIDFrom(ctx),
+				PatientIDs: patientIDs,
+			}
+			// the entry is queued even when the client has gone away meanwhile
+			enqueueCtx, cancel := context.WithTimeout(context.Background(), auditEnqueueTimeout)
+			defer cancel()
+			if err := queue.Enqueue(enqueueCtx, entry); err != nil {
+				logger.Error("failed to queue audit entry",
+					zap.Error(err),
+					zap.String("action", entry.Action),
+					zap.Int("user id", entry.UserID),
+					zap.Ints("patient ids", entry.PatientIDs),
+					zap.String("request id", entry.RequestID))
+			}
+		}
+		ctx.Set(auditRecordKey, record)
+		ctx.Next()
+
+		patientIDs := auditedPatients(ctx)
+		if strings.HasPrefix(ctx.FullPath(), patientRoutePrefix) {
+			if pid, err := strconv.Atoi(ctx.Param("id")); err == nil {
+				patientIDs = append(patientIDs, pid)
+			}
+		}
+		record(ctx.Writer.Status(), patientIDs)
+	}
+}
+
+// auditPatients reports patients whose data the handler returned or changed
+// to auditTrail.
+func auditPatients(ctx *gin.Context, ids ...int) {
+	ctx.Set(auditPatientsKey, append(auditedPatients(ctx), ids...))
+}
+
+func auditedPatients(ctx *gin.Context) []int {
+	value, _ := ctx.Get(auditPatientsKey)
+	ids, _ := value.([]int)
+	return ids
+}
+
+// auditShown records patients that a long-running response, i.e. a
+// dashboard stream, has just sent, instead of once the handler is done.
+func auditShown(ctx *gin.Context, ids ...int) {
+	value, _ := ctx.Get(auditRecordKey)
+	if record, ok := value.(func(int, []int)); ok && len(ids) > 0 {
+		record(http.StatusOK, ids)
+	}
+}
+
+type AuditHandler struct {
+	logger *zap.Logger
+	repo   repository.AuditLog
+}
+
+func NewAuditHandler(logger *zap.Logger, repo repository.AuditLog) *AuditHandler {
+	return &AuditHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type AuditEntryResp struct {
+	EntryID    int64     `json:"entry_id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	Role       string    `json:"role"`
+	Action     string    `json:"action"`
+	Status     int       `json:"status"`
+	ClientIP   string    `json:"client_ip"`
+	RequestID  string    `json:"request_id"`
+	PatientIDs []int     `json:"patient_ids"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+type AuditVerificationResp struct {
+	Valid    bool   `json:"valid"`
+	Entries  int64  `json:"entries"`
+	BrokenAt *int64 `json:"broken_at"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// ListEntries returns audit entries, newest first, optionally filtered by
+// patient_id, user_id and an RFC 3339 from/to window. limit defaults to 100
+// and may be at most 1000.
+func (h *AuditHandler) ListEntries(ctx *gin.Context) {
+	var f repository.AuditFilter
+	for param, dst := range map[string]*int{"user_id": &f.UserID, "patient_id": &f.PatientID, "limit": &f.Limit} {
+		if value := ctx.Query(param); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" must be a positive integer")
+				return
+			}
+			*dst = n
+		}
+	}
+	if f.Limit > 1000 {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "limit must not be greater than 1000")
+		return
+	}
+	for param, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if value := ctx.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" must be an RFC 3339 timestamp")
+				return
+			}
+			t = t.UTC()
+			*dst = &t
+		}
+	}
+	entries, err := h.repo.ListAuditEntries(ctx.Request.Context(), f)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	resp := make([]AuditEntryResp, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, toAuditEntryResp(e))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"entries": resp})
+}
+
+// VerifyChain checks the hash chain of the whole log. A broken chain is
+// reported with 200 and valid set to false; it is also logged as an error.
+func (h *AuditHandler) VerifyChain(ctx *gin.Context) {
+	v, err := h.repo.VerifyAuditChain(ctx.Request.Context())
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	resp := AuditVerificationResp{Valid: v.Valid, Entries: v.Entries, Reason: v.Reason}
+	if !v.Valid {
+		resp.BrokenAt = &v.BrokenAt
+		h.logger.Error("audit log hash chain is broken", zap.Int64("entry id", v.BrokenAt), zap.String("reason", v.Reason))
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func toAuditEntryResp(e model.AuditEntry) AuditEntryResp {
+	patientIDs := e.PatientIDs
+	if patientIDs == nil {
+		patientIDs = []int{}
+	}
+	return AuditEntryResp{
+		EntryID:    e.EntryID,
+		OccurredAt: e.OccurredAt,
+		UserID:     e.UserID,
+		Username:   e.Username,
+		Role:       e.Role,
+		Action:     e.Action,
+		Status:     e.Status,
+		ClientIP:   e.ClientIP,
+		RequestID:  e.RequestID,
+		PatientIDs: patientIDs,
+		PrevHash:   e.PrevHash,
+		Hash:       e.Hash,
+	}
+}
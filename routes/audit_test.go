@@ -0,0 +1,99 @@
+package routes
+
+import (
+	"context"
+	"health-care-backend/auth"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// stubAuditQueue keeps queued entries in memory.
+type stubAuditQueue struct {
+	mu      sync.Mutex
+	entries []model.AuditEntry
+	// deadlines of the contexts entries were queued with
+	deadlines []time.Time
+}
+
+func (s *stubAuditQueue) Enqueue(ctx context.Context, e model.AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+	deadline, _ := ctx.Deadline()
+	s.deadlines = append(s.deadlines, deadline)
+	return nil
+}
+
+func Test_AuditTrail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := &stubAuditQueue{}
+	router := gin.New()
+	router.Use(requestID())
+	api := router.Group("/api", func(ctx *gin.Context) {
+		auth.SetIdentity(ctx, auth.Identity{UserID: 7, Username: "emily.wilson", Role: "nurse", NurseID: intPtr(1)})
+	})
+	audited := auditTrail(zap.NewNop(), log)
+	api.GET("/patients/:id/vitals", audited, func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+	api.GET("/dashboard/nurse", audited, func(ctx *gin.Context) {
+		auditPatients(ctx, 4, 1)
+		ctx.Status(http.StatusOK)
+	})
+	api.GET("/patients/:id/forbidden", audited, requirePermission("nothing"), func(ctx *gin.Context) {
+		t.Error("handler must not run")
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/patients/3/vitals", nil)
+	req.Header.Set(requestIDHeader, "req-42")
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, "req-42", rec.Header().Get(requestIDHeader))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/dashboard/nurse", nil))
+	generated := rec.Header().Get(requestIDHeader)
+	assert.Len(t, generated, 32)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/patients/2/forbidden", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	if assert.Len(t, log.entries, 3) {
+		e := log.entries[0]
+		assert.Equal(t, 7, e.UserID)
+		assert.Equal(t, "emily.wilson", e.Username)
+		assert.Equal(t, "nurse", e.Role)
+		assert.Equal(t, "GET /api/patients/:id/vitals", e.Action)
+		assert.Equal(t, http.StatusOK, e.Status)
+		assert.Equal(t, "req-42", e.RequestID)
+		assert.Equal(t, []int{3}, e.PatientIDs)
+
+		assert.Equal(t, []int{4, 1}, log.entries[1].PatientIDs)
+		assert.Equal(t, generated, log.entries[1].RequestID)
+
+		// refused requests are recorded too
+		assert.Equal(t, http.StatusForbidden, log.entries[2].Status)
+		assert.Equal(t, []int{2}, log.entries[2].PatientIDs)
+	}
+	// a full audit queue does not hold the request forever
+	assert.Len(t, log.deadlines, 3)
+	for _, deadline := range log.deadlines {
+		assert.WithinDuration(t, time.Now().Add(auditEnqueueTimeout), deadline, auditEnqueueTimeout)
+	}
+}
+
+func Test_ValidRequestID(t *testing.T) {
+	assert.True(t, validRequestID("3f2b9c1e-7a4d-4e0b-9c55-1f6a2b3c4d5e"))
+	assert.False(t, validRequestID(""))
+	assert.False(t, validRequestID("line\nbreak"))
+	assert.False(t, validRequestID(string(make([]byte, 65))))
+}
@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"errors"
+	"health-care-backend/auth"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type AuthHandler struct {
+	logger        *zap.Logger
+	authenticator *auth.Authenticator
+	repo          repository.Users
+}
+
+func NewAuthHandler(logger *zap.Logger, authenticator *auth.Authenticator, repo repository.Users) *AuthHandler {
+	return &AuthHandler{
+		logger:        logger,
+		authenticator: authenticator,
+		repo:          repo,
+	}
+}
+
+type LoginReq struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type LoginResp struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int       `json:"expires_in"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Login exchanges a username and password for a bearer token. Unknown users,
+// wrong passwords and inactive accounts, including those of deactivated
+// doctors and nurses, get the same answer.
+func (h *AuthHandler) Login(ctx *gin.Context) {
+	var req LoginReq
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Username == "" || req.Password == "" {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "username and password are required")
+		return
+	}
+	user, err := h.repo.SelectUserByUsername(ctx.Request.Context(), req.Username)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		respondInternalError(ctx, err)
+		return
+	}
+	if !auth.CheckPassword(user.PasswordHash, req.Password) || !user.Active {
+		h.logger.Warn("failed login", zap.String("username", req.Username), zap.String("client ip", ctx.ClientIP()))
+		respondError(ctx, http.StatusUnauthorized, CodeUnauthorized, "invalid username or password")
+		return
+	}
+	token, expiresAt, err := h.authenticator.Issue(toIdentity(user))
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, LoginResp{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(expiresAt).Seconds()),
+		ExpiresAt:   expiresAt,
+	})
+}
+
+// requireActiveAccount rejects the tokens of accounts that were deactivated
+// after the token was issued, so that deactivation takes effect at once
+// rather than when the token expires. It costs one lookup per request.
+func requireActiveAccount(repo repository.Users) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := auth.IdentityFrom(ctx)
+		if !ok {
+			ctx.Next()
+			return
+		}
+		user, err := repo.SelectUser(ctx.Request.Context(), id.UserID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			respondInternalError(ctx, err)
+			return
+		}
+		if !user.Active {
+			respondError(ctx, http.StatusUnauthorized, CodeUnauthorized, "account is inactive")
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// Me returns the identity of the caller's token.
+func (h *AuthHandler) Me(ctx *gin.Context) {
+	id, ok := auth.IdentityFrom(ctx)
+	if !ok {
+		respondError(ctx, http.StatusUnauthorized, CodeUnauthorized, "not authenticated")
+		return
+	}
+	ctx.JSON(http.StatusOK, id)
+}
+
+func toIdentity(u model.User) auth.Identity {
+	return auth.Identity{
+		UserID:    u.UserID,
+		Username:  u.Username,
+		Role:      u.Role,
+		DoctorID:  u.DoctorID,
+		NurseID:   u.NurseID,
+		PatientID: u.PatientID,
+	}
+}
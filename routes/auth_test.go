@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"health-care-backend/auth"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeactivationRevokesAccess(t *testing.T) {
+	api := newTestAPI(t)
+	var token LoginResp
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodPost, "/api/auth/login",
+		LoginReq{Username: "John.Doe", Password: "demo-password"}, &token))
+	assert.NotEmpty(t, token.AccessToken)
+	assert.Equal(t, http.StatusUnauthorized, api.do(demoAdmin, http.MethodPost, "/api/auth/login",
+		LoginReq{Username: "john.doe", Password: "wrong-password"}, nil))
+	assert.Equal(t, http.StatusOK, api.do(emily, http.MethodGet, "/api/auth/me", nil, nil))
+
+	// tokens issued before the deactivation stop working with it
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodDelete, "/api/nurses/1", nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, api.do(emily, http.MethodGet, "/api/auth/me", nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, api.do(emily, http.MethodGet, "/api/dashboard/nurse", nil, nil))
+	assert.Equal(t, http.StatusUnauthorized, api.do(demoAdmin, http.MethodPost, "/api/auth/login",
+		LoginReq{Username: "emily.wilson", Password: "demo-password"}, nil))
+	// unknown accounts are rejected too
+	assert.Equal(t, http.StatusUnauthorized, api.do(auth.Identity{UserID: 99, Role: "admin"}, http.MethodGet, "/api/auth/me", nil, nil))
+}
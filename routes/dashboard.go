@@ -1,7 +1,12 @@
 package routes
 
 import (
+	"context"
+	"health-care-backend/events"
+	"health-care-backend/news2"
+	"health-care-backend/policy"
 	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
 	"net/http"
 	"strconv"
 	"time"
@@ -13,15 +18,21 @@ import (
 type DashboardHandler struct {
 	logger *zap.Logger
 	repo   repository.Dashboard
+	access repository.Access
+	broker *events.Broker
 }
 
-func NewDashboardHandler(logger *zap.Logger, repo repository.Dashboard) *DashboardHandler {
+func NewDashboardHandler(logger *zap.Logger, repo repository.Dashboard, access repository.Access, broker *events.Broker) *DashboardHandler {
 	return &DashboardHandler{
 		logger: logger,
 		repo:   repo,
+		access: access,
+		broker: broker,
 	}
 }
 
+// The vital sign fields of the dashboard responses are null until the first
+// reading of the patient has been recorded.
 type PatientDashboardResp struct {
 	ID                      int          `json:"patient_id"`
 	FirstName               string       `json:"first_name"`
@@ -33,115 +44,72 @@ type PatientDashboardResp struct {
 	AssignedDoctorID        int          `json:"assigned_doctor_id"`
 	AssignedDoctorFirstName string       `json:"assigned_doctor_first_name"`
 	AssignedDoctorLastName  string       `json:"assigned_doctor_last_name"`
-	BodyTemperature         float64      `json:"body_temperature"`
-	PulseRate               int          `json:"pulse_rate"`
-	RespirationRate         int          `json:"respiration_rate"`
-	SystolicPressure        int          `json:"systolic_pressure"`
-	DiastolicPressure       int          `json:"diastolic_pressure"`
+	BodyTemperature         *float64     `json:"body_temperature"`
+	PulseRate               *int         `json:"pulse_rate"`
+	RespirationRate         *int         `json:"respiration_rate"`
+	SystolicPressure        *int         `json:"systolic_pressure"`
+	DiastolicPressure       *int         `json:"diastolic_pressure"`
+	OxygenSaturation        *int         `json:"oxygen_saturation"`
+	SupplementalOxygen      *bool        `json:"supplemental_oxygen"`
+	Consciousness           *string      `json:"consciousness"`
+	VitalsRecordedAt        *time.Time   `json:"vitals_recorded_at"`
 	CurrentPrescribedMeds   []Medication `json:"current_prescribed_meds"`
 	CurrentDiseases         []Disease    `json:"current_diseases"`
 }
 
+// GetPatientDashboard shows a patient in the caller's scope. Patients may omit
+// patient_id to see their own dashboard.
 func (h *DashboardHandler) GetPatientDashboard(ctx *gin.Context) {
-	pidStr := ctx.Query("patient_id")
-	if pidStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "patient_id is required"})
+	principal := principalFrom(ctx)
+	pid, ok := dashboardIDParam(ctx, "patient_id", principal.Role == policy.Patient, principal.PatientID)
+	if !ok {
 		return
 	}
-	pid, err := strconv.Atoi(pidStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "patient_id must be an integer"})
+	if !checkPatientAccess(ctx, h.access, pid) {
 		return
 	}
-	patientViews, err := h.repo.SelectPatientDashboard(pid)
+	patientViews, err := h.repo.SelectPatientDashboard(ctx.Request.Context(), principal, pid)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
 		return
 	}
-	type tempPatient struct {
-		ID                      int
-		FirstName               string
-		LastName                string
-		Age                     int
-		Sex                     string
-		BloodType               string
-		DOB                     time.Time
-		AssignedDoctorID        int
-		AssignedDoctorFirstName string
-		AssignedDoctorLastName  string
-		BodyTemperature         float64
-		PulseRate               int
-		RespirationRate         int
-		SystolicPressure        int
-		DiastolicPressure       int
-		CurrentPrescribedMeds   map[string]int
-		CurrentDiseases         map[string]int
-	}
-	idToPatient := make(map[int]tempPatient)
-	for _, view := range patientViews {
-		if _, ok := idToPatient[view.ID]; !ok {
-			idToPatient[view.ID] = tempPatient{
-				ID:                      view.ID,
-				FirstName:               view.FirstName,
-				LastName:                view.LastName,
-				Age:                     view.Age,
-				Sex:                     view.Sex,
-				BloodType:               view.BloodType,
-				DOB:                     view.DOB,
-				AssignedDoctorID:        view.AssignedDoctorID,
-				AssignedDoctorFirstName: view.AssignedDoctorFirstName,
-				AssignedDoctorLastName:  view.AssignedDoctorLastName,
-				BodyTemperature:         view.BodyTemperature,
-				PulseRate:               view.PulseRate,
-				RespirationRate:         view.RespirationRate,
-				SystolicPressure:        view.SystolicPressure,
-				DiastolicPressure:       view.DiastolicPressure,
-				CurrentPrescribedMeds:   make(map[string]int),
-				CurrentDiseases:         make(map[string]int),
-			}
-		}
-		p := idToPatient[view.ID]
-		p.CurrentPrescribedMeds[view.CurrentPrescribedMed] = 1
-		p.CurrentDiseases[view.CurrentDisease] = 1
-		idToPatient[view.ID] = p
-	}
-	var resp PatientDashboardResp
-	for _, patient := range idToPatient {
-		// we can only have one patient since the pid is unique
-		resp = PatientDashboardResp{
-			ID:                      patient.ID,
-			FirstName:               patient.FirstName,
-			LastName:                patient.LastName,
-			Age:                     patient.Age,
-			Sex:                     patient.Sex,
-			BloodType:               patient.BloodType,
-			DOB:                     patient.DOB,
-			AssignedDoctorID:        patient.AssignedDoctorID,
-			AssignedDoctorFirstName: patient.AssignedDoctorFirstName,
-			AssignedDoctorLastName:  patient.AssignedDoctorLastName,
-			BodyTemperature:         patient.BodyTemperature,
-			PulseRate:               patient.PulseRate,
-			RespirationRate:         patient.RespirationRate,
-			SystolicPressure:        patient.SystolicPressure,
-			DiastolicPressure:       patient.DiastolicPressure,
-		}
-		// convert map to array
-		for med := range patient.CurrentPrescribedMeds {
-			resp.CurrentPrescribedMeds = append(resp.CurrentPrescribedMeds, Medication{
-				Name: med,
-			})
-		}
-		for disease := range patient.CurrentDiseases {
-			resp.CurrentDiseases = append(resp.CurrentDiseases, Disease{
-				Name: disease,
-			})
-		}
+	// the view holds one row per patient
+	if len(patientViews) == 0 {
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient not found")
+		return
 	}
-	ctx.JSON(http.StatusOK, resp)
+	view := patientViews[0]
+	auditPatients(ctx, view.ID)
+	ctx.JSON(http.StatusOK, PatientDashboardResp{
+		ID:                      view.ID,
+		FirstName:               view.FirstName,
+		LastName:                view.LastName,
+		Age:                     view.Age,
+		Sex:                     view.Sex,
+		BloodType:               view.BloodType,
+		DOB:                     view.DOB,
+		AssignedDoctorID:        view.AssignedDoctorID,
+		AssignedDoctorFirstName: view.AssignedDoctorFirstName,
+		AssignedDoctorLastName:  view.AssignedDoctorLastName,
+		BodyTemperature:         view.BodyTemperature,
+		PulseRate:               view.PulseRate,
+		RespirationRate:         view.RespirationRate,
+		SystolicPressure:        view.SystolicPressure,
+		DiastolicPressure:       view.DiastolicPressure,
+		OxygenSaturation:        view.OxygenSaturation,
+		SupplementalOxygen:      view.SupplementalOxygen,
+		Consciousness:           view.Consciousness,
+		VitalsRecordedAt:        view.VitalsRecordedAt,
+		CurrentPrescribedMeds:   toMedications(view.CurrentPrescribedMeds),
+		CurrentDiseases:         toDiseases(view.CurrentDiseases),
+	})
 }
 
+// NurseDashboardResp is a page of the dashboard. NextCursor is null on the
+// last page.
 type NurseDashboardResp struct {
-	Patients []NursePatient `json:"patients"`
+	Patients   []NursePatient `json:"patients"`
+	NextCursor *string        `json:"next_cursor"`
 }
 type NursePatient struct {
 	NurseID                 int          `json:"nurse_id"`
@@ -159,132 +127,118 @@ type NursePatient struct {
 	AssignedDoctorID        int          `json:"assigned_doctor_id"`
 	AssignedDoctorFirstName string       `json:"assigned_doctor_first_name"`
 	AssignedDoctorLastName  string       `json:"assigned_doctor_last_name"`
-	BodyTemperature         float64      `json:"body_temperature"`
-	PulseRate               int          `json:"pulse_rate"`
-	RespirationRate         int          `json:"respiration_rate"`
-	SystolicPressure        int          `json:"systolic_pressure"`
-	DiastolicPressure       int          `json:"diastolic_pressure"`
+	BodyTemperature         *float64     `json:"body_temperature"`
+	PulseRate               *int         `json:"pulse_rate"`
+	RespirationRate         *int         `json:"respiration_rate"`
+	SystolicPressure        *int         `json:"systolic_pressure"`
+	DiastolicPressure       *int         `json:"diastolic_pressure"`
+	OxygenSaturation        *int         `json:"oxygen_saturation"`
+	SupplementalOxygen      *bool        `json:"supplemental_oxygen"`
+	Consciousness           *string      `json:"consciousness"`
+	VitalsRecordedAt        *time.Time   `json:"vitals_recorded_at"`
 	CurrentPrescribedMeds   []Medication `json:"current_prescribed_meds"`
 	CurrentDiseases         []Disease    `json:"current_diseases"`
+	NEWS2                   *news2.Score `json:"news2"`
 }
 
+// GetNurseDashboard lists the patients of a nurse. Nurses may only open their
+// own dashboard and may omit nurse_id. The patients are paged, sorted and
+// filtered in the database, see dashboardQueryParams.
 func (h *DashboardHandler) GetNurseDashboard(ctx *gin.Context) {
-	nidStr := ctx.Query("nurse_id")
-	if nidStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "nurse_id is required"})
+	principal := principalFrom(ctx)
+	nid, ok := dashboardIDParam(ctx, "nurse_id", principal.Role == policy.Nurse, principal.NurseID)
+	if !ok {
 		return
 	}
-	nid, err := strconv.Atoi(nidStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "nurse_id must be integer"})
+	q, ok := dashboardQueryParams(ctx)
+	if !ok {
 		return
 	}
-	views, err := h.repo.SelectNurseDashboard(nid)
+	if !principal.CanViewNurse(nid) {
+		respondError(ctx, http.StatusForbidden, CodeForbidden, "not allowed to view this nurse's dashboard")
+		return
+	}
+	patients, next, err := h.nursePatients(ctx.Request.Context(), principal, nid, q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
 		return
 	}
-	type tempNursePatient struct {
-		NurseID                 int
-		NurseFirstName          string
-		NurseLastName           string
-		PatientID               int
-		PatientFirstName        string
-		PatientLastName         string
-		Age                     int
-		Sex                     string
-		BloodType               string
-		PhoneNumber             string
-		Address                 string
-		DOB                     time.Time
-		AssignedDoctorID        int
-		AssignedDoctorFirstName string
-		AssignedDoctorLastName  string
-		BodyTemperature         float64
-		PulseRate               int
-		RespirationRate         int
-		SystolicPressure        int
-		DiastolicPressure       int
-		CurrentPrescribedMeds   map[string]int
-		CurrentDiseases         map[string]int
+	for _, p := range patients {
+		auditPatients(ctx, p.PatientID)
 	}
+	ctx.JSON(http.StatusOK, NurseDashboardResp{Patients: patients, NextCursor: next})
+}
 
-	idToPatient := make(map[int]tempNursePatient)
-	for _, view := range views {
-		if _, ok := idToPatient[view.PatientID]; !ok {
-			idToPatient[view.PatientID] = tempNursePatient{
-				NurseID:                 view.NurseID,
-				NurseFirstName:          view.NurseFirstName,
-				NurseLastName:           view.NurseLastName,
-				PatientID:               view.PatientID,
-				PatientFirstName:        view.PatientFirstName,
-				PatientLastName:         view.PatientLastName,
-				Age:                     view.Age,
-				Sex:                     view.Sex,
-				BloodType:               view.BloodType,
-				PhoneNumber:             view.PhoneNumber,
-				Address:                 view.Address,
-				DOB:                     view.DOB,
-				AssignedDoctorID:        view.AssignedDoctorID,
-				AssignedDoctorFirstName: view.AssignedDoctorFirstName,
-				AssignedDoctorLastName:  view.AssignedDoctorLastName,
-				BodyTemperature:         view.BodyTemperature,
-				PulseRate:               view.PulseRate,
-				RespirationRate:         view.RespirationRate,
-				SystolicPressure:        view.SystolicPressure,
-				DiastolicPressure:       view.DiastolicPressure,
-				CurrentPrescribedMeds:   make(map[string]int),
-				CurrentDiseases:         make(map[string]int),
-			}
-		}
-		p := idToPatient[view.PatientID]
-		p.CurrentPrescribedMeds[view.CurrentPrescribedMed] = 1
-		p.CurrentDiseases[view.CurrentDisease] = 1
-		idToPatient[view.PatientID] = p
+// nursePatients loads a page of a nurse dashboard and the cursor of the
+// next page, which is nil on the last one.
+func (h *DashboardHandler) nursePatients(ctx context.Context, principal policy.Principal, nid int, q repository.DashboardQuery) ([]NursePatient, *string, error) {
+	// one more row than asked for tells whether there is a next page
+	page := q
+	if q.Limit > 0 {
+		page.Limit++
 	}
-
-	var resp NurseDashboardResp
-	for _, patient := range idToPatient {
-		patientResp := NursePatient{
-			NurseID:                 patient.NurseID,
-			NurseFirstName:          patient.NurseFirstName,
-			NurseLastName:           patient.NurseLastName,
-			PatientID:               patient.PatientID,
-			PatientFirstName:        patient.PatientFirstName,
-			PatientLastName:         patient.PatientLastName,
-			Age:                     patient.Age,
-			Sex:                     patient.Sex,
-			BloodType:               patient.BloodType,
-			PhoneNumber:             patient.PhoneNumber,
-			Address:                 patient.Address,
-			DOB:                     patient.DOB,
-			AssignedDoctorID:        patient.AssignedDoctorID,
-			AssignedDoctorFirstName: patient.AssignedDoctorFirstName,
-			AssignedDoctorLastName:  patient.AssignedDoctorLastName,
-			BodyTemperature:         patient.BodyTemperature,
-			PulseRate:               patient.PulseRate,
-			RespirationRate:         patient.RespirationRate,
-			SystolicPressure:        patient.SystolicPressure,
-			DiastolicPressure:       patient.DiastolicPressure,
-		}
-		for med := range patient.CurrentPrescribedMeds {
-			patientResp.CurrentPrescribedMeds = append(patientResp.CurrentPrescribedMeds, Medication{
-				Name: med,
-			})
-		}
-		for disease := range patient.CurrentDiseases {
-			patientResp.CurrentDiseases = append(patientResp.CurrentDiseases, Disease{
-				Name: disease,
-			})
+	views, err := h.repo.SelectNurseDashboard(ctx, principal, nid, page)
+	if err != nil {
+		return nil, nil, err
+	}
+	var next *string
+	if q.Limit > 0 && len(views) > q.Limit {
+		views = views[:q.Limit]
+		last := views[len(views)-1]
+		value := dashboardSortValue(q.Sort, last.PatientLastName, last.Age, last.VitalsRecordedAt, last.NEWS2Rank)
+		if next, err = encodeDashboardCursor(q, value, last.PatientID); err != nil {
+			return nil, nil, err
 		}
-		resp.Patients = append(resp.Patients, patientResp)
 	}
 
-	ctx.JSON(http.StatusOK, resp)
+	patients := make([]NursePatient, 0, len(views))
+	for _, view := range views {
+		patients = append(patients, NursePatient{
+			NurseID:                 view.NurseID,
+			NurseFirstName:          view.NurseFirstName,
+			NurseLastName:           view.NurseLastName,
+			PatientID:               view.PatientID,
+			PatientFirstName:        view.PatientFirstName,
+			PatientLastName:         view.PatientLastName,
+			Age:                     view.Age,
+			Sex:                     view.Sex,
+			BloodType:               view.BloodType,
+			PhoneNumber:             view.PhoneNumber,
+			Address:                 view.Address,
+			DOB:                     view.DOB,
+			AssignedDoctorID:        view.AssignedDoctorID,
+			AssignedDoctorFirstName: view.AssignedDoctorFirstName,
+			AssignedDoctorLastName:  view.AssignedDoctorLastName,
+			BodyTemperature:         view.BodyTemperature,
+			PulseRate:               view.PulseRate,
+			RespirationRate:         view.RespirationRate,
+			SystolicPressure:        view.SystolicPressure,
+			DiastolicPressure:       view.DiastolicPressure,
+			OxygenSaturation:        view.OxygenSaturation,
+			SupplementalOxygen:      view.SupplementalOxygen,
+			Consciousness:           view.Consciousness,
+			VitalsRecordedAt:        view.VitalsRecordedAt,
+			CurrentPrescribedMeds:   toMedications(view.CurrentPrescribedMeds),
+			CurrentDiseases:         toDiseases(view.CurrentDiseases),
+			NEWS2: earlyWarningScore(view.VitalsRecordedAt, news2.Inputs{
+				RespirationRate:    view.RespirationRate,
+				OxygenSaturation:   view.OxygenSaturation,
+				SupplementalOxygen: view.SupplementalOxygen,
+				SystolicPressure:   view.SystolicPressure,
+				PulseRate:          view.PulseRate,
+				Consciousness:      view.Consciousness,
+				TemperatureF:       view.BodyTemperature,
+			}),
+		})
+	}
+	return patients, next, nil
 }
 
+// DoctorDashboardResp is a page of the dashboard. NextCursor is null on the
+// last page.
 type DoctorDashboardResp struct {
-	Patients []DoctorPatient `json:"patients"`
+	Patients   []DoctorPatient `json:"patients"`
+	NextCursor *string         `json:"next_cursor"`
 }
 type DoctorPatient struct {
 	PatientID               int          `json:"patient_id"`
@@ -299,124 +253,187 @@ type DoctorPatient struct {
 	AssignedDoctorID        int          `json:"assigned_doctor_id"`
 	AssignedDoctorFirstName string       `json:"assigned_doctor_first_name"`
 	AssignedDoctorLastName  string       `json:"assigned_doctor_last_name"`
-	BodyTemperature         float64      `json:"body_temperature"`
-	PulseRate               int          `json:"pulse_rate"`
-	RespirationRate         int          `json:"respiration_rate"`
-	SystolicPressure        int          `json:"systolic_pressure"`
-	DiastolicPressure       int          `json:"diastolic_pressure"`
+	BodyTemperature         *float64     `json:"body_temperature"`
+	PulseRate               *int         `json:"pulse_rate"`
+	RespirationRate         *int         `json:"respiration_rate"`
+	SystolicPressure        *int         `json:"systolic_pressure"`
+	DiastolicPressure       *int         `json:"diastolic_pressure"`
+	OxygenSaturation        *int         `json:"oxygen_saturation"`
+	SupplementalOxygen      *bool        `json:"supplemental_oxygen"`
+	Consciousness           *string      `json:"consciousness"`
+	VitalsRecordedAt        *time.Time   `json:"vitals_recorded_at"`
 	CurrentPrescribedMeds   []Medication `json:"current_prescribed_meds"`
 	CurrentDiseases         []Disease    `json:"current_diseases"`
+	NEWS2                   *news2.Score `json:"news2"`
 }
+
+// Medication is a current (active or held) medication order. Orders migrated
+// from the old name-only list have no dose, unit, route or frequency. The
+// medications of a patient are listed by start date and name.
 type Medication struct {
-	Name string `json:"name"`
+	OrderID             int         `json:"order_id"`
+	Name                string      `json:"name"`
+	Dose                *float64    `json:"dose"`
+	Unit                *string     `json:"unit"`
+	Route               *string     `json:"route"`
+	Frequency           *string     `json:"frequency"`
+	StartDate           model.Date  `json:"start_date"`
+	StopDate            *model.Date `json:"stop_date"`
+	PrescribingDoctorID *int        `json:"prescribing_doctor_id"`
+	Status              string      `json:"status"`
 }
 
+// Disease is an unresolved diagnosis. Name is the ICD-10 description, or the
+// free text of diagnoses recorded before coding, whose ICD10Code is null. The
+// primary diagnosis is listed first, the others by onset date and name.
 type Disease struct {
-	Name string `json:"name"`
+	DiagnosisID  int         `json:"diagnosis_id"`
+	Name         string      `json:"name"`
+	ICD10Code    *string     `json:"icd10_code"`
+	OnsetDate    *model.Date `json:"onset_date"`
+	ResolvedDate *model.Date `json:"resolved_date"`
+	IsPrimary    bool        `json:"is_primary"`
 }
 
+// GetDoctorDashboard lists the patients of a doctor. Doctors may only open
+// their own dashboard and may omit doctor_id. The patients are paged, sorted
+// and filtered in the database, see dashboardQueryParams.
 func (h *DashboardHandler) GetDoctorDashboard(ctx *gin.Context) {
-	didStr := ctx.Query("doctor_id")
-	if didStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "doctor_id is required"})
+	principal := principalFrom(ctx)
+	did, ok := dashboardIDParam(ctx, "doctor_id", principal.Role == policy.Doctor, principal.DoctorID)
+	if !ok {
 		return
 	}
-	did, err := strconv.Atoi(didStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "doctor_id must be integer"})
+	q, ok := dashboardQueryParams(ctx)
+	if !ok {
 		return
 	}
-	views, err := h.repo.SelectDoctorDashboard(did)
+	if !principal.CanViewDoctor(did) {
+		respondError(ctx, http.StatusForbidden, CodeForbidden, "not allowed to view this doctor's dashboard")
+		return
+	}
+	patients, next, err := h.doctorPatients(ctx.Request.Context(), principal, did, q)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondInternalError(ctx, err)
 		return
 	}
+	for _, p := range patients {
+		auditPatients(ctx, p.PatientID)
+	}
+	ctx.JSON(http.StatusOK, DoctorDashboardResp{Patients: patients, NextCursor: next})
+}
 
-	type tempDoctorPatient struct {
-		PatientID               int
-		FirstName               string
-		LastName                string
-		Age                     int
-		Sex                     string
-		BloodType               string
-		PhoneNumber             string
-		Address                 string
-		DOB                     time.Time
-		AssignedDoctorID        int
-		AssignedDoctorFirstName string
-		AssignedDoctorLastName  string
-		BodyTemperature         float64
-		PulseRate               int
-		RespirationRate         int
-		SystolicPressure        int
-		DiastolicPressure       int
-		CurrentPrescribedMeds   map[string]int
-		CurrentDiseases         map[string]int
+// doctorPatients loads a page of a doctor dashboard and the cursor of the
+// next page, which is nil on the last one.
+func (h *DashboardHandler) doctorPatients(ctx context.Context, principal policy.Principal, did int, q repository.DashboardQuery) ([]DoctorPatient, *string, error) {
+	// one more row than asked for tells whether there is a next page
+	page := q
+	if q.Limit > 0 {
+		page.Limit++
+	}
+	views, err := h.repo.SelectDoctorDashboard(ctx, principal, did, page)
+	if err != nil {
+		return nil, nil, err
+	}
+	var next *string
+	if q.Limit > 0 && len(views) > q.Limit {
+		views = views[:q.Limit]
+		last := views[len(views)-1]
+		value := dashboardSortValue(q.Sort, last.LastName, last.Age, last.VitalsRecordedAt, last.NEWS2Rank)
+		if next, err = encodeDashboardCursor(q, value, last.PatientID); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	idToPatient := make(map[int]tempDoctorPatient)
+	patients := make([]DoctorPatient, 0, len(views))
 	for _, view := range views {
-		if _, ok := idToPatient[view.PatientID]; !ok {
-			idToPatient[view.PatientID] = tempDoctorPatient{
-				PatientID:               view.PatientID,
-				FirstName:               view.FirstName,
-				LastName:                view.LastName,
-				Age:                     view.Age,
-				Sex:                     view.Sex,
-				BloodType:               view.BloodType,
-				PhoneNumber:             view.PhoneNumber,
-				Address:                 view.Address,
-				DOB:                     view.DOB,
-				AssignedDoctorID:        view.AssignedDoctorID,
-				AssignedDoctorFirstName: view.AssignedDoctorFirstName,
-				AssignedDoctorLastName:  view.AssignedDoctorLastName,
-				BodyTemperature:         view.BodyTemperature,
-				PulseRate:               view.PulseRate,
-				RespirationRate:         view.RespirationRate,
-				SystolicPressure:        view.SystolicPressure,
-				DiastolicPressure:       view.DiastolicPressure,
-				CurrentPrescribedMeds:   make(map[string]int),
-				CurrentDiseases:         make(map[string]int),
-			}
-		}
-		v := idToPatient[view.PatientID]
-		v.CurrentPrescribedMeds[view.CurrentPrescribedMed] = 1
-		v.CurrentDiseases[view.CurrentDisease] = 1
-		idToPatient[view.PatientID] = v
+		patients = append(patients, DoctorPatient{
+			PatientID:               view.PatientID,
+			FirstName:               view.FirstName,
+			LastName:                view.LastName,
+			Age:                     view.Age,
+			Sex:                     view.Sex,
+			BloodType:               view.BloodType,
+			PhoneNumber:             view.PhoneNumber,
+			Address:                 view.Address,
+			DOB:                     view.DOB,
+			AssignedDoctorID:        view.AssignedDoctorID,
+			AssignedDoctorFirstName: view.AssignedDoctorFirstName,
+			AssignedDoctorLastName:  view.AssignedDoctorLastName,
+			BodyTemperature:         view.BodyTemperature,
+			PulseRate:               view.PulseRate,
+			RespirationRate:         view.RespirationRate,
+			SystolicPressure:        view.SystolicPressure,
+			DiastolicPressure:       view.DiastolicPressure,
+			OxygenSaturation:        view.OxygenSaturation,
+			SupplementalOxygen:      view.SupplementalOxygen,
+			Consciousness:           view.Consciousness,
+			VitalsRecordedAt:        view.VitalsRecordedAt,
+			CurrentPrescribedMeds:   toMedications(view.CurrentPrescribedMeds),
+			CurrentDiseases:         toDiseases(view.CurrentDiseases),
+			NEWS2: earlyWarningScore(view.VitalsRecordedAt, news2.Inputs{
+				RespirationRate:    view.RespirationRate,
+				OxygenSaturation:   view.OxygenSaturation,
+				SupplementalOxygen: view.SupplementalOxygen,
+				SystolicPressure:   view.SystolicPressure,
+				PulseRate:          view.PulseRate,
+				Consciousness:      view.Consciousness,
+				TemperatureF:       view.BodyTemperature,
+			}),
+		})
 	}
+	return patients, next, nil
+}
 
-	var resp DoctorDashboardResp
-	for _, patient := range idToPatient {
-		patientResp := DoctorPatient{
-			PatientID:               patient.PatientID,
-			FirstName:               patient.FirstName,
-			LastName:                patient.LastName,
-			Age:                     patient.Age,
-			Sex:                     patient.Sex,
-			BloodType:               patient.BloodType,
-			PhoneNumber:             patient.PhoneNumber,
-			Address:                 patient.Address,
-			DOB:                     patient.DOB,
-			AssignedDoctorID:        patient.AssignedDoctorID,
-			AssignedDoctorFirstName: patient.AssignedDoctorFirstName,
-			AssignedDoctorLastName:  patient.AssignedDoctorLastName,
-			BodyTemperature:         patient.BodyTemperature,
-			PulseRate:               patient.PulseRate,
-			RespirationRate:         patient.RespirationRate,
-			SystolicPressure:        patient.SystolicPressure,
-			DiastolicPressure:       patient.DiastolicPressure,
-		}
-		for med := range patient.CurrentPrescribedMeds {
-			patientResp.CurrentPrescribedMeds = append(patientResp.CurrentPrescribedMeds, Medication{
-				Name: med,
-			})
-		}
-		for disease := range patient.CurrentDiseases {
-			patientResp.CurrentDiseases = append(patientResp.CurrentDiseases, Disease{
-				Name: disease,
-			})
+// earlyWarningScore is nil for patients without any reading.
+func earlyWarningScore(recordedAt *time.Time, in news2.Inputs) *news2.Score {
+	if recordedAt == nil {
+		return nil
+	}
+	score := news2.Compute(in)
+	return &score
+}
+
+// dashboardIDParam reads the id query parameter. When it is missing and
+// defaultOwn is set, the caller's own id is used instead.
+func dashboardIDParam(ctx *gin.Context, param string, defaultOwn bool, own int) (int, bool) {
+	value := ctx.Query(param)
+	if value == "" {
+		if defaultOwn {
+			return own, true
 		}
-		resp.Patients = append(resp.Patients, patientResp)
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" is required")
+		return 0, false
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" must be an integer")
+		return 0, false
+	}
+	return id, true
+}
+
+// toMedications never returns nil so that patients without medications are
+// rendered with an empty array instead of null.
+func toMedications(orders model.DashboardMedications) []Medication {
+	meds := make([]Medication, 0, len(orders))
+	for _, o := range orders {
+		meds = append(meds, Medication(o))
+	}
+	return meds
+}
+
+func toDiseases(diagnoses model.DashboardDiagnoses) []Disease {
+	diseases := make([]Disease, 0, len(diagnoses))
+	for _, d := range diagnoses {
+		diseases = append(diseases, Disease{
+			DiagnosisID:  d.DiagnosisID,
+			Name:         d.Description,
+			ICD10Code:    d.ICD10Code,
+			OnsetDate:    d.OnsetDate,
+			ResolvedDate: d.ResolvedDate,
+			IsPrimary:    d.IsPrimary,
+		})
 	}
-	ctx.JSON(http.StatusOK, resp)
+	return diseases
 }
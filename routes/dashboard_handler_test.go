@@ -0,0 +1,233 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"health-care-backend/auth"
+	"health-care-backend/events"
+	"health-care-backend/policy"
+	"health-care-backend/repository/memory"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// bornYearsAgo is the date of birth of someone turning age yesterday.
+func bornYearsAgo(age int) time.Time {
+	return time.Now().UTC().AddDate(-age, 0, -1)
+}
+
+// dashboardWard has doctor 1 with patients 1 to 4, nurse 1 looking after
+// patients 1 to 3 and nurse 2 after patient 5 of doctor 2. Patient 4 has been
+// discharged. Patient 1's latest reading scores NEWS2 3, patient 2's 0 and
+// patient 3 has none.
+func dashboardWard() *memory.Store {
+	s := memory.NewStore()
+	s.AddDoctor(model.StaffMember{ID: 1, FirstName: "Gregory", LastName: "House"})
+	s.AddDoctor(model.StaffMember{ID: 2, FirstName: "Lisa", LastName: "Cuddy"})
+	s.AddNurse(model.StaffMember{ID: 1, FirstName: "Carla", LastName: "Espinosa"})
+	s.AddNurse(model.StaffMember{ID: 2, FirstName: "Laverne", LastName: "Roberts"})
+	day := time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+	for _, p := range []model.Patient{
+		{PatientID: 1, FirstName: "Ann", LastName: "Miller", DOB: bornYearsAgo(70), BloodType: "A+", DoctorID: 1},
+		{PatientID: 2, FirstName: "Bob", LastName: "Adams", DOB: bornYearsAgo(35), BloodType: "O-", DoctorID: 1},
+		{PatientID: 3, FirstName: "Cid", LastName: "Baker", DOB: bornYearsAgo(52), BloodType: "A+", DoctorID: 1},
+		{PatientID: 4, FirstName: "Dee", LastName: "Clark", DOB: bornYearsAgo(44), BloodType: "B+", DoctorID: 1, DischargedAt: &day},
+		{PatientID: 5, FirstName: "Eve", LastName: "Davis", DOB: bornYearsAgo(61), BloodType: "AB+", DoctorID: 2},
+	} {
+		s.AddPatient(p)
+	}
+	for pid := 1; pid <= 3; pid++ {
+		s.AssignNurse(pid, 1)
+	}
+	s.AssignNurse(5, 2)
+	s.AddVitalSign(model.VitalSign{PatientID: 1, IssueTime: day, BodyTemperature: 98.6, PulseRate: 140, RespirationRate: 16, SystolicPressure: 120, DiastolicPressure: 80})
+	s.AddVitalSign(model.VitalSign{PatientID: 2, IssueTime: day, BodyTemperature: 98.6, PulseRate: 70, RespirationRate: 16, SystolicPressure: 120, DiastolicPressure: 80})
+	s.AddMedicationOrder(model.MedicationOrder{OrderID: 1, PatientID: 1, Name: "Metformin", StartDate: model.Date{Time: day}, Status: model.MedicationActive})
+	s.AddMedicationOrder(model.MedicationOrder{OrderID: 2, PatientID: 2, Name: "Warfarin", StartDate: model.Date{Time: day}, Status: model.MedicationDiscontinued})
+	s.AddDiagnosis(model.Diagnosis{DiagnosisID: 1, PatientID: 1, ICD10Code: strPtr("E11.9"), Description: "Type 2 diabetes", IsPrimary: true})
+	return s
+}
+
+// getDashboard serves target through the dashboard routes as identity and decodes
+// the response of a successful request into resp.
+func getDashboard(t *testing.T, h *DashboardHandler, identity auth.Identity, target string, resp interface{}) int {
+	t.Helper()
+	router := gin.New()
+	identify := func(ctx *gin.Context) {
+		auth.SetIdentity(ctx, identity)
+	}
+	router.GET("/dashboard/patient", identify, requirePermission(policy.ReadPatientDashboard), h.GetPatientDashboard)
+	router.GET("/dashboard/doctor", identify, requirePermission(policy.ReadDoctorDashboard), h.GetDoctorDashboard)
+	router.GET("/dashboard/nurse", identify, requirePermission(policy.ReadNurseDashboard), h.GetNurseDashboard)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code == http.StatusOK && resp != nil {
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), resp))
+	}
+	return rec.Code
+}
+
+func doctorPatientIDs(resp DoctorDashboardResp) []int {
+	ids := []int{}
+	for _, p := range resp.Patients {
+		ids = append(ids, p.PatientID)
+	}
+	return ids
+}
+
+func Test_PatientDashboardHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := dashboardWard()
+	h := NewDashboardHandler(zap.NewNop(), store, store, events.NewBroker())
+
+	var resp PatientDashboardResp
+	self := auth.Identity{UserID: 11, Role: "patient", PatientID: intPtr(1)}
+	if assert.Equal(t, http.StatusOK, getDashboard(t, h, self, "/dashboard/patient", &resp)) {
+		assert.Equal(t, "Miller", resp.LastName)
+		assert.Equal(t, "House", resp.AssignedDoctorLastName)
+		assert.Equal(t, 140, *resp.PulseRate)
+		if assert.Len(t, resp.CurrentPrescribedMeds, 1) {
+			assert.Equal(t, "Metformin", resp.CurrentPrescribedMeds[0].Name)
+		}
+		if assert.Len(t, resp.CurrentDiseases, 1) {
+			assert.Equal(t, "Type 2 diabetes", resp.CurrentDiseases[0].Name)
+		}
+	}
+	assert.Equal(t, http.StatusForbidden, getDashboard(t, h, self, "/dashboard/patient?patient_id=2", nil))
+
+	resp = PatientDashboardResp{}
+	admin := auth.Identity{UserID: 1, Role: "admin"}
+	if assert.Equal(t, http.StatusOK, getDashboard(t, h, admin, "/dashboard/patient?patient_id=3", &resp)) {
+		assert.Nil(t, resp.VitalsRecordedAt)
+		assert.Equal(t, []Medication{}, resp.CurrentPrescribedMeds)
+	}
+	assert.Equal(t, http.StatusForbidden, getDashboard(t, h, admin, "/dashboard/patient?patient_id=9", nil))
+}
+
+func Test_DoctorDashboardHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := dashboardWard()
+	h := NewDashboardHandler(zap.NewNop(), store, store, events.NewBroker())
+	doctor := auth.Identity{UserID: 21, Role: "doctor", DoctorID: intPtr(1)}
+
+	cases := []struct {
+		query string
+		ids   []int
+	}{
+		{"", []int{2, 3, 1}},
+		{"sort=risk", []int{1, 2, 3}},
+		{"sort=age&order=desc", []int{1, 3, 2}},
+		{"disease=e11", []int{1}},
+		{"medication=warfarin", []int{}},
+		{"blood_type=a%2B", []int{3, 1}},
+		{"min_age=40&max_age=60", []int{3}},
+	}
+	for _, c := range cases {
+		var resp DoctorDashboardResp
+		if assert.Equal(t, http.StatusOK, getDashboard(t, h, doctor, "/dashboard/doctor?"+c.query, &resp), c.query) {
+			assert.Equal(t, c.ids, doctorPatientIDs(resp), c.query)
+			assert.Nil(t, resp.NextCursor, c.query)
+		}
+	}
+
+	var resp DoctorDashboardResp
+	getDashboard(t, h, doctor, "/dashboard/doctor?sort=risk", &resp)
+	if assert.Len(t, resp.Patients, 3) {
+		assert.Equal(t, 3, resp.Patients[0].NEWS2.Total)
+		assert.Nil(t, resp.Patients[2].NEWS2)
+	}
+
+	assert.Equal(t, http.StatusForbidden, getDashboard(t, h, doctor, "/dashboard/doctor?doctor_id=2", nil))
+	nurse := auth.Identity{UserID: 31, Role: "nurse", NurseID: intPtr(1)}
+	assert.Equal(t, http.StatusForbidden, getDashboard(t, h, nurse, "/dashboard/doctor?doctor_id=1", nil))
+	assert.Equal(t, http.StatusBadRequest, getDashboard(t, h, doctor, "/dashboard/doctor?sort=name", nil))
+}
+
+func Test_DashboardHandlerPaging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := dashboardWard()
+	h := NewDashboardHandler(zap.NewNop(), store, store, events.NewBroker())
+	doctor := auth.Identity{UserID: 21, Role: "doctor", DoctorID: intPtr(1)}
+
+	for _, sort := range []string{"last_name", "age", "vitals_time", "risk"} {
+		var ids []int
+		next := ""
+		for page := 0; page < 5; page++ {
+			u := "/dashboard/doctor?limit=1&sort=" + sort
+			if next != "" {
+				u += "&cursor=" + url.QueryEscape(next)
+			}
+			var resp DoctorDashboardResp
+			if !assert.Equal(t, http.StatusOK, getDashboard(t, h, doctor, u, &resp), sort) {
+				break
+			}
+			ids = append(ids, doctorPatientIDs(resp)...)
+			if resp.NextCursor == nil {
+				break
+			}
+			next = *resp.NextCursor
+		}
+		assert.ElementsMatch(t, []int{1, 2, 3}, ids, sort)
+		assert.Len(t, ids, 3, sort)
+	}
+
+	var resp DoctorDashboardResp
+	getDashboard(t, h, doctor, "/dashboard/doctor?limit=1&sort=risk", &resp)
+	if assert.NotNil(t, resp.NextCursor) {
+		// a cursor is only valid for the sort order it was issued for
+		u := "/dashboard/doctor?limit=1&sort=age&cursor=" + url.QueryEscape(*resp.NextCursor)
+		assert.Equal(t, http.StatusBadRequest, getDashboard(t, h, doctor, u, nil))
+	}
+}
+
+func Test_NurseDashboardHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := dashboardWard()
+	h := NewDashboardHandler(zap.NewNop(), store, store, events.NewBroker())
+	nurse := auth.Identity{UserID: 31, Role: "nurse", NurseID: intPtr(1)}
+
+	var resp NurseDashboardResp
+	if assert.Equal(t, http.StatusOK, getDashboard(t, h, nurse, "/dashboard/nurse?sort=risk&limit=2", &resp)) {
+		if assert.Len(t, resp.Patients, 2) {
+			assert.Equal(t, []int{1, 2}, []int{resp.Patients[0].PatientID, resp.Patients[1].PatientID})
+			assert.Equal(t, "Espinosa", resp.Patients[0].NurseLastName)
+		}
+		assert.NotNil(t, resp.NextCursor)
+	}
+	assert.Equal(t, http.StatusForbidden, getDashboard(t, h, nurse, "/dashboard/nurse?nurse_id=2", nil))
+
+	resp = NurseDashboardResp{}
+	admin := auth.Identity{UserID: 1, Role: "admin"}
+	if assert.Equal(t, http.StatusOK, getDashboard(t, h, admin, "/dashboard/nurse?nurse_id=2", &resp)) {
+		if assert.Len(t, resp.Patients, 1) {
+			assert.Equal(t, 5, resp.Patients[0].PatientID)
+			assert.Equal(t, "Cuddy", resp.Patients[0].AssignedDoctorLastName)
+		}
+	}
+	patient := auth.Identity{UserID: 11, Role: "patient", PatientID: intPtr(1)}
+	assert.Equal(t, http.StatusForbidden, getDashboard(t, h, patient, "/dashboard/nurse?nurse_id=1", nil))
+}
+
+func Test_DashboardHandlerTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := dashboardWard()
+	h := NewDashboardHandler(zap.NewNop(), store, store, events.NewBroker())
+	router := gin.New()
+	router.GET("/dashboard/doctor", func(ctx *gin.Context) {
+		auth.SetIdentity(ctx, auth.Identity{UserID: 21, Role: "doctor", DoctorID: intPtr(1)})
+	}, requirePermission(policy.ReadDoctorDashboard), h.GetDoctorDashboard)
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard/doctor", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
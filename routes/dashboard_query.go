@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	repository "health-care-backend/repository"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDashboardLimit = 50
+	maxDashboardLimit     = 200
+)
+
+var errInvalidCursor = errors.New("cursor is invalid or belongs to another sort order")
+
+// dashboardCursor is the opaque next_cursor of a dashboard page, base64
+// encoded JSON. It carries the sort order it was issued for.
+type dashboardCursor struct {
+	Sort       repository.DashboardSort `json:"sort"`
+	Descending bool                     `json:"desc"`
+	Value      json.RawMessage          `json:"value"`
+	PatientID  int                      `json:"patient_id"`
+}
+
+// dashboardQueryParams reads the paging, sorting and filter parameters of the
+// doctor and nurse dashboards:
+//
+//	sort=last_name|age|vitals_time|risk  (default last_name)
+//	order=asc|desc  (default asc, desc for vitals_time and risk)
+//
+// risk sorts by the NEWS2 risk band of the latest reading, then its total.
+//
+//	limit=1..200  (default 50), cursor=<next_cursor of the previous page>
+//	disease, medication, blood_type, min_age, max_age
+func dashboardQueryParams(ctx *gin.Context) (repository.DashboardQuery, bool) {
+	q := repository.DashboardQuery{
+		Sort:       repository.DashboardSort(ctx.DefaultQuery("sort", string(repository.SortByLastName))),
+		Disease:    strings.TrimSpace(ctx.Query("disease")),
+		Medication: strings.TrimSpace(ctx.Query("medication")),
+		BloodType:  strings.ToUpper(strings.TrimSpace(ctx.Query("blood_type"))),
+	}
+	switch q.Sort {
+	case repository.SortByLastName, repository.SortByAge:
+	case repository.SortByVitalsTime, repository.SortByRisk:
+		q.Descending = true
+	default:
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "sort must be one of last_name, age, vitals_time or risk")
+		return q, false
+	}
+	switch ctx.Query("order") {
+	case "":
+	case "asc":
+		q.Descending = false
+	case "desc":
+		q.Descending = true
+	default:
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "order must be asc or desc")
+		return q, false
+	}
+
+	q.Limit = defaultDashboardLimit
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxDashboardLimit {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "limit must be between 1 and "+strconv.Itoa(maxDashboardLimit))
+			return q, false
+		}
+		q.Limit = limit
+	}
+	if value := ctx.Query("cursor"); value != "" {
+		after, err := decodeDashboardCursor(value, q)
+		if err != nil {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+			return q, false
+		}
+		q.After = &after
+	}
+
+	if q.BloodType != "" && !validBloodTypes[q.BloodType] {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "blood_type must be one of A+, A-, B+, B-, AB+, AB-, O+ or O-")
+		return q, false
+	}
+	for param, dst := range map[string]**int{"min_age": &q.MinAge, "max_age": &q.MaxAge} {
+		if value := ctx.Query(param); value != "" {
+			age, err := strconv.Atoi(value)
+			if err != nil || age < 0 {
+				respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" must be a non-negative integer")
+				return q, false
+			}
+			*dst = &age
+		}
+	}
+	return q, true
+}
+
+// dashboardSortValue picks the value of a row that q sorts by.
+func dashboardSortValue(sort repository.DashboardSort, lastName string, age int, vitalsRecordedAt *time.Time, news2Rank *int) interface{} {
+	switch sort {
+	case repository.SortByAge:
+		return age
+	case repository.SortByVitalsTime:
+		if vitalsRecordedAt == nil {
+			return nil
+		}
+		return *vitalsRecordedAt
+	case repository.SortByRisk:
+		if news2Rank == nil {
+			return nil
+		}
+		return *news2Rank
+	}
+	return lastName
+}
+
+func encodeDashboardCursor(q repository.DashboardQuery, value interface{}, pid int) (*string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(dashboardCursor{Sort: q.Sort, Descending: q.Descending, Value: raw, PatientID: pid})
+	if err != nil {
+		return nil, err
+	}
+	cursor := base64.RawURLEncoding.EncodeToString(data)
+	return &cursor, nil
+}
+
+func decodeDashboardCursor(s string, q repository.DashboardQuery) (repository.DashboardCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return repository.DashboardCursor{}, errInvalidCursor
+	}
+	var c dashboardCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != q.Sort || c.Descending != q.Descending || c.PatientID <= 0 {
+		return repository.DashboardCursor{}, errInvalidCursor
+	}
+	after := repository.DashboardCursor{PatientID: c.PatientID}
+	if string(c.Value) == "null" {
+		return after, nil
+	}
+	switch q.Sort {
+	case repository.SortByAge, repository.SortByRisk:
+		var v int
+		err = json.Unmarshal(c.Value, &v)
+		after.Value = v
+	case repository.SortByVitalsTime:
+		var v time.Time
+		err = json.Unmarshal(c.Value, &v)
+		after.Value = v
+	default:
+		var v string
+		err = json.Unmarshal(c.Value, &v)
+		after.Value = v
+	}
+	if err != nil {
+		return repository.DashboardCursor{}, errInvalidCursor
+	}
+	return after, nil
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	repository "health-care-backend/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseDashboardQuery(url string) (repository.DashboardQuery, int) {
+	var q repository.DashboardQuery
+	router := gin.New()
+	router.GET("/", func(ctx *gin.Context) {
+		var ok bool
+		if q, ok = dashboardQueryParams(ctx); ok {
+			ctx.Status(http.StatusOK)
+		}
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	return q, rec.Code
+}
+
+func Test_DashboardQueryParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	q, status := parseDashboardQuery("/")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, repository.SortByLastName, q.Sort)
+	assert.False(t, q.Descending)
+	assert.Equal(t, defaultDashboardLimit, q.Limit)
+
+	q, status = parseDashboardQuery("/?sort=risk&limit=10&blood_type=ab%2B&min_age=40&disease=I10")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, q.Descending)
+	assert.Equal(t, 10, q.Limit)
+	assert.Equal(t, "AB+", q.BloodType)
+	assert.Equal(t, 40, *q.MinAge)
+	assert.Nil(t, q.MaxAge)
+	assert.Equal(t, "I10", q.Disease)
+
+	for _, url := range []string{"/?sort=name", "/?order=up", "/?limit=0", "/?limit=201",
+		"/?blood_type=C", "/?max_age=-1", "/?cursor=nope"} {
+		_, status := parseDashboardQuery(url)
+		assert.Equal(t, http.StatusBadRequest, status, url)
+	}
+}
+
+func Test_DashboardCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	byTime := repository.DashboardQuery{Sort: repository.SortByVitalsTime, Descending: true}
+	recorded := time.Date(2023, 5, 1, 10, 30, 0, 123000, time.UTC)
+	cursor, err := encodeDashboardCursor(byTime, recorded, 7)
+	if assert.NoError(t, err) {
+		after, err := decodeDashboardCursor(*cursor, byTime)
+		assert.NoError(t, err)
+		assert.Equal(t, repository.DashboardCursor{Value: recorded, PatientID: 7}, after)
+
+		// a cursor is only valid for the order it was issued for
+		_, err = decodeDashboardCursor(*cursor, repository.DashboardQuery{Sort: repository.SortByVitalsTime})
+		assert.Error(t, err)
+
+		q, status := parseDashboardQuery("/?sort=vitals_time&cursor=" + *cursor)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, 7, q.After.PatientID)
+	}
+
+	// patients without readings have no sort value
+	cursor, err = encodeDashboardCursor(byTime, dashboardSortValue(byTime.Sort, "Smith", 40, nil, nil), 9)
+	if assert.NoError(t, err) {
+		after, err := decodeDashboardCursor(*cursor, byTime)
+		assert.NoError(t, err)
+		assert.Nil(t, after.Value)
+	}
+}
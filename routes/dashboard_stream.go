@@ -0,0 +1,232 @@
+package routes
+
+import (
+	"health-care-backend/events"
+	"health-care-backend/policy"
+	repository "health-care-backend/repository"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	dashboardStreamKeepAlive = 25 * time.Second
+	// streams end after a while so that clients reconnect with a fresh token
+	maxDashboardStreamDuration = 15 * time.Minute
+)
+
+// dashboardLoader loads the patients of a dashboard in display order, keyed
+// by patient id.
+type dashboardLoader func() ([]int, map[int]interface{}, error)
+
+// StreamNurseDashboard pushes a nurse dashboard as Server-Sent Events, see
+// streamDashboard. Access rules are those of GetNurseDashboard.
+func (h *DashboardHandler) StreamNurseDashboard(ctx *gin.Context) {
+	principal := principalFrom(ctx)
+	nid, ok := dashboardIDParam(ctx, "nurse_id", principal.Role == policy.Nurse, principal.NurseID)
+	if !ok {
+		return
+	}
+	if !principal.CanViewNurse(nid) {
+		respondError(ctx, http.StatusForbidden, CodeForbidden, "not allowed to view this nurse's dashboard")
+		return
+	}
+	h.streamDashboard(ctx, func() ([]int, map[int]interface{}, error) {
+		patients, _, err := h.nursePatients(ctx.Request.Context(), principal, nid, repository.DashboardQuery{})
+		if err != nil {
+			return nil, nil, err
+		}
+		order := make([]int, 0, len(patients))
+		rows := make(map[int]interface{}, len(patients))
+		for _, p := range patients {
+			order = append(order, p.PatientID)
+			rows[p.PatientID] = p
+		}
+		return order, rows, nil
+	})
+}
+
+// StreamDoctorDashboard pushes a doctor dashboard as Server-Sent Events, see
+// streamDashboard. Access rules are those of GetDoctorDashboard.
+func (h *DashboardHandler) StreamDoctorDashboard(ctx *gin.Context) {
+	principal := principalFrom(ctx)
+	did, ok := dashboardIDParam(ctx, "doctor_id", principal.Role == policy.Doctor, principal.DoctorID)
+	if !ok {
+		return
+	}
+	if !principal.CanViewDoctor(did) {
+		respondError(ctx, http.StatusForbidden, CodeForbidden, "not allowed to view this doctor's dashboard")
+		return
+	}
+	h.streamDashboard(ctx, func() ([]int, map[int]interface{}, error) {
+		patients, _, err := h.doctorPatients(ctx.Request.Context(), principal, did, repository.DashboardQuery{})
+		if err != nil {
+			return nil, nil, err
+		}
+		order := make([]int, 0, len(patients))
+		rows := make(map[int]interface{}, len(patients))
+		for _, p := range patients {
+			order = append(order, p.PatientID)
+			rows[p.PatientID] = p
+		}
+		return order, rows, nil
+	})
+}
+
+// streamDashboard sends a "snapshot" event with the whole dashboard, then a
+// "patient" event with the new row whenever a patient on it changes, or a
+// "removed" event with the patient_id once a patient left it, e.g. because
+// of a reassignment. Patients assigned later are sent as "patient" events
+// too. After the listener may have missed changes another snapshot is sent.
+// Streams hold every patient of the dashboard, unfiltered and by last name.
+// The stream ends after maxDashboardStreamDuration or when the server shuts
+// down; clients reconnect. Every snapshot and patient event is queued for the
+// audit log before it is sent, not when the stream ends.
+//
+// Changes of patients that are neither on the dashboard nor in the caller's
+// scope are ignored without reloading. Patients seen through an emergency
+// grant are dropped once it expires.
+func (h *DashboardHandler) streamDashboard(ctx *gin.Context, load dashboardLoader) {
+	// subscribe first so that no change between loading and streaming is lost
+	sub := h.broker.Subscribe()
+	defer h.broker.Unsubscribe(sub)
+	order, rows, err := load()
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	expiry, err := h.grantExpiry(ctx)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	defer func() { stopTimer(expiry) }()
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no")
+	// the server's write timeout is meant for ordinary responses, streams
+	// are bounded by maxDashboardStreamDuration instead
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+	h.sendSnapshot(ctx, order, rows)
+
+	keepAlive := time.NewTicker(dashboardStreamKeepAlive)
+	defer keepAlive.Stop()
+	deadline := time.NewTimer(maxDashboardStreamDuration)
+	defer deadline.Stop()
+	for {
+		var expired <-chan time.Time
+		if expiry != nil {
+			expired = expiry.C
+		}
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-h.broker.Closed():
+			return
+		case <-deadline.C:
+			return
+		case <-keepAlive.C:
+			io.WriteString(ctx.Writer, ": keep-alive\n\n")
+			ctx.Writer.Flush()
+		case <-expired:
+			newOrder, newRows, err := load()
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			for _, pid := range order {
+				if _, ok := newRows[pid]; !ok {
+					ctx.SSEvent("removed", gin.H{"patient_id": pid})
+				}
+			}
+			ctx.Writer.Flush()
+			order, rows = newOrder, newRows
+			if expiry, err = h.grantExpiry(ctx); err != nil {
+				ctx.Error(err)
+				return
+			}
+		case <-sub.Ready():
+			pids, err := h.affected(ctx, sub.Take(), rows)
+			if err != nil {
+				ctx.Error(err)
+				return
+			}
+			if len(pids) == 0 {
+				continue
+			}
+			newOrder, newRows, err := load()
+			if err != nil {
+				// the client reconnects and starts over with a snapshot
+				ctx.Error(err)
+				return
+			}
+			if pids[0] == events.AllPatients {
+				h.sendSnapshot(ctx, newOrder, newRows)
+			} else {
+				for _, pid := range pids {
+					if row, ok := newRows[pid]; ok {
+						auditShown(ctx, pid)
+						ctx.SSEvent("patient", row)
+					} else if _, ok := rows[pid]; ok {
+						ctx.SSEvent("removed", gin.H{"patient_id": pid})
+					}
+				}
+				ctx.Writer.Flush()
+			}
+			order, rows = newOrder, newRows
+			// a change may have been a new grant
+			stopTimer(expiry)
+			if expiry, err = h.grantExpiry(ctx); err != nil {
+				ctx.Error(err)
+				return
+			}
+		}
+	}
+}
+
+// affected keeps the changed patients that are on the dashboard or may join
+// it, i.e. are in the caller's scope. AllPatients is always kept.
+func (h *DashboardHandler) affected(ctx *gin.Context, pids []int, rows map[int]interface{}) ([]int, error) {
+	kept := pids[:0]
+	for _, pid := range pids {
+		if _, ok := rows[pid]; ok || pid == events.AllPatients {
+			kept = append(kept, pid)
+			continue
+		}
+		ok, err := h.access.CanAccessPatient(ctx.Request.Context(), principalFrom(ctx), pid)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			kept = append(kept, pid)
+		}
+	}
+	return kept, nil
+}
+
+// grantExpiry fires when the caller's first emergency grant expires; nil
+// without grants.
+func (h *DashboardHandler) grantExpiry(ctx *gin.Context) (*time.Timer, error) {
+	next, err := h.access.NextGrantExpiry(ctx.Request.Context(), principalFrom(ctx))
+	if err != nil || next == nil {
+		return nil, err
+	}
+	return time.NewTimer(time.Until(*next)), nil
+}
+
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+func (h *DashboardHandler) sendSnapshot(ctx *gin.Context, order []int, rows map[int]interface{}) {
+	patients := make([]interface{}, 0, len(order))
+	for _, pid := range order {
+		patients = append(patients, rows[pid])
+	}
+	auditShown(ctx, order...)
+	ctx.SSEvent("snapshot", gin.H{"patients": patients})
+	ctx.Writer.Flush()
+}
@@ -0,0 +1,187 @@
+package routes
+
+import (
+	"context"
+	"health-care-backend/auth"
+	"health-care-backend/events"
+	"health-care-backend/policy"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// streamedDashboard serves the nurse dashboard from patients and signals
+// every load. It is also the access repository: the nurse's scope is the
+// dashboard, plus patient granted until expiry when set.
+type streamedDashboard struct {
+	stubDashboard
+	mu       sync.Mutex
+	patients []int
+	granted  int
+	expiry   time.Time
+	loads    int
+	loaded   chan struct{}
+}
+
+func (s *streamedDashboard) SelectNurseDashboard(ctx context.Context, p policy.Principal, nid int, q repository.DashboardQuery) ([]model.NurseDashboardView, error) {
+	s.mu.Lock()
+	views := make([]model.NurseDashboardView, 0, len(s.patients))
+	for _, pid := range s.patients {
+		views = append(views, model.NurseDashboardView{NurseID: nid, PatientID: pid})
+	}
+	if s.granted != 0 && time.Now().Before(s.expiry) {
+		views = append(views, model.NurseDashboardView{NurseID: nid, PatientID: s.granted})
+	}
+	s.loads++
+	s.mu.Unlock()
+	select {
+	case s.loaded <- struct{}{}:
+	case <-ctx.Done():
+	}
+	return views, nil
+}
+
+func (s *streamedDashboard) CanAccessPatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, in := range s.patients {
+		if in == pid {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *streamedDashboard) CanManagePatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	return s.CanAccessPatient(ctx, p, pid)
+}
+
+func (s *streamedDashboard) NextGrantExpiry(ctx context.Context, p policy.Principal) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.granted == 0 || !time.Now().Before(s.expiry) {
+		return nil, nil
+	}
+	expiry := s.expiry
+	return &expiry, nil
+}
+
+func (s *streamedDashboard) setPatients(pids ...int) {
+	s.mu.Lock()
+	s.patients = pids
+	s.mu.Unlock()
+}
+
+func Test_StreamNurseDashboard(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &streamedDashboard{patients: []int{1, 2}, loaded: make(chan struct{})}
+	broker := events.NewBroker()
+	h := NewDashboardHandler(zap.NewNop(), repo, repo, broker)
+	log := &stubAuditQueue{}
+	router := gin.New()
+	router.GET("/stream", func(ctx *gin.Context) {
+		auth.SetIdentity(ctx, auth.Identity{UserID: 3, Role: "nurse", NurseID: intPtr(1)})
+	}, auditTrail(zap.NewNop(), log), h.StreamNurseDashboard)
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(reqCtx))
+	}()
+	<-repo.loaded
+
+	// patient 2 is reassigned, patient 3 assigned, patient 9 is not ours
+	// and does not cause a reload
+	repo.setPatients(1, 3)
+	broker.Publish(2)
+	<-repo.loaded
+	broker.Publish(3)
+	<-repo.loaded
+	broker.Publish(9)
+	broker.Publish(1)
+	<-repo.loaded
+	cancel()
+	<-done
+	assert.Equal(t, 4, repo.loads)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	if assert.Len(t, events, 4) {
+		assert.True(t, strings.HasPrefix(events[0], "event:snapshot\n"))
+		assert.Equal(t, "event:removed\ndata:{\"patient_id\":2}", events[1])
+		assert.True(t, strings.HasPrefix(events[2], "event:patient\n"))
+		assert.Contains(t, events[2], `"patient_id":3`)
+		assert.Contains(t, events[3], `"patient_id":1`)
+	}
+	// every patient sent is audited as it is sent, the end of the stream
+	// records none
+	var audited [][]int
+	for _, e := range log.entries {
+		audited = append(audited, e.PatientIDs)
+	}
+	assert.Equal(t, [][]int{{1, 2}, {3}, {1}, nil}, audited)
+}
+
+func Test_StreamDropsExpiredGrants(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// patient 2 is on the dashboard through a grant that is about to expire
+	repo := &streamedDashboard{patients: []int{1}, granted: 2, expiry: time.Now().Add(50 * time.Millisecond), loaded: make(chan struct{})}
+	broker := events.NewBroker()
+	h := NewDashboardHandler(zap.NewNop(), repo, repo, broker)
+	router := gin.New()
+	router.GET("/stream", func(ctx *gin.Context) {
+		auth.SetIdentity(ctx, auth.Identity{UserID: 3, Role: "nurse", NurseID: intPtr(1)})
+	}, h.StreamNurseDashboard)
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(reqCtx))
+	}()
+	<-repo.loaded
+	<-repo.loaded
+	cancel()
+	<-done
+
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	if assert.Len(t, events, 2) {
+		assert.True(t, strings.HasPrefix(events[0], "event:snapshot\n"))
+		assert.Equal(t, "event:removed\ndata:{\"patient_id\":2}", events[1])
+	}
+}
+
+func Test_StreamEndsOnShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &streamedDashboard{patients: []int{1}, loaded: make(chan struct{})}
+	broker := events.NewBroker()
+	h := NewDashboardHandler(zap.NewNop(), repo, stubAccess{}, broker)
+	router := gin.New()
+	router.GET("/stream", func(ctx *gin.Context) {
+		auth.SetIdentity(ctx, auth.Identity{UserID: 3, Role: "nurse", NurseID: intPtr(1)})
+	}, h.StreamNurseDashboard)
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	}()
+	<-repo.loaded
+	broker.Close()
+	<-done
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "event:snapshot\n"))
+}
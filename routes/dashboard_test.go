@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"context"
+	"health-care-backend/auth"
+	"health-care-backend/events"
+	"health-care-backend/news2"
+	"health-care-backend/policy"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// stubDashboard returns one row for any query and records the principal.
+type stubDashboard struct {
+	principal policy.Principal
+}
+
+func (s *stubDashboard) SelectPatientDashboard(ctx context.Context, p policy.Principal, pid int) ([]model.PatientDashboardView, error) {
+	s.principal = p
+	return []model.PatientDashboardView{{ID: pid}}, nil
+}
+
+func (s *stubDashboard) SelectDoctorDashboard(ctx context.Context, p policy.Principal, did int, q repository.DashboardQuery) ([]model.DoctorDashboardView, error) {
+	s.principal = p
+	return []model.DoctorDashboardView{{AssignedDoctorID: did}}, nil
+}
+
+func (s *stubDashboard) SelectNurseDashboard(ctx context.Context, p policy.Principal, nid int, q repository.DashboardQuery) ([]model.NurseDashboardView, error) {
+	s.principal = p
+	return []model.NurseDashboardView{{NurseID: nid}}, nil
+}
+
+// stubAccess lets every principal see only patient 1.
+type stubAccess struct{}
+
+func (stubAccess) CanAccessPatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	return pid == 1, nil
+}
+
+func (stubAccess) CanManagePatient(ctx context.Context, p policy.Principal, pid int) (bool, error) {
+	return pid == 1, nil
+}
+
+func (stubAccess) NextGrantExpiry(ctx context.Context, p policy.Principal) (*time.Time, error) {
+	return nil, nil
+}
+
+func Test_DashboardAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &stubDashboard{}
+	h := NewDashboardHandler(zap.NewNop(), repo, stubAccess{}, events.NewBroker())
+
+	cases := []struct {
+		identity auth.Identity
+		perm     policy.Permission
+		handler  gin.HandlerFunc
+		url      string
+		status   int
+	}{
+		{auth.Identity{Role: "patient", PatientID: intPtr(1)}, policy.ReadPatientDashboard, h.GetPatientDashboard, "/?", http.StatusOK},
+		{auth.Identity{Role: "patient", PatientID: intPtr(1)}, policy.ReadPatientDashboard, h.GetPatientDashboard, "/?patient_id=2", http.StatusForbidden},
+		{auth.Identity{Role: "nurse", NurseID: intPtr(1)}, policy.ReadPatientDashboard, h.GetPatientDashboard, "/?", http.StatusBadRequest},
+		{auth.Identity{Role: "doctor", DoctorID: intPtr(1)}, policy.ReadDoctorDashboard, h.GetDoctorDashboard, "/?", http.StatusOK},
+		{auth.Identity{Role: "doctor", DoctorID: intPtr(1)}, policy.ReadDoctorDashboard, h.GetDoctorDashboard, "/?doctor_id=2", http.StatusForbidden},
+		{auth.Identity{Role: "admin"}, policy.ReadDoctorDashboard, h.GetDoctorDashboard, "/?doctor_id=2", http.StatusOK},
+		{auth.Identity{Role: "nurse", NurseID: intPtr(1)}, policy.ReadDoctorDashboard, h.GetDoctorDashboard, "/?doctor_id=1", http.StatusForbidden},
+		{auth.Identity{Role: "nurse", NurseID: intPtr(3)}, policy.ReadNurseDashboard, h.GetNurseDashboard, "/?nurse_id=3", http.StatusOK},
+		{auth.Identity{Role: "nurse", NurseID: intPtr(3)}, policy.ReadNurseDashboard, h.GetNurseDashboard, "/?nurse_id=1", http.StatusForbidden},
+		{auth.Identity{Role: "patient", PatientID: intPtr(1)}, policy.ReadNurseDashboard, h.GetNurseDashboard, "/?nurse_id=1", http.StatusForbidden},
+	}
+	for _, c := range cases {
+		router := gin.New()
+		identity := c.identity
+		router.GET("/", func(ctx *gin.Context) {
+			auth.SetIdentity(ctx, identity)
+		}, requirePermission(c.perm), c.handler)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.url, nil))
+		assert.Equal(t, c.status, rec.Code, "%s %s", c.identity.Role, c.url)
+		if c.status == http.StatusOK {
+			assert.Equal(t, policy.Role(c.identity.Role), repo.principal.Role)
+		}
+	}
+}
+
+func Test_EarlyWarningScore(t *testing.T) {
+	assert.Nil(t, earlyWarningScore(nil, news2.Inputs{PulseRate: intPtr(140)}))
+	recorded := time.Now()
+	score := earlyWarningScore(&recorded, news2.Inputs{PulseRate: intPtr(140)})
+	if assert.NotNil(t, score) {
+		assert.Equal(t, 3, score.Total)
+		assert.False(t, score.Complete)
+	}
+}
+
+func Test_DashboardListsKeepOrder(t *testing.T) {
+	var meds model.DashboardMedications
+	assert.NoError(t, meds.Scan(`[{"order_id": 9, "name": "Metformin", "start_date": "2023-01-02", "status": "active"},
+		{"order_id": 4, "name": "Lisinopril", "start_date": "2023-03-01", "status": "held"}]`))
+	var diagnoses model.DashboardDiagnoses
+	assert.NoError(t, diagnoses.Scan(`[{"diagnosis_id": 5, "description": "Hypertension", "is_primary": true},
+		{"diagnosis_id": 2, "description": "Type 2 diabetes"}]`))
+
+	got := toMedications(meds)
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, []int{9, 4}, []int{got[0].OrderID, got[1].OrderID})
+		assert.Equal(t, "Lisinopril", got[1].Name)
+	}
+	diseases := toDiseases(diagnoses)
+	if assert.Len(t, diseases, 2) {
+		assert.Equal(t, []int{5, 2}, []int{diseases[0].DiagnosisID, diseases[1].DiagnosisID})
+	}
+	assert.Equal(t, []Medication{}, toMedications(nil))
+}
@@ -0,0 +1,239 @@
+package routes
+
+import (
+	"errors"
+	"health-care-backend/icd10"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type DiagnosisHandler struct {
+	logger *zap.Logger
+	repo   repository.Diagnoses
+}
+
+func NewDiagnosisHandler(logger *zap.Logger, repo repository.Diagnoses) *DiagnosisHandler {
+	return &DiagnosisHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type DiagnosisResp struct {
+	DiagnosisID  int         `json:"diagnosis_id"`
+	PatientID    int         `json:"patient_id"`
+	ICD10Code    *string     `json:"icd10_code"`
+	Description  string      `json:"description"`
+	OnsetDate    *model.Date `json:"onset_date"`
+	ResolvedDate *model.Date `json:"resolved_date"`
+	IsPrimary    bool        `json:"is_primary"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// DiagnosisReq is the body of POST and PATCH. The description is taken from
+// the ICD-10 table, and the code can only be set on POST.
+type DiagnosisReq struct {
+	ICD10Code    *string `json:"icd10_code"`
+	OnsetDate    *string `json:"onset_date"`
+	ResolvedDate *string `json:"resolved_date"`
+	IsPrimary    *bool   `json:"is_primary"`
+}
+
+type DiagnosisCountResp struct {
+	ICD10Code   string `json:"icd10_code"`
+	Description string `json:"description"`
+	Patients    int    `json:"patients"`
+}
+
+// ListDiagnoses returns the open diagnoses of the patient, or all of them
+// with ?include_resolved=true.
+func (h *DiagnosisHandler) ListDiagnoses(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	includeResolved := ctx.Query("include_resolved") == "true"
+	diagnoses, err := h.repo.ListDiagnoses(ctx.Request.Context(), pid, includeResolved)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	resp := make([]DiagnosisResp, 0, len(diagnoses))
+	for _, d := range diagnoses {
+		resp = append(resp, toDiagnosisResp(d))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"diagnoses": resp})
+}
+
+func (h *DiagnosisHandler) GetDiagnosis(ctx *gin.Context) {
+	pid, did, ok := diagnosisParams(ctx)
+	if !ok {
+		return
+	}
+	d, err := h.repo.SelectDiagnosis(ctx.Request.Context(), pid, did)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toDiagnosisResp(d))
+}
+
+func (h *DiagnosisHandler) CreateDiagnosis(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req DiagnosisReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	d := model.Diagnosis{PatientID: pid}
+	if err := req.apply(&d, true, time.Now()); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	created, err := h.repo.InsertDiagnosis(ctx.Request.Context(), d)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toDiagnosisResp(created))
+}
+
+// ModifyDiagnosis changes the onset or resolved date or the primary flag.
+func (h *DiagnosisHandler) ModifyDiagnosis(ctx *gin.Context) {
+	pid, did, ok := diagnosisParams(ctx)
+	if !ok {
+		return
+	}
+	var req DiagnosisReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	d, err := h.repo.SelectDiagnosis(ctx.Request.Context(), pid, did)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	if err := req.apply(&d, false, time.Now()); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	updated, err := h.repo.UpdateDiagnosis(ctx.Request.Context(), d)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toDiagnosisResp(updated))
+}
+
+// CountDiagnoses reports the number of patients per open coded diagnosis.
+func (h *DiagnosisHandler) CountDiagnoses(ctx *gin.Context) {
+	counts, err := h.repo.CountDiagnoses(ctx.Request.Context())
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	resp := make([]DiagnosisCountResp, 0, len(counts))
+	for _, c := range counts {
+		resp = append(resp, DiagnosisCountResp{
+			ICD10Code:   c.ICD10Code,
+			Description: c.Description,
+			Patients:    c.Patients,
+		})
+	}
+	ctx.JSON(http.StatusOK, gin.H{"diagnoses": resp})
+}
+
+func (h *DiagnosisHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient or diagnosis not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, "patient is discharged")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+// apply validates the request and copies it onto d. With create set, the
+// code is required and must be in the ICD-10 table; otherwise it is immutable.
+func (req *DiagnosisReq) apply(d *model.Diagnosis, create bool, now time.Time) error {
+	if create {
+		if req.ICD10Code == nil {
+			return errors.New("icd10_code is required")
+		}
+		code, ok := icd10.Lookup(*req.ICD10Code)
+		if !ok {
+			return errors.New("icd10_code is not a known ICD-10 code")
+		}
+		d.ICD10Code = &code.Code
+		d.Description = code.Description
+	} else if req.ICD10Code != nil {
+		return errors.New("icd10_code cannot be changed")
+	}
+	today := model.NewDate(now)
+	if req.OnsetDate != nil {
+		onset, err := model.ParseDate(*req.OnsetDate)
+		if err != nil {
+			return errors.New("onset_date must be a date formatted as YYYY-MM-DD")
+		}
+		d.OnsetDate = &onset
+	}
+	if req.ResolvedDate != nil {
+		resolved, err := model.ParseDate(*req.ResolvedDate)
+		if err != nil {
+			return errors.New("resolved_date must be a date formatted as YYYY-MM-DD")
+		}
+		d.ResolvedDate = &resolved
+	}
+	if req.IsPrimary != nil {
+		d.IsPrimary = *req.IsPrimary
+	}
+	if d.OnsetDate != nil && d.OnsetDate.After(today.Time) {
+		return errors.New("onset_date must not be in the future")
+	}
+	if d.ResolvedDate != nil {
+		if d.ResolvedDate.After(today.Time) {
+			return errors.New("resolved_date must not be in the future")
+		}
+		if d.OnsetDate != nil && d.ResolvedDate.Before(d.OnsetDate.Time) {
+			return errors.New("resolved_date must not be before onset_date")
+		}
+	}
+	return nil
+}
+
+func diagnosisParams(ctx *gin.Context) (int, int, bool) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return 0, 0, false
+	}
+	did, err := strconv.Atoi(ctx.Param("diagnosis_id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "diagnosis id must be an integer")
+		return 0, 0, false
+	}
+	return pid, did, true
+}
+
+func toDiagnosisResp(d model.Diagnosis) DiagnosisResp {
+	return DiagnosisResp{
+		DiagnosisID:  d.DiagnosisID,
+		PatientID:    d.PatientID,
+		ICD10Code:    d.ICD10Code,
+		Description:  d.Description,
+		OnsetDate:    d.OnsetDate,
+		ResolvedDate: d.ResolvedDate,
+		IsPrimary:    d.IsPrimary,
+		CreatedAt:    d.CreatedAt,
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	model "health-care-backend/repository/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func boolPtr(b bool) *bool { return &b }
+
+func Test_DiagnosisReqApply(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC)
+	req := DiagnosisReq{ICD10Code: strPtr("e119"), OnsetDate: strPtr("2020-02-03"), IsPrimary: boolPtr(true)}
+	var d model.Diagnosis
+	assert.NoError(t, req.apply(&d, true, now))
+	assert.Equal(t, "E11.9", *d.ICD10Code)
+	assert.Equal(t, "Type 2 diabetes mellitus without complications", d.Description)
+	assert.True(t, d.IsPrimary)
+
+	invalid := map[string]DiagnosisReq{
+		"missing code":    {},
+		"unknown code":    {ICD10Code: strPtr("X99.99")},
+		"future onset":    {ICD10Code: strPtr("I10"), OnsetDate: strPtr("2023-06-01")},
+		"resolved before": {ICD10Code: strPtr("I10"), OnsetDate: strPtr("2023-01-10"), ResolvedDate: strPtr("2023-01-01")},
+		"bad date":        {ICD10Code: strPtr("I10"), OnsetDate: strPtr("01/02/2023")},
+	}
+	for name, req := range invalid {
+		var d model.Diagnosis
+		assert.Error(t, req.apply(&d, true, now), name)
+	}
+
+	recode := DiagnosisReq{ICD10Code: strPtr("I10")}
+	assert.Error(t, recode.apply(&d, false, now))
+	resolve := DiagnosisReq{ResolvedDate: strPtr("2023-04-30")}
+	assert.NoError(t, resolve.apply(&d, false, now))
+	assert.Equal(t, "2023-04-30", d.ResolvedDate.String())
+}
@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultEmergencyAccessDuration = time.Hour
+	maxEmergencyAccessDuration     = 4 * time.Hour
+	minEmergencyReasonLength       = 10
+	maxEmergencyReasonLength       = 500
+)
+
+// EmergencyAccessHandler serves break-the-glass grants. Every grant is logged
+// as a warning so that it shows up in alerting, and listed in the compliance
+// report.
+type EmergencyAccessHandler struct {
+	logger *zap.Logger
+	repo   repository.EmergencyGrants
+}
+
+func NewEmergencyAccessHandler(logger *zap.Logger, repo repository.EmergencyGrants) *EmergencyAccessHandler {
+	return &EmergencyAccessHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type EmergencyGrantResp struct {
+	GrantID   int        `json:"grant_id"`
+	UserID    int        `json:"user_id"`
+	Username  string     `json:"username"`
+	PatientID int        `json:"patient_id"`
+	Reason    string     `json:"reason"`
+	ClientIP  string     `json:"client_ip"`
+	GrantedAt time.Time  `json:"granted_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	Active    bool       `json:"active"`
+}
+
+// EmergencyAccessReq asks for access to a patient out of the caller's scope.
+// DurationMinutes defaults to 60 and is capped at 240.
+type EmergencyAccessReq struct {
+	Reason          string `json:"reason"`
+	DurationMinutes *int   `json:"duration_minutes"`
+}
+
+// RequestAccess grants the caller access to the patient until the grant
+// expires. The justification is mandatory.
+func (h *EmergencyAccessHandler) RequestAccess(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req EmergencyAccessReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	duration, err := req.validate()
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	principal := principalFrom(ctx)
+	now := time.Now().UTC()
+	grant, err := h.repo.InsertEmergencyGrant(ctx.Request.Context(), model.EmergencyGrant{
+		UserID:    principal.UserID,
+		PatientID: pid,
+		Reason:    strings.TrimSpace(req.Reason),
+		ClientIP:  ctx.ClientIP(),
+		GrantedAt: now,
+		ExpiresAt: now.Add(duration),
+	})
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	h.logger.Warn("break-the-glass access granted",
+		zap.Int("grant id", grant.GrantID),
+		zap.Int("user id", grant.UserID),
+		zap.String("username", grant.Username),
+		zap.Int("patient id", grant.PatientID),
+		zap.Time("expires at", grant.ExpiresAt))
+	ctx.JSON(http.StatusCreated, toEmergencyGrantResp(grant, now))
+}
+
+// ListOwnGrants returns the caller's grants, newest first.
+func (h *EmergencyAccessHandler) ListOwnGrants(ctx *gin.Context) {
+	grants, err := h.repo.ListEmergencyGrants(ctx.Request.Context(), repository.EmergencyGrantFilter{UserID: principalFrom(ctx).UserID})
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	h.writeGrants(ctx, grants)
+}
+
+// RevokeGrant ends one of the caller's grants early.
+func (h *EmergencyAccessHandler) RevokeGrant(ctx *gin.Context) {
+	gid, err := strconv.Atoi(ctx.Param("grant_id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "grant id must be an integer")
+		return
+	}
+	principal := principalFrom(ctx)
+	grant, err := h.repo.SelectEmergencyGrant(ctx.Request.Context(), gid)
+	if err == nil && grant.UserID != principal.UserID {
+		err = repository.ErrNotFound
+	}
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	now := time.Now().UTC()
+	if grant, err = h.repo.RevokeEmergencyGrant(ctx.Request.Context(), gid, now); err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	auditPatients(ctx, grant.PatientID)
+	h.logger.Info("break-the-glass access revoked", zap.Int("grant id", gid), zap.Int("revoked by", principal.UserID))
+	ctx.JSON(http.StatusOK, toEmergencyGrantResp(grant, now))
+}
+
+// Report lists all grants for compliance review, optionally filtered by
+// user_id, patient_id and an RFC 3339 from/to window on the grant time.
+func (h *EmergencyAccessHandler) Report(ctx *gin.Context) {
+	var f repository.EmergencyGrantFilter
+	for param, dst := range map[string]*int{"user_id": &f.UserID, "patient_id": &f.PatientID} {
+		if value := ctx.Query(param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id <= 0 {
+				respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" must be a positive integer")
+				return
+			}
+			*dst = id
+		}
+	}
+	for param, dst := range map[string]**time.Time{"from": &f.From, "to": &f.To} {
+		if value := ctx.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" must be an RFC 3339 timestamp")
+				return
+			}
+			t = t.UTC()
+			*dst = &t
+		}
+	}
+	grants, err := h.repo.ListEmergencyGrants(ctx.Request.Context(), f)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	h.writeGrants(ctx, grants)
+}
+
+func (h *EmergencyAccessHandler) writeGrants(ctx *gin.Context, grants []model.EmergencyGrant) {
+	now := time.Now().UTC()
+	resp := make([]EmergencyGrantResp, 0, len(grants))
+	for _, g := range grants {
+		resp = append(resp, toEmergencyGrantResp(g, now))
+		auditPatients(ctx, g.PatientID)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"grants": resp})
+}
+
+func (h *EmergencyAccessHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "grant not found")
+	case errors.Is(err, repository.ErrInvalidReference):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, "grant has already expired or been revoked")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+// validate checks the justification and returns the grant duration.
+func (req EmergencyAccessReq) validate() (time.Duration, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) < minEmergencyReasonLength || len(reason) > maxEmergencyReasonLength {
+		return 0, fmt.Errorf("reason must be between %d and %d characters", minEmergencyReasonLength, maxEmergencyReasonLength)
+	}
+	if req.DurationMinutes == nil {
+		return defaultEmergencyAccessDuration, nil
+	}
+	duration := time.Duration(*req.DurationMinutes) * time.Minute
+	if duration <= 0 || duration > maxEmergencyAccessDuration {
+		return 0, fmt.Errorf("duration_minutes must be between 1 and %d", int(maxEmergencyAccessDuration.Minutes()))
+	}
+	return duration, nil
+}
+
+func toEmergencyGrantResp(g model.EmergencyGrant, now time.Time) EmergencyGrantResp {
+	return EmergencyGrantResp{
+		GrantID:   g.GrantID,
+		UserID:    g.UserID,
+		Username:  g.Username,
+		PatientID: g.PatientID,
+		Reason:    g.Reason,
+		ClientIP:  g.ClientIP,
+		GrantedAt: g.GrantedAt,
+		ExpiresAt: g.ExpiresAt,
+		RevokedAt: g.RevokedAt,
+		Active:    g.Active(now),
+	}
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EmergencyAccessReqValidate(t *testing.T) {
+	d, err := EmergencyAccessReq{Reason: "patient unresponsive in corridor"}.validate()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, d)
+
+	d, err = EmergencyAccessReq{Reason: "covering for night shift", DurationMinutes: intPtr(30)}.validate()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, d)
+
+	invalid := map[string]EmergencyAccessReq{
+		"missing reason": {},
+		"short reason":   {Reason: "  urgent   "},
+		"zero duration":  {Reason: "patient unresponsive", DurationMinutes: intPtr(0)},
+		"long duration":  {Reason: "patient unresponsive", DurationMinutes: intPtr(241)},
+	}
+	for name, req := range invalid {
+		_, err := req.validate()
+		assert.Error(t, err, name)
+	}
+}
@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode identifies the kind of an error. Codes are stable, clients should
+// branch on them rather than on the message, which may change.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest   ErrorCode = "invalid_request"
+	CodeUnauthorized     ErrorCode = "unauthorized"
+	CodeForbidden        ErrorCode = "forbidden"
+	CodeNotFound         ErrorCode = "not_found"
+	CodeConflict         ErrorCode = "conflict"
+	CodeInUse            ErrorCode = "in_use"
+	CodeInvalidReference ErrorCode = "invalid_reference"
+	CodeInternal         ErrorCode = "internal_error"
+	CodeTimeout          ErrorCode = "timeout"
+)
+
+// ErrorResp is the body of every error response. Error is safe to show to
+// users; it never holds database errors or patient data. RequestID links the
+// response to the server logs.
+type ErrorResp struct {
+	Code      ErrorCode `json:"code"`
+	Error     string    `json:"error"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// respondError writes an error response and stops the handler chain.
+func respondError(ctx *gin.Context, status int, code ErrorCode, msg string) {
+	ctx.AbortWithStatusJSON(status, ErrorResp{Code: code, Error: msg, RequestID: requestIDFrom(ctx)})
+}
+
+// respondInternalError answers 500 without revealing err, which is attached
+// to the context and logged by requestLogger. Queries cut off by the query
+// timeout answer 504, the client may retry them later.
+func respondInternalError(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	if errors.Is(err, context.DeadlineExceeded) {
+		respondError(ctx, http.StatusGatewayTimeout, CodeTimeout, "the database did not answer in time")
+		return
+	}
+	respondError(ctx, http.StatusInternalServerError, CodeInternal, "internal server error")
+}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_RespondInternalError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.InfoLevel)
+	router := gin.New()
+	router.Use(requestID(), recoverPanics(zap.New(core)), requestLogger(zap.New(core)))
+	router.GET("/patients/:id", func(ctx *gin.Context) {
+		respondInternalError(ctx, errors.New(`ERROR: value too long for "Alice Johnson"`))
+	})
+	router.GET("/panic", func(ctx *gin.Context) {
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/patients/1", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "Alice")
+	var body ErrorResp
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, CodeInternal, body.Code)
+	assert.Equal(t, rec.Header().Get(requestIDHeader), body.RequestID)
+
+	failed := logs.FilterMessage("request failed").All()
+	if assert.Len(t, failed, 1) {
+		fields := failed[0].ContextMap()
+		assert.Equal(t, "/patients/:id", fields["route"])
+		assert.Equal(t, body.RequestID, fields["request id"])
+		assert.Contains(t, fields["error"], "value too long")
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Len(t, logs.FilterMessage("panic while serving request").All(), 1)
+}
+
+func Test_RespondTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/patients", func(ctx *gin.Context) {
+		respondInternalError(ctx, fmt.Errorf("list patients: %w", context.DeadlineExceeded))
+	})
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/patients", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	var body ErrorResp
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, CodeTimeout, body.Code)
+}
@@ -0,0 +1,48 @@
+package routes
+
+import (
+	"fmt"
+	"health-care-backend/icd10"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultICD10Limit = 20
+	maxICD10Limit     = 100
+)
+
+// ICD10Handler serves the bundled ICD-10 code table.
+type ICD10Handler struct {
+	logger *zap.Logger
+}
+
+func NewICD10Handler(logger *zap.Logger) *ICD10Handler {
+	return &ICD10Handler{logger: logger}
+}
+
+// SearchCodes matches ?q= against code prefixes and descriptions.
+func (h *ICD10Handler) SearchCodes(ctx *gin.Context) {
+	limit := defaultICD10Limit
+	if value := ctx.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxICD10Limit {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxICD10Limit))
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"codes": icd10.Search(ctx.Query("q"), limit)})
+}
+
+func (h *ICD10Handler) GetCode(ctx *gin.Context) {
+	code, ok := icd10.Lookup(ctx.Param("code"))
+	if !ok {
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "ICD-10 code not found")
+		return
+	}
+	ctx.JSON(http.StatusOK, code)
+}
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// requestLogger logs every request once it is done, together with the errors
+// handlers attached with respondInternalError. Only the route template is
+// logged, not the path and query, so that ids and search terms stay out of
+// the logs.
+func requestLogger(logger *zap.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		fields := append(requestFields(ctx),
+			zap.Int("status", ctx.Writer.Status()),
+			zap.Duration("latency", time.Since(start)))
+		if len(ctx.Errors) == 0 {
+			logger.Info("request", fields...)
+			return
+		}
+		for _, e := range ctx.Errors {
+			logger.Error("request failed", append(fields, zap.Error(e.Err))...)
+		}
+	}
+}
+
+// recoverPanics answers 500 when a handler panics. Unlike gin.Recovery it
+// does not dump the request, and logs through the redacting logger.
+func recoverPanics(logger *zap.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered interface{}) {
+		logger.Error("panic while serving request", append(requestFields(ctx),
+			zap.String("panic", fmt.Sprint(recovered)),
+			zap.Stack("stack"))...)
+		respondError(ctx, http.StatusInternalServerError, CodeInternal, "internal server error")
+	})
+}
+
+func requestFields(ctx *gin.Context) []zap.Field {
+	route := ctx.FullPath()
+	if route == "" {
+		route = "unmatched"
+	}
+	return []zap.Field{
+		zap.String("method", ctx.Request.Method),
+		zap.String("route", route),
+		zap.String("client ip", ctx.ClientIP()),
+		zap.String("request id", requestIDFrom(ctx)),
+	}
+}
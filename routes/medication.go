@@ -0,0 +1,305 @@
+package routes
+
+import (
+	"errors"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+var (
+	validMedicationRoutes = map[string]bool{
+		"PO": true, "IV": true, "IM": true, "SC": true, "SL": true,
+		"PR": true, "INH": true, "TOP": true, "TD": true, "NG": true,
+	}
+	validMedicationStatuses = map[string]bool{
+		model.MedicationActive:       true,
+		model.MedicationHeld:         true,
+		model.MedicationDiscontinued: true,
+	}
+	errMedicationDiscontinued = errors.New("medication order is discontinued")
+)
+
+type MedicationHandler struct {
+	logger *zap.Logger
+	repo   repository.Medications
+}
+
+func NewMedicationHandler(logger *zap.Logger, repo repository.Medications) *MedicationHandler {
+	return &MedicationHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type MedicationOrderResp struct {
+	OrderID             int         `json:"order_id"`
+	PatientID           int         `json:"patient_id"`
+	Name                string      `json:"name"`
+	Dose                *float64    `json:"dose"`
+	Unit                *string     `json:"unit"`
+	Route               *string     `json:"route"`
+	Frequency           *string     `json:"frequency"`
+	StartDate           model.Date  `json:"start_date"`
+	StopDate            *model.Date `json:"stop_date"`
+	PrescribingDoctorID *int        `json:"prescribing_doctor_id"`
+	Status              string      `json:"status"`
+	CreatedAt           time.Time   `json:"created_at"`
+	UpdatedAt           time.Time   `json:"updated_at"`
+}
+
+// MedicationOrderReq is the body of POST and PATCH. Name, start_date and
+// prescribing_doctor_id can only be set on POST, status only on PATCH: a new
+// order is always active.
+type MedicationOrderReq struct {
+	Name                *string  `json:"name"`
+	Dose                *float64 `json:"dose"`
+	Unit                *string  `json:"unit"`
+	Route               *string  `json:"route"`
+	Frequency           *string  `json:"frequency"`
+	StartDate           *string  `json:"start_date"`
+	StopDate            *string  `json:"stop_date"`
+	PrescribingDoctorID *int     `json:"prescribing_doctor_id"`
+	Status              *string  `json:"status"`
+}
+
+// ListMedicationOrders returns every order of the patient, optionally
+// filtered by ?status=.
+func (h *MedicationHandler) ListMedicationOrders(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	status := ctx.Query("status")
+	if status != "" && !validMedicationStatuses[status] {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "status must be one of active, held or discontinued")
+		return
+	}
+	orders, err := h.repo.ListMedicationOrders(ctx.Request.Context(), pid, status)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	resp := make([]MedicationOrderResp, 0, len(orders))
+	for _, o := range orders {
+		resp = append(resp, toMedicationOrderResp(o))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"medications": resp})
+}
+
+func (h *MedicationHandler) GetMedicationOrder(ctx *gin.Context) {
+	pid, oid, ok := medicationOrderParams(ctx)
+	if !ok {
+		return
+	}
+	o, err := h.repo.SelectMedicationOrder(ctx.Request.Context(), pid, oid)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toMedicationOrderResp(o))
+}
+
+func (h *MedicationHandler) CreateMedicationOrder(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req MedicationOrderReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	o := model.MedicationOrder{
+		PatientID: pid,
+		StartDate: model.NewDate(time.Now()),
+		Status:    model.MedicationActive,
+	}
+	if err := req.apply(&o, true); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	created, err := h.repo.InsertMedicationOrder(ctx.Request.Context(), o)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, toMedicationOrderResp(created))
+}
+
+// ModifyMedicationOrder changes the dosing or the status of an order. Active
+// and held orders may move between each other or be discontinued, which sets
+// the stop date to today unless one is given. Discontinued orders are final.
+func (h *MedicationHandler) ModifyMedicationOrder(ctx *gin.Context) {
+	pid, oid, ok := medicationOrderParams(ctx)
+	if !ok {
+		return
+	}
+	var req MedicationOrderReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	o, err := h.repo.SelectMedicationOrder(ctx.Request.Context(), pid, oid)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	if err := req.apply(&o, false); err != nil {
+		if errors.Is(err, errMedicationDiscontinued) {
+			respondError(ctx, http.StatusConflict, CodeConflict, "medication order is discontinued and cannot be changed")
+			return
+		}
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	updated, err := h.repo.UpdateMedicationOrder(ctx.Request.Context(), o)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toMedicationOrderResp(updated))
+}
+
+func (h *MedicationHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient or medication order not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, "patient is discharged or the medication order is discontinued")
+	case errors.Is(err, repository.ErrInvalidReference):
+		respondError(ctx, http.StatusUnprocessableEntity, CodeInvalidReference, "prescribing_doctor_id does not exist or the doctor is inactive")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+// apply validates the request and copies it onto o. With create set, name is
+// required and status may not be given; otherwise name, start_date and
+// prescribing_doctor_id are immutable.
+func (req *MedicationOrderReq) apply(o *model.MedicationOrder, create bool) error {
+	if create {
+		if req.Name == nil {
+			return errors.New("name is required")
+		}
+		if req.Status != nil {
+			return errors.New("status cannot be set on a new order")
+		}
+	} else {
+		if o.Status == model.MedicationDiscontinued {
+			return errMedicationDiscontinued
+		}
+		switch {
+		case req.Name != nil:
+			return errors.New("name cannot be changed")
+		case req.StartDate != nil:
+			return errors.New("start_date cannot be changed")
+		case req.PrescribingDoctorID != nil:
+			return errors.New("prescribing_doctor_id cannot be changed")
+		}
+	}
+	if req.Name != nil {
+		if err := validateText("name", *req.Name, 100); err != nil {
+			return err
+		}
+		o.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Dose != nil {
+		if *req.Dose <= 0 {
+			return errors.New("dose must be greater than 0")
+		}
+		o.Dose = req.Dose
+	}
+	if req.Unit != nil {
+		if err := validateText("unit", *req.Unit, 20); err != nil {
+			return err
+		}
+		unit := strings.TrimSpace(*req.Unit)
+		o.Unit = &unit
+	}
+	if req.Route != nil {
+		route := strings.ToUpper(strings.TrimSpace(*req.Route))
+		if !validMedicationRoutes[route] {
+			return errors.New("route must be one of PO, IV, IM, SC, SL, PR, INH, TOP, TD or NG")
+		}
+		o.Route = &route
+	}
+	if req.Frequency != nil {
+		if err := validateText("frequency", *req.Frequency, 50); err != nil {
+			return err
+		}
+		frequency := strings.TrimSpace(*req.Frequency)
+		o.Frequency = &frequency
+	}
+	if req.StartDate != nil {
+		start, err := model.ParseDate(*req.StartDate)
+		if err != nil {
+			return errors.New("start_date must be a date formatted as YYYY-MM-DD")
+		}
+		o.StartDate = start
+	}
+	if req.StopDate != nil {
+		stop, err := model.ParseDate(*req.StopDate)
+		if err != nil {
+			return errors.New("stop_date must be a date formatted as YYYY-MM-DD")
+		}
+		o.StopDate = &stop
+	}
+	if req.PrescribingDoctorID != nil {
+		if *req.PrescribingDoctorID <= 0 {
+			return errors.New("prescribing_doctor_id must be a positive integer")
+		}
+		o.PrescribingDoctorID = req.PrescribingDoctorID
+	}
+	if req.Status != nil {
+		if !validMedicationStatuses[*req.Status] {
+			return errors.New("status must be one of active, held or discontinued")
+		}
+		o.Status = *req.Status
+		if o.Status == model.MedicationDiscontinued && o.StopDate == nil {
+			today := model.NewDate(time.Now())
+			o.StopDate = &today
+		}
+	}
+	if o.StopDate != nil && o.StopDate.Before(o.StartDate.Time) {
+		return errors.New("stop_date must not be before start_date")
+	}
+	return nil
+}
+
+func medicationOrderParams(ctx *gin.Context) (int, int, bool) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return 0, 0, false
+	}
+	oid, err := strconv.Atoi(ctx.Param("order_id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "order id must be an integer")
+		return 0, 0, false
+	}
+	return pid, oid, true
+}
+
+func toMedicationOrderResp(o model.MedicationOrder) MedicationOrderResp {
+	return MedicationOrderResp{
+		OrderID:             o.OrderID,
+		PatientID:           o.PatientID,
+		Name:                o.Name,
+		Dose:                o.Dose,
+		Unit:                o.Unit,
+		Route:               o.Route,
+		Frequency:           o.Frequency,
+		StartDate:           o.StartDate,
+		StopDate:            o.StopDate,
+		PrescribingDoctorID: o.PrescribingDoctorID,
+		Status:              o.Status,
+		CreatedAt:           o.CreatedAt,
+		UpdatedAt:           o.UpdatedAt,
+	}
+}
@@ -0,0 +1,57 @@
+package routes
+
+import (
+	model "health-care-backend/repository/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MedicationOrderReqApply(t *testing.T) {
+	start, _ := model.ParseDate("2023-03-01")
+	req := MedicationOrderReq{
+		Name:      strPtr(" Aspirin "),
+		Dose:      floatPtr(81),
+		Unit:      strPtr("mg"),
+		Route:     strPtr("po"),
+		Frequency: strPtr("once daily"),
+	}
+	o := model.MedicationOrder{StartDate: start, Status: model.MedicationActive}
+	assert.NoError(t, req.apply(&o, true))
+	assert.Equal(t, "Aspirin", o.Name)
+	assert.Equal(t, "PO", *o.Route)
+
+	invalid := map[string]MedicationOrderReq{
+		"missing name":   {Dose: floatPtr(1)},
+		"zero dose":      {Name: strPtr("Aspirin"), Dose: floatPtr(0)},
+		"unknown route":  {Name: strPtr("Aspirin"), Route: strPtr("oral")},
+		"status on post": {Name: strPtr("Aspirin"), Status: strPtr(model.MedicationHeld)},
+		"stop before":    {Name: strPtr("Aspirin"), StopDate: strPtr("2023-02-01")},
+	}
+	for name, req := range invalid {
+		o := model.MedicationOrder{StartDate: start, Status: model.MedicationActive}
+		assert.Error(t, req.apply(&o, true), name)
+	}
+}
+
+func Test_MedicationOrderReqApplyLifecycle(t *testing.T) {
+	start := model.NewDate(time.Now().AddDate(0, 0, -7))
+	o := model.MedicationOrder{Name: "Aspirin", StartDate: start, Status: model.MedicationActive}
+
+	held := MedicationOrderReq{Status: strPtr(model.MedicationHeld)}
+	assert.NoError(t, held.apply(&o, false))
+	assert.Equal(t, model.MedicationHeld, o.Status)
+
+	rename := MedicationOrderReq{Name: strPtr("Ibuprofen")}
+	assert.Error(t, rename.apply(&o, false))
+
+	discontinue := MedicationOrderReq{Status: strPtr(model.MedicationDiscontinued)}
+	assert.NoError(t, discontinue.apply(&o, false))
+	if assert.NotNil(t, o.StopDate) {
+		assert.Equal(t, model.NewDate(time.Now()), *o.StopDate)
+	}
+
+	resume := MedicationOrderReq{Status: strPtr(model.MedicationActive)}
+	assert.ErrorIs(t, resume.apply(&o, false), errMedicationDiscontinued)
+}
@@ -0,0 +1,319 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	validSexes      = map[string]bool{"M": true, "F": true, "O": true}
+	validBloodTypes = map[string]bool{
+		"A+": true, "A-": true, "B+": true, "B-": true,
+		"AB+": true, "AB-": true, "O+": true, "O-": true,
+	}
+	phonePattern = regexp.MustCompile(`^\+?[0-9 ().-]+$`)
+)
+
+type PatientHandler struct {
+	logger *zap.Logger
+	repo   repository.Patients
+}
+
+func NewPatientHandler(logger *zap.Logger, repo repository.Patients) *PatientHandler {
+	return &PatientHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type PatientResp struct {
+	PatientID    int        `json:"patient_id"`
+	FirstName    string     `json:"first_name"`
+	LastName     string     `json:"last_name"`
+	Age          int        `json:"age"`
+	Sex          string     `json:"sex"`
+	BloodType    string     `json:"blood_type"`
+	DOB          time.Time  `json:"dob"`
+	DoctorID     int        `json:"doctor_id"`
+	PhoneNumber  string     `json:"phone_number"`
+	Address      string     `json:"address"`
+	DischargedAt *time.Time `json:"discharged_at"`
+}
+
+// PatientReq is the body of POST, PUT and PATCH. Every field is a pointer so
+// PATCH can tell omitted fields apart; POST and PUT require all of them except
+// PatientID. The database assigns the id, so POST rejects it and PUT and PATCH
+// only accept the id of the path.
+type PatientReq struct {
+	PatientID   *int    `json:"patient_id"`
+	FirstName   *string `json:"first_name"`
+	LastName    *string `json:"last_name"`
+	Sex         *string `json:"sex"`
+	BloodType   *string `json:"blood_type"`
+	DOB         *string `json:"dob"`
+	DoctorID    *int    `json:"doctor_id"`
+	PhoneNumber *string `json:"phone_number"`
+	Address     *string `json:"address"`
+}
+
+func (h *PatientHandler) ListPatients(ctx *gin.Context) {
+	includeDischarged := ctx.Query("include_discharged") == "true"
+	patients, err := h.repo.ListPatients(ctx.Request.Context(), principalFrom(ctx), includeDischarged)
+	if err != nil {
+		respondInternalError(ctx, err)
+		return
+	}
+	resp := make([]PatientResp, 0, len(patients))
+	for _, p := range patients {
+		resp = append(resp, toPatientResp(p))
+		auditPatients(ctx, p.PatientID)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"patients": resp})
+}
+
+func (h *PatientHandler) GetPatient(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	p, err := h.repo.SelectPatient(ctx.Request.Context(), pid)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toPatientResp(p))
+}
+
+func (h *PatientHandler) CreatePatient(ctx *gin.Context) {
+	var req PatientReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.PatientID != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "patient_id is assigned by the server")
+		return
+	}
+	var p model.Patient
+	if err := req.apply(&p, true); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	created, err := h.repo.InsertPatient(ctx.Request.Context(), p)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	auditPatients(ctx, created.PatientID)
+	ctx.JSON(http.StatusCreated, toPatientResp(created))
+}
+
+func (h *PatientHandler) ReplacePatient(ctx *gin.Context) {
+	h.updatePatient(ctx, true)
+}
+
+func (h *PatientHandler) ModifyPatient(ctx *gin.Context) {
+	h.updatePatient(ctx, false)
+}
+
+// DischargePatient handles DELETE: the patient is marked discharged, not removed.
+func (h *PatientHandler) DischargePatient(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	p, err := h.repo.DischargePatient(ctx.Request.Context(), pid)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toPatientResp(p))
+}
+
+func (h *PatientHandler) updatePatient(ctx *gin.Context, replace bool) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	var req PatientReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.PatientID != nil && *req.PatientID != pid {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "patient_id cannot be changed")
+		return
+	}
+	p := model.Patient{PatientID: pid}
+	if !replace {
+		var err error
+		if p, err = h.repo.SelectPatient(ctx.Request.Context(), pid); err != nil {
+			h.writeError(ctx, err)
+			return
+		}
+	}
+	if err := req.apply(&p, replace); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+	updated, err := h.repo.UpdatePatient(ctx.Request.Context(), p)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, toPatientResp(updated))
+}
+
+func (h *PatientHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, "patient already exists or is discharged")
+	case errors.Is(err, repository.ErrInvalidReference):
+		respondError(ctx, http.StatusUnprocessableEntity, CodeInvalidReference, "doctor_id does not exist or the doctor is inactive")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+// apply validates the request and copies it onto p. With all set, every field
+// must be present; otherwise only the present fields are copied.
+func (req *PatientReq) apply(p *model.Patient, all bool) error {
+	if all {
+		switch {
+		case req.FirstName == nil:
+			return errors.New("first_name is required")
+		case req.LastName == nil:
+			return errors.New("last_name is required")
+		case req.Sex == nil:
+			return errors.New("sex is required")
+		case req.BloodType == nil:
+			return errors.New("blood_type is required")
+		case req.DOB == nil:
+			return errors.New("dob is required")
+		case req.DoctorID == nil:
+			return errors.New("doctor_id is required")
+		case req.PhoneNumber == nil:
+			return errors.New("phone_number is required")
+		case req.Address == nil:
+			return errors.New("address is required")
+		}
+	}
+	if req.FirstName != nil {
+		if err := validateText("first_name", *req.FirstName, 50); err != nil {
+			return err
+		}
+		p.FirstName = strings.TrimSpace(*req.FirstName)
+	}
+	if req.LastName != nil {
+		if err := validateText("last_name", *req.LastName, 50); err != nil {
+			return err
+		}
+		p.LastName = strings.TrimSpace(*req.LastName)
+	}
+	if req.Sex != nil {
+		sex := strings.ToUpper(*req.Sex)
+		if !validSexes[sex] {
+			return errors.New("sex must be one of M, F or O")
+		}
+		p.Sex = sex
+	}
+	if req.BloodType != nil {
+		bloodType := strings.ToUpper(*req.BloodType)
+		if !validBloodTypes[bloodType] {
+			return errors.New("blood_type must be one of A+, A-, B+, B-, AB+, AB-, O+ or O-")
+		}
+		p.BloodType = bloodType
+	}
+	if req.DOB != nil {
+		dob, err := time.Parse(dateLayout, *req.DOB)
+		if err != nil {
+			return errors.New("dob must be a date formatted as YYYY-MM-DD")
+		}
+		if dob.After(time.Now()) || dob.Year() < 1900 {
+			return errors.New("dob must be between 1900-01-01 and today")
+		}
+		p.DOB = dob
+	}
+	if req.DoctorID != nil {
+		if *req.DoctorID <= 0 {
+			return errors.New("doctor_id must be a positive integer")
+		}
+		p.DoctorID = *req.DoctorID
+	}
+	if req.PhoneNumber != nil {
+		if !validPhoneNumber(*req.PhoneNumber) {
+			return errors.New("phone_number must contain 7 to 15 digits, optionally separated by spaces, dots, dashes or parentheses")
+		}
+		p.PhoneNumber = *req.PhoneNumber
+	}
+	if req.Address != nil {
+		if err := validateText("address", *req.Address, 50); err != nil {
+			return err
+		}
+		p.Address = strings.TrimSpace(*req.Address)
+	}
+	return nil
+}
+
+func validateText(field, value string, maxLen int) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fmt.Errorf("%s must not be empty", field)
+	}
+	if len(value) > maxLen {
+		return fmt.Errorf("%s must be at most %d characters", field, maxLen)
+	}
+	return nil
+}
+
+func validPhoneNumber(phone string) bool {
+	if len(phone) > 50 || !phonePattern.MatchString(phone) {
+		return false
+	}
+	digits := 0
+	for _, c := range phone {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+func patientIDParam(ctx *gin.Context) (int, bool) {
+	pid, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "patient id must be an integer")
+		return 0, false
+	}
+	return pid, true
+}
+
+func toPatientResp(p model.Patient) PatientResp {
+	return PatientResp{
+		PatientID:    p.PatientID,
+		FirstName:    p.FirstName,
+		LastName:     p.LastName,
+		Age:          p.AgeAt(time.Now()),
+		Sex:          p.Sex,
+		BloodType:    p.BloodType,
+		DOB:          p.DOB,
+		DoctorID:     p.DoctorID,
+		PhoneNumber:  p.PhoneNumber,
+		Address:      p.Address,
+		DischargedAt: p.DischargedAt,
+	}
+}
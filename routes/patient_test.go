@@ -0,0 +1,71 @@
+package routes
+
+import (
+	model "health-care-backend/repository/model"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func strPtr(s string) *string { return &s }
+func intPtr(i int) *int       { return &i }
+
+func validPatientReq() PatientReq {
+	return PatientReq{
+		FirstName:   strPtr("Alice"),
+		LastName:    strPtr("Johnson"),
+		Sex:         strPtr("f"),
+		BloodType:   strPtr("ab+"),
+		DOB:         strPtr("1988-03-12"),
+		DoctorID:    intPtr(1),
+		PhoneNumber: strPtr("(123) 456-7890"),
+		Address:     strPtr("123 Main St"),
+	}
+}
+
+func Test_PatientReqApply(t *testing.T) {
+	req := validPatientReq()
+	var p model.Patient
+	assert.NoError(t, req.apply(&p, true))
+	assert.Equal(t, "F", p.Sex)
+	assert.Equal(t, "AB+", p.BloodType)
+	assert.Equal(t, time.Date(1988, 3, 12, 0, 0, 0, 0, time.UTC), p.DOB)
+
+	invalid := map[string]func(r *PatientReq){
+		"missing field": func(r *PatientReq) { r.Address = nil },
+		"sex":           func(r *PatientReq) { r.Sex = strPtr("X") },
+		"blood type":    func(r *PatientReq) { r.BloodType = strPtr("C+") },
+		"dob format":    func(r *PatientReq) { r.DOB = strPtr("12/03/1988") },
+		"dob future":    func(r *PatientReq) { r.DOB = strPtr(time.Now().AddDate(1, 0, 0).Format(dateLayout)) },
+		"phone letters": func(r *PatientReq) { r.PhoneNumber = strPtr("555-CALL-NOW") },
+		"phone short":   func(r *PatientReq) { r.PhoneNumber = strPtr("12-34") },
+		"empty name":    func(r *PatientReq) { r.FirstName = strPtr("  ") },
+	}
+	for name, mutate := range invalid {
+		req := validPatientReq()
+		mutate(&req)
+		assert.Error(t, req.apply(&model.Patient{}, true), name)
+	}
+}
+
+func Test_PatientReqApplyPartial(t *testing.T) {
+	p := model.Patient{FirstName: "Alice", Sex: "F"}
+	req := PatientReq{LastName: strPtr("Smith")}
+	assert.NoError(t, req.apply(&p, false))
+	assert.Equal(t, "Alice", p.FirstName)
+	assert.Equal(t, "Smith", p.LastName)
+}
+
+func Test_CreatePatientAssignsID(t *testing.T) {
+	api := newTestAPI(t)
+	req := validPatientReq()
+	req.PatientID = intPtr(42)
+	assert.Equal(t, http.StatusBadRequest, api.do(demoAdmin, http.MethodPost, "/api/patients", req, nil))
+
+	var created PatientResp
+	assert.Equal(t, http.StatusCreated, api.do(demoAdmin, http.MethodPost, "/api/patients", validPatientReq(), &created))
+	assert.Equal(t, 5, created.PatientID)
+	assert.Equal(t, model.Patient{DOB: created.DOB}.AgeAt(time.Now()), created.Age)
+}
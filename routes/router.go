@@ -1,7 +1,10 @@
 package routes
 
 import (
+	"health-care-backend/auth"
 	envconfig "health-care-backend/envconfig"
+	"health-care-backend/events"
+	"health-care-backend/policy"
 	"health-care-backend/repository"
 
 	"github.com/gin-contrib/cors"
@@ -14,18 +17,128 @@ func Register(
 	logger *zap.Logger,
 	db *repository.GormDatabase,
 	env *envconfig.Env,
+	authenticator *auth.Authenticator,
+	broker *events.Broker,
+	auditWriter *repository.AuditWriter,
 ) *gin.Engine {
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	router.Use(cors.New(config))
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", requestIDHeader}
+	config.ExposeHeaders = []string{requestIDHeader}
+	router.Use(requestID(), recoverPanics(logger), requestLogger(logger), cors.New(config))
 
 	dashboardRepo := repository.NewDashboardRepo(db)
+	patientRepo := repository.NewPatientRepo(db)
+	staffRepo := repository.NewStaffRepo(db)
+	assignmentRepo := repository.NewAssignmentRepo(db)
+	vitalSignRepo := repository.NewVitalSignRepo(db)
+	medicationRepo := repository.NewMedicationRepo(db)
+	diagnosisRepo := repository.NewDiagnosisRepo(db)
+	userRepo := repository.NewUserRepo(db)
+	accessRepo := repository.NewAccessRepo(db)
+	emergencyGrantRepo := repository.NewEmergencyGrantRepo(db)
+	auditRepo := repository.NewAuditRepo(db)
+	alertRepo := repository.NewAlertRepo(db)
 
-	dashboardHandler := NewDashboardHandler(logger, dashboardRepo)
+	dashboardHandler := NewDashboardHandler(logger, dashboardRepo, accessRepo, broker)
+	patientHandler := NewPatientHandler(logger, patientRepo)
+	staffHandler := NewStaffHandler(logger, staffRepo)
+	assignmentHandler := NewAssignmentHandler(logger, assignmentRepo)
+	vitalSignHandler := NewVitalSignHandler(logger, vitalSignRepo)
+	medicationHandler := NewMedicationHandler(logger, medicationRepo)
+	diagnosisHandler := NewDiagnosisHandler(logger, diagnosisRepo)
+	icd10Handler := NewICD10Handler(logger)
+	authHandler := NewAuthHandler(logger, authenticator, userRepo)
+	emergencyAccessHandler := NewEmergencyAccessHandler(logger, emergencyGrantRepo)
+	auditHandler := NewAuditHandler(logger, auditRepo)
+	alertHandler := NewAlertHandler(logger, alertRepo, accessRepo)
 
-	router.GET("/api/dashboard/patient", dashboardHandler.GetPatientDashboard)
-	router.GET("/api/dashboard/doctor", dashboardHandler.GetDoctorDashboard)
-	router.GET("/api/dashboard/nurse", dashboardHandler.GetNurseDashboard)
+	router.POST("/api/auth/login", authHandler.Login)
+
+	// everything else under /api needs a bearer token of an active account
+	// and a permission of the caller's role, see package policy
+	api := router.Group("/api", auth.Middleware(authenticator), requireActiveAccount(userRepo))
+	can := requirePermission
+	// every read and write of patient data ends up in the audit log
+	audited := auditTrail(logger, auditWriter)
+
+	api.GET("/auth/me", authHandler.Me)
+
+	api.GET("/dashboard/patient", audited, can(policy.ReadPatientDashboard), dashboardHandler.GetPatientDashboard)
+	api.GET("/dashboard/doctor", audited, can(policy.ReadDoctorDashboard), dashboardHandler.GetDoctorDashboard)
+	api.GET("/dashboard/nurse", audited, can(policy.ReadNurseDashboard), dashboardHandler.GetNurseDashboard)
+	// Server-Sent Events, fed by the database via events.Broker
+	api.GET("/dashboard/doctor/stream", audited, can(policy.ReadDoctorDashboard), dashboardHandler.StreamDoctorDashboard)
+	api.GET("/dashboard/nurse/stream", audited, can(policy.ReadNurseDashboard), dashboardHandler.StreamNurseDashboard)
+
+	api.POST("/patients", audited, can(policy.WritePatients), patientHandler.CreatePatient)
+	api.GET("/patients", audited, can(policy.ReadPatients), patientHandler.ListPatients)
+
+	// break-the-glass: access to a patient out of the caller's scope
+	api.POST("/patients/:id/emergency-access", audited, can(policy.RequestEmergencyAccess), emergencyAccessHandler.RequestAccess)
+	api.GET("/emergency-access", audited, can(policy.RequestEmergencyAccess), emergencyAccessHandler.ListOwnGrants)
+	api.DELETE("/emergency-access/:grant_id", audited, can(policy.RequestEmergencyAccess), emergencyAccessHandler.RevokeGrant)
+	api.GET("/reports/emergency-access", audited, can(policy.ReadEmergencyAccessReport), emergencyAccessHandler.Report)
+
+	api.GET("/audit", audited, can(policy.ReadAuditLog), auditHandler.ListEntries)
+	api.GET("/audit/verify", can(policy.ReadAuditLog), auditHandler.VerifyChain)
+
+	// alerts are limited to the caller's patients by the repository
+	api.GET("/alerts", audited, can(policy.ReadAlerts), alertHandler.ListAlerts)
+	api.POST("/alerts/:alert_id/acknowledge", audited, can(policy.WriteAlerts), alertHandler.AcknowledgeAlert)
+	api.POST("/alerts/:alert_id/resolve", audited, can(policy.WriteAlerts), alertHandler.ResolveAlert)
+	api.GET("/alert-rules", can(policy.ReadAlertRules), alertHandler.ListGlobalRules)
+	api.POST("/alert-rules", can(policy.WriteGlobalAlertRules), alertHandler.CreateGlobalRule)
+	api.DELETE("/alert-rules/:rule_id", audited, can(policy.WritePatientAlertRules), alertHandler.DeactivateRule)
+
+	// routes of a single patient are limited to the caller's patients; an
+	// emergency grant lets its holder read but not change the patient
+	patient := api.Group("/patients/:id", audited)
+	read := requirePatientAccess(accessRepo)
+	write := requirePatientManagement(accessRepo)
+
+	patient.GET("", read, can(policy.ReadPatients), patientHandler.GetPatient)
+	patient.PUT("", write, can(policy.WritePatients), patientHandler.ReplacePatient)
+	patient.PATCH("", write, can(policy.WritePatients), patientHandler.ModifyPatient)
+	patient.DELETE("", write, can(policy.WritePatients), patientHandler.DischargePatient)
+
+	patient.GET("/assignments", read, can(policy.ReadAssignments), assignmentHandler.ListAssignments)
+	patient.POST("/nurses", write, can(policy.WriteAssignments), assignmentHandler.AssignNurse)
+	patient.DELETE("/nurses/:nurse_id", write, can(policy.WriteAssignments), assignmentHandler.UnassignNurse)
+	patient.PUT("/doctor", write, can(policy.WriteAssignments), assignmentHandler.ReassignDoctor)
+
+	patient.POST("/vitals", write, can(policy.WriteVitalSigns), vitalSignHandler.RecordVitalSigns)
+	patient.GET("/vitals", read, can(policy.ReadVitalSigns), vitalSignHandler.ListVitalSigns)
+
+	patient.GET("/alerts", read, can(policy.ReadAlerts), alertHandler.ListPatientAlerts)
+	patient.GET("/alert-rules", read, can(policy.ReadAlertRules), alertHandler.ListPatientRules)
+	patient.POST("/alert-rules", write, can(policy.WritePatientAlertRules), alertHandler.CreatePatientRule)
+
+	patient.POST("/medications", write, can(policy.WriteMedications), medicationHandler.CreateMedicationOrder)
+	patient.GET("/medications", read, can(policy.ReadMedications), medicationHandler.ListMedicationOrders)
+	patient.GET("/medications/:order_id", read, can(policy.ReadMedications), medicationHandler.GetMedicationOrder)
+	patient.PATCH("/medications/:order_id", write, can(policy.WriteMedications), medicationHandler.ModifyMedicationOrder)
+
+	patient.POST("/diagnoses", write, can(policy.WriteDiagnoses), diagnosisHandler.CreateDiagnosis)
+	patient.GET("/diagnoses", read, can(policy.ReadDiagnoses), diagnosisHandler.ListDiagnoses)
+	patient.GET("/diagnoses/:diagnosis_id", read, can(policy.ReadDiagnoses), diagnosisHandler.GetDiagnosis)
+	patient.PATCH("/diagnoses/:diagnosis_id", write, can(policy.WriteDiagnoses), diagnosisHandler.ModifyDiagnosis)
+
+	api.GET("/reports/diagnoses", can(policy.ReadReports), diagnosisHandler.CountDiagnoses)
+
+	api.GET("/icd10", can(policy.ReadCodeTables), icd10Handler.SearchCodes)
+	api.GET("/icd10/:code", can(policy.ReadCodeTables), icd10Handler.GetCode)
+
+	for path, kind := range map[string]repository.StaffKind{
+		"/doctors": repository.DoctorStaff,
+		"/nurses":  repository.NurseStaff,
+	} {
+		api.POST(path, can(policy.WriteStaff), staffHandler.Create(kind))
+		api.GET(path, can(policy.ReadStaff), staffHandler.List(kind))
+		api.GET(path+"/:id", can(policy.ReadStaff), staffHandler.Get(kind))
+		api.PUT(path+"/:id", can(policy.WriteStaff), staffHandler.Update(kind))
+		api.DELETE(path+"/:id", can(policy.WriteStaff), staffHandler.Deactivate(kind))
+		api.POST(path+"/:id/reactivate", can(policy.WriteStaff), staffHandler.Reactivate(kind))
+	}
 	return router
 }
@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"health-care-backend/auth"
+	"health-care-backend/envconfig"
+	"health-care-backend/events"
+	repository "health-care-backend/repository"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testAPI serves all routes over a fresh in-memory SQLite database with the
+// demo data.
+type testAPI struct {
+	t      *testing.T
+	db     *repository.GormDatabase
+	auth   *auth.Authenticator
+	router *gin.Engine
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := repository.NewGormDatabase("sqlite::memory:", false)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, db.Migrate())
+	fixtures, err := repository.DemoFixtures()
+	require.NoError(t, err)
+	require.NoError(t, repository.Seed(db, fixtures))
+	a, err := auth.New(auth.Config{HS256Secret: "routes-test-secret-0123456789abcdef", Issuer: "test", TTL: time.Hour})
+	require.NoError(t, err)
+	auditWriter := repository.NewAuditWriter(repository.NewAuditRepo(db), zap.NewNop())
+	t.Cleanup(auditWriter.Close)
+	router := Register(gin.New(), zap.NewNop(), db, &envconfig.Env{}, a, events.NewBroker(), auditWriter)
+	return &testAPI{t: t, db: db, auth: a, router: router}
+}
+
+// Demo users by id, in the order of the demo fixtures.
+var (
+	demoAdmin = auth.Identity{UserID: 1, Username: "admin", Role: "admin"}
+	johnDoe   = auth.Identity{UserID: 2, Username: "john.doe", Role: "doctor", DoctorID: intPtr(1)}
+	janeSmith = auth.Identity{UserID: 3, Username: "jane.smith", Role: "doctor", DoctorID: intPtr(2)}
+	emily     = auth.Identity{UserID: 4, Username: "emily.wilson", Role: "nurse", NurseID: intPtr(1)}
+)
+
+// do sends the request as the identity and decodes a JSON answer into out
+// unless it is nil.
+func (api *testAPI) do(id auth.Identity, method, url string, body interface{}, out interface{}) int {
+	api.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		require.NoError(api.t, json.NewEncoder(&payload).Encode(body))
+	}
+	token, _, err := api.auth.Issue(id)
+	require.NoError(api.t, err)
+	req := httptest.NewRequest(method, url, &payload)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	api.router.ServeHTTP(rec, req)
+	if out != nil {
+		require.NoError(api.t, json.Unmarshal(rec.Body.Bytes(), out), rec.Body.String())
+	}
+	return rec.Code
+}
@@ -0,0 +1,229 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// StaffHandler serves /api/doctors and /api/nurses. Every method takes the
+// staff kind and returns the gin handler for that kind.
+type StaffHandler struct {
+	logger *zap.Logger
+	repo   repository.Staff
+}
+
+func NewStaffHandler(logger *zap.Logger, repo repository.Staff) *StaffHandler {
+	return &StaffHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type DoctorResp struct {
+	DoctorID      int        `json:"doctor_id"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	Active        bool       `json:"active"`
+	DeactivatedAt *time.Time `json:"deactivated_at"`
+}
+
+type NurseResp struct {
+	NurseID       int        `json:"nurse_id"`
+	FirstName     string     `json:"first_name"`
+	LastName      string     `json:"last_name"`
+	Active        bool       `json:"active"`
+	DeactivatedAt *time.Time `json:"deactivated_at"`
+}
+
+// StaffReq is the body of POST and PUT. ID is only honoured on POST and is
+// read from "doctor_id" or "nurse_id" depending on the route.
+type StaffReq struct {
+	DoctorID  *int   `json:"doctor_id"`
+	NurseID   *int   `json:"nurse_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+func (h *StaffHandler) List(kind repository.StaffKind) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		includeInactive := ctx.Query("include_inactive") == "true"
+		members, err := h.repo.ListStaff(ctx.Request.Context(), kind, includeInactive)
+		if err != nil {
+			respondInternalError(ctx, err)
+			return
+		}
+		resp := make([]interface{}, 0, len(members))
+		for _, m := range members {
+			resp = append(resp, toStaffResp(kind, m))
+		}
+		ctx.JSON(http.StatusOK, gin.H{string(kind) + "s": resp})
+	}
+}
+
+func (h *StaffHandler) Get(kind repository.StaffKind) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := staffIDParam(ctx, kind)
+		if !ok {
+			return
+		}
+		m, err := h.repo.SelectStaff(ctx.Request.Context(), kind, id)
+		if err != nil {
+			h.writeError(ctx, kind, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, toStaffResp(kind, m))
+	}
+}
+
+func (h *StaffHandler) Create(kind repository.StaffKind) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		m, ok := bindStaffReq(ctx, kind)
+		if !ok {
+			return
+		}
+		created, err := h.repo.InsertStaff(ctx.Request.Context(), kind, m)
+		if err != nil {
+			h.writeError(ctx, kind, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, toStaffResp(kind, created))
+	}
+}
+
+func (h *StaffHandler) Update(kind repository.StaffKind) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := staffIDParam(ctx, kind)
+		if !ok {
+			return
+		}
+		m, ok := bindStaffReq(ctx, kind)
+		if !ok {
+			return
+		}
+		if m.ID != 0 && m.ID != id {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, string(kind)+"_id cannot be changed")
+			return
+		}
+		m.ID = id
+		updated, err := h.repo.UpdateStaff(ctx.Request.Context(), kind, m)
+		if err != nil {
+			h.writeError(ctx, kind, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, toStaffResp(kind, updated))
+	}
+}
+
+// Deactivate handles DELETE: the staff member is deactivated, not removed,
+// and can no longer log in.
+func (h *StaffHandler) Deactivate(kind repository.StaffKind) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := staffIDParam(ctx, kind)
+		if !ok {
+			return
+		}
+		m, err := h.repo.DeactivateStaff(ctx.Request.Context(), kind, id)
+		if err != nil {
+			h.writeError(ctx, kind, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, toStaffResp(kind, m))
+	}
+}
+
+func (h *StaffHandler) Reactivate(kind repository.StaffKind) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, ok := staffIDParam(ctx, kind)
+		if !ok {
+			return
+		}
+		m, err := h.repo.ReactivateStaff(ctx.Request.Context(), kind, id)
+		if err != nil {
+			h.writeError(ctx, kind, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, toStaffResp(kind, m))
+	}
+}
+
+func (h *StaffHandler) writeError(ctx *gin.Context, kind repository.StaffKind, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, string(kind)+" not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, string(kind)+" already exists or is already in that state")
+	case errors.Is(err, repository.ErrInUse):
+		respondError(ctx, http.StatusConflict, CodeInUse, string(kind)+" is still attending admitted patients")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+func bindStaffReq(ctx *gin.Context, kind repository.StaffKind) (model.StaffMember, bool) {
+	var req StaffReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return model.StaffMember{}, false
+	}
+	if err := validateText("first_name", req.FirstName, 50); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return model.StaffMember{}, false
+	}
+	if err := validateText("last_name", req.LastName, 50); err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return model.StaffMember{}, false
+	}
+	m := model.StaffMember{
+		FirstName: strings.TrimSpace(req.FirstName),
+		LastName:  strings.TrimSpace(req.LastName),
+	}
+	id := req.DoctorID
+	if kind == repository.NurseStaff {
+		id = req.NurseID
+	}
+	if id != nil {
+		if *id <= 0 {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("%s_id must be a positive integer", kind))
+			return model.StaffMember{}, false
+		}
+		m.ID = *id
+	}
+	return m, true
+}
+
+func staffIDParam(ctx *gin.Context, kind repository.StaffKind) (int, bool) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, string(kind)+" id must be an integer")
+		return 0, false
+	}
+	return id, true
+}
+
+func toStaffResp(kind repository.StaffKind, m model.StaffMember) interface{} {
+	if kind == repository.NurseStaff {
+		return NurseResp{
+			NurseID:       m.ID,
+			FirstName:     m.FirstName,
+			LastName:      m.LastName,
+			Active:        m.Active,
+			DeactivatedAt: m.DeactivatedAt,
+		}
+	}
+	return DoctorResp{
+		DoctorID:      m.ID,
+		FirstName:     m.FirstName,
+		LastName:      m.LastName,
+		Active:        m.Active,
+		DeactivatedAt: m.DeactivatedAt,
+	}
+}
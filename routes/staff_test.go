@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_StaffDeactivation(t *testing.T) {
+	api := newTestAPI(t)
+	login := LoginReq{Username: "emily.wilson", Password: "demo-password"}
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodPost, "/api/auth/login", login, nil))
+
+	var errResp ErrorResp
+	assert.Equal(t, http.StatusConflict, api.do(demoAdmin, http.MethodDelete, "/api/doctors/1", nil, &errResp))
+	assert.Equal(t, CodeInUse, errResp.Code)
+	assert.Equal(t, http.StatusForbidden, api.do(johnDoe, http.MethodDelete, "/api/doctors/3", nil, nil))
+
+	var nurse NurseResp
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodDelete, "/api/nurses/1", nil, &nurse))
+	assert.False(t, nurse.Active)
+	assert.Equal(t, http.StatusConflict, api.do(demoAdmin, http.MethodDelete, "/api/nurses/1", nil, &errResp))
+	assert.Equal(t, CodeConflict, errResp.Code)
+	assert.Equal(t, http.StatusUnauthorized, api.do(demoAdmin, http.MethodPost, "/api/auth/login", login, nil))
+	assert.Equal(t, http.StatusNotFound, api.do(demoAdmin, http.MethodDelete, "/api/nurses/99", nil, nil))
+
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodPost, "/api/nurses/1/reactivate", nil, &nurse))
+	assert.True(t, nurse.Active)
+	assert.Equal(t, http.StatusConflict, api.do(demoAdmin, http.MethodPost, "/api/nurses/1/reactivate", nil, nil))
+	assert.Equal(t, http.StatusOK, api.do(demoAdmin, http.MethodPost, "/api/auth/login", login, nil))
+}
@@ -0,0 +1,294 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"health-care-backend/news2"
+	repository "health-care-backend/repository"
+	model "health-care-backend/repository/model"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	maxVitalSignBatch      = 500
+	defaultVitalSignLimit  = 100
+	maxVitalSignLimit      = 1000
+	allowedIssueTimeSkew   = 5 * time.Minute
+	vitalSignTimePrecision = time.Microsecond
+)
+
+// vitalSignRange is the physiologically plausible interval of a reading.
+// Anything outside of it is almost certainly a typo or a sensor fault.
+type vitalSignRange struct {
+	field    string
+	min, max float64
+}
+
+var (
+	bodyTemperatureRange   = vitalSignRange{"body_temperature", 80, 113} // °F
+	pulseRateRange         = vitalSignRange{"pulse_rate", 20, 250}
+	respirationRateRange   = vitalSignRange{"respiration_rate", 4, 70}
+	systolicPressureRange  = vitalSignRange{"systolic_pressure", 50, 300}
+	diastolicPressureRange = vitalSignRange{"diastolic_pressure", 20, 200}
+	oxygenSaturationRange  = vitalSignRange{"oxygen_saturation", 50, 100} // %
+)
+
+func (r vitalSignRange) check(value float64) error {
+	if value < r.min || value > r.max {
+		return fmt.Errorf("%s must be between %g and %g", r.field, r.min, r.max)
+	}
+	return nil
+}
+
+type VitalSignHandler struct {
+	logger *zap.Logger
+	repo   repository.VitalSigns
+}
+
+func NewVitalSignHandler(logger *zap.Logger, repo repository.VitalSigns) *VitalSignHandler {
+	return &VitalSignHandler{
+		logger: logger,
+		repo:   repo,
+	}
+}
+
+type VitalSignResp struct {
+	PatientID          int       `json:"patient_id"`
+	IssueTime          time.Time `json:"issue_time"`
+	BodyTemperature    float64   `json:"body_temperature"`
+	PulseRate          int       `json:"pulse_rate"`
+	RespirationRate    int       `json:"respiration_rate"`
+	SystolicPressure   int       `json:"systolic_pressure"`
+	DiastolicPressure  int       `json:"diastolic_pressure"`
+	OxygenSaturation   *int      `json:"oxygen_saturation"`
+	SupplementalOxygen *bool     `json:"supplemental_oxygen"`
+	Consciousness      *string   `json:"consciousness"`
+}
+
+// VitalSignReq is a single reading. IssueTime defaults to the time the
+// request is received. OxygenSaturation, SupplementalOxygen and
+// Consciousness (ACVPU: A, C, V, P or U) are optional, but without them the
+// NEWS2 score of the reading is incomplete.
+type VitalSignReq struct {
+	IssueTime          *time.Time `json:"issue_time"`
+	BodyTemperature    *float64   `json:"body_temperature"`
+	PulseRate          *int       `json:"pulse_rate"`
+	RespirationRate    *int       `json:"respiration_rate"`
+	SystolicPressure   *int       `json:"systolic_pressure"`
+	DiastolicPressure  *int       `json:"diastolic_pressure"`
+	OxygenSaturation   *int       `json:"oxygen_saturation"`
+	SupplementalOxygen *bool      `json:"supplemental_oxygen"`
+	Consciousness      *string    `json:"consciousness"`
+}
+
+// RecordVitalSigns accepts either a single reading or a JSON array of
+// readings. A batch is stored atomically and answered with an array. A
+// reading without issue_time is taken now, so in a batch of several readings
+// each one needs its own issue_time.
+func (h *VitalSignHandler) RecordVitalSigns(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	body, err := ctx.GetRawData()
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	batch := bytes.HasPrefix(bytes.TrimSpace(body), []byte("["))
+	var reqs []VitalSignReq
+	if batch {
+		err = json.Unmarshal(body, &reqs)
+	} else {
+		reqs = make([]VitalSignReq, 1)
+		err = json.Unmarshal(body, &reqs[0])
+	}
+	if err != nil {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "invalid request body")
+		return
+	}
+	if len(reqs) == 0 || len(reqs) > maxVitalSignBatch {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("a batch must hold between 1 and %d readings", maxVitalSignBatch))
+		return
+	}
+
+	// readings are unique per patient and issue time, so only a single one
+	// may default to now
+	now := time.Now().UTC().Truncate(vitalSignTimePrecision)
+	readings := make([]model.VitalSign, 0, len(reqs))
+	issuedBy := make(map[int64]int, len(reqs))
+	for i, req := range reqs {
+		v, err := req.toVitalSign(pid, now)
+		switch {
+		case err != nil:
+		case len(reqs) > 1 && req.IssueTime == nil:
+			err = errors.New("issue_time is required when a batch holds several readings")
+		default:
+			if j, ok := issuedBy[v.IssueTime.UnixNano()]; ok {
+				err = fmt.Errorf("issue_time is the same as that of reading %d", j)
+			}
+		}
+		if err != nil {
+			msg := err.Error()
+			if batch {
+				msg = fmt.Sprintf("reading %d: %s", i, msg)
+			}
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, msg)
+			return
+		}
+		issuedBy[v.IssueTime.UnixNano()] = i
+		readings = append(readings, v)
+	}
+
+	recorded, err := h.repo.InsertVitalSigns(ctx.Request.Context(), pid, readings)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	if !batch {
+		ctx.JSON(http.StatusCreated, toVitalSignResp(recorded[0]))
+		return
+	}
+	resp := make([]VitalSignResp, 0, len(recorded))
+	for _, v := range recorded {
+		resp = append(resp, toVitalSignResp(v))
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"vital_signs": resp})
+}
+
+// ListVitalSigns returns the most recent readings within the optional
+// from/to window (RFC 3339), oldest first.
+func (h *VitalSignHandler) ListVitalSigns(ctx *gin.Context) {
+	pid, ok := patientIDParam(ctx)
+	if !ok {
+		return
+	}
+	var from, to *time.Time
+	for param, dst := range map[string]**time.Time{"from": &from, "to": &to} {
+		value := ctx.Query(param)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, param+" must be an RFC 3339 timestamp")
+			return
+		}
+		t = t.UTC()
+		*dst = &t
+	}
+	if from != nil && to != nil && from.After(*to) {
+		respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, "from must not be after to")
+		return
+	}
+	limit := defaultVitalSignLimit
+	if value := ctx.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxVitalSignLimit {
+			respondError(ctx, http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxVitalSignLimit))
+			return
+		}
+	}
+
+	readings, err := h.repo.SelectVitalSigns(ctx.Request.Context(), pid, from, to, limit)
+	if err != nil {
+		h.writeError(ctx, err)
+		return
+	}
+	resp := make([]VitalSignResp, 0, len(readings))
+	for _, v := range readings {
+		resp = append(resp, toVitalSignResp(v))
+	}
+	ctx.JSON(http.StatusOK, gin.H{"vital_signs": resp})
+}
+
+func (h *VitalSignHandler) writeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		respondError(ctx, http.StatusNotFound, CodeNotFound, "patient not found")
+	case errors.Is(err, repository.ErrConflict):
+		respondError(ctx, http.StatusConflict, CodeConflict, "patient is discharged or a reading with the same issue_time exists")
+	default:
+		respondInternalError(ctx, err)
+	}
+}
+
+// toVitalSign checks that every required value is present and that all
+// values are plausible.
+func (req VitalSignReq) toVitalSign(pid int, now time.Time) (model.VitalSign, error) {
+	if req.BodyTemperature == nil || req.PulseRate == nil || req.RespirationRate == nil ||
+		req.SystolicPressure == nil || req.DiastolicPressure == nil {
+		return model.VitalSign{}, errors.New("body_temperature, pulse_rate, respiration_rate, systolic_pressure and diastolic_pressure are required")
+	}
+	v := model.VitalSign{
+		PatientID:          pid,
+		IssueTime:          now,
+		BodyTemperature:    *req.BodyTemperature,
+		PulseRate:          *req.PulseRate,
+		RespirationRate:    *req.RespirationRate,
+		SystolicPressure:   *req.SystolicPressure,
+		DiastolicPressure:  *req.DiastolicPressure,
+		OxygenSaturation:   req.OxygenSaturation,
+		SupplementalOxygen: req.SupplementalOxygen,
+	}
+	if req.Consciousness != nil {
+		level := strings.ToUpper(strings.TrimSpace(*req.Consciousness))
+		if !news2.ValidConsciousness(level) {
+			return model.VitalSign{}, errors.New("consciousness must be one of A, C, V, P or U")
+		}
+		v.Consciousness = &level
+	}
+	if v.OxygenSaturation != nil {
+		if err := oxygenSaturationRange.check(float64(*v.OxygenSaturation)); err != nil {
+			return model.VitalSign{}, err
+		}
+	}
+	if req.IssueTime != nil {
+		v.IssueTime = req.IssueTime.UTC().Truncate(vitalSignTimePrecision)
+		if v.IssueTime.After(now.Add(allowedIssueTimeSkew)) {
+			return model.VitalSign{}, errors.New("issue_time must not be in the future")
+		}
+	}
+	for _, c := range []struct {
+		r     vitalSignRange
+		value float64
+	}{
+		{bodyTemperatureRange, v.BodyTemperature},
+		{pulseRateRange, float64(v.PulseRate)},
+		{respirationRateRange, float64(v.RespirationRate)},
+		{systolicPressureRange, float64(v.SystolicPressure)},
+		{diastolicPressureRange, float64(v.DiastolicPressure)},
+	} {
+		if err := c.r.check(c.value); err != nil {
+			return model.VitalSign{}, err
+		}
+	}
+	if v.SystolicPressure <= v.DiastolicPressure {
+		return model.VitalSign{}, errors.New("systolic_pressure must be greater than diastolic_pressure")
+	}
+	return v, nil
+}
+
+func toVitalSignResp(v model.VitalSign) VitalSignResp {
+	return VitalSignResp{
+		PatientID:          v.PatientID,
+		IssueTime:          v.IssueTime,
+		BodyTemperature:    v.BodyTemperature,
+		PulseRate:          v.PulseRate,
+		RespirationRate:    v.RespirationRate,
+		SystolicPressure:   v.SystolicPressure,
+		DiastolicPressure:  v.DiastolicPressure,
+		OxygenSaturation:   v.OxygenSaturation,
+		SupplementalOxygen: v.SupplementalOxygen,
+		Consciousness:      v.Consciousness,
+	}
+}
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func floatPtr(f float64) *float64 { return &f }
+
+func validVitalSignReq() VitalSignReq {
+	return VitalSignReq{
+		BodyTemperature:   floatPtr(98.6),
+		PulseRate:         intPtr(70),
+		RespirationRate:   intPtr(18),
+		SystolicPressure:  intPtr(120),
+		DiastolicPressure: intPtr(80),
+	}
+}
+
+func Test_VitalSignReqToVitalSign(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC)
+	v, err := validVitalSignReq().toVitalSign(1, now)
+	assert.NoError(t, err)
+	assert.Equal(t, now, v.IssueTime)
+	assert.Equal(t, 1, v.PatientID)
+	assert.Nil(t, v.OxygenSaturation)
+
+	req := validVitalSignReq()
+	req.OxygenSaturation = intPtr(95)
+	req.Consciousness = strPtr(" v ")
+	v, err = req.toVitalSign(1, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "V", *v.Consciousness)
+
+	invalid := map[string]func(r *VitalSignReq){
+		"missing pulse":   func(r *VitalSignReq) { r.PulseRate = nil },
+		"temperature":     func(r *VitalSignReq) { r.BodyTemperature = floatPtr(37) },
+		"pulse":           func(r *VitalSignReq) { r.PulseRate = intPtr(400) },
+		"respiration":     func(r *VitalSignReq) { r.RespirationRate = intPtr(0) },
+		"inverted bp":     func(r *VitalSignReq) { r.SystolicPressure = intPtr(70) },
+		"future reading":  func(r *VitalSignReq) { future := now.Add(time.Hour); r.IssueTime = &future },
+		"diastolic range": func(r *VitalSignReq) { r.DiastolicPressure = intPtr(5) },
+		"spo2 range":      func(r *VitalSignReq) { r.OxygenSaturation = intPtr(101) },
+		"consciousness":   func(r *VitalSignReq) { r.Consciousness = strPtr("X") },
+	}
+	for name, mutate := range invalid {
+		req := validVitalSignReq()
+		mutate(&req)
+		_, err := req.toVitalSign(1, now)
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_RecordVitalSignBatchNeedsIssueTimes(t *testing.T) {
+	api := newTestAPI(t)
+	var errResp ErrorResp
+	batch := []VitalSignReq{validVitalSignReq(), validVitalSignReq()}
+	assert.Equal(t, http.StatusBadRequest, api.do(johnDoe, http.MethodPost, "/api/patients/1/vitals", batch, &errResp))
+	assert.Equal(t, CodeInvalidRequest, errResp.Code)
+	assert.Contains(t, errResp.Error, "reading 0: issue_time is required")
+
+	issued := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	batch[0].IssueTime = &issued
+	batch[1].IssueTime = &issued
+	assert.Equal(t, http.StatusBadRequest, api.do(johnDoe, http.MethodPost, "/api/patients/1/vitals", batch, &errResp))
+	assert.Contains(t, errResp.Error, "reading 1: issue_time is the same as that of reading 0")
+
+	later := issued.Add(time.Minute)
+	batch[1].IssueTime = &later
+	var resp struct {
+		VitalSigns []VitalSignResp `json:"vital_signs"`
+	}
+	assert.Equal(t, http.StatusCreated, api.do(johnDoe, http.MethodPost, "/api/patients/1/vitals", batch, &resp))
+	assert.Len(t, resp.VitalSigns, 2)
+
+	// a single reading still defaults to now
+	assert.Equal(t, http.StatusCreated, api.do(johnDoe, http.MethodPost, "/api/patients/1/vitals", validVitalSignReq(), nil))
+}